@@ -1,13 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
 
 	"investment_committee/internal/api/router"
 	"investment_committee/internal/config"
 	"investment_committee/internal/db"
 	"investment_committee/internal/db/queries"
+	"investment_committee/internal/phase/phase1"
+	"investment_committee/internal/phase1/fetcher"
+	"investment_committee/internal/phase1/fetcher/vcr"
+	"investment_committee/internal/phase1/sentiment"
 )
 
 func main() {
@@ -19,16 +33,173 @@ func main() {
 	}
 	defer conn.Close()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	settings, err := fetcherSettings(cfg)
+	if err != nil {
+		log.Fatalf("fetcher config: %v", err)
+	}
+
+	scorer, err := sentimentScorer(cfg)
+	if err != nil {
+		log.Fatalf("sentiment lexicons: %v", err)
+	}
+
 	repo := queries.NewRepository(conn)
-	r := router.New(repo, cfg.APIKey)
+	executor := phase1.NewExecutor(repo, fetcher.NewDefaultRegistry(settings))
+	executor.Sentiment = scorer
+	go executor.Run(ctx)
+	go phase1.NewScheduler(repo, executor).Run(ctx)
+
+	r := router.New(repo, cfg.APIKey, cfg.WebhookSecret, executor)
 
 	srv := &http.Server{
 		Addr:    cfg.Addr,
 		Handler: r,
 	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
 
 	log.Printf("listening on %s", cfg.Addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("server: %v", err)
 	}
 }
+
+// fetcherSettings enables the real EDGAR fetcher once a contact User-Agent is
+// configured, as SEC rejects anonymous clients, and the EDINET fetcher once an
+// API key or a fixture base URL is set. IR feeds need no credentials.
+// FETCH_RATE_LIMITS and FETCH_MAX_ATTEMPTS adjust the default policies.
+func fetcherSettings(cfg config.Config) (fetcher.Settings, error) {
+	s := fetcher.Settings{
+		IR: &fetcher.IRFeedConfig{},
+	}
+	if cfg.SECUserAgent != "" {
+		s.SEC = &fetcher.SECConfig{
+			DataBaseURL:     cfg.SECDataBaseURL,
+			ArchivesBaseURL: cfg.SECArchivesBaseURL,
+			UserAgent:       cfg.SECUserAgent,
+		}
+	}
+	if cfg.EDINETAPIKey != "" || cfg.EDINETBaseURL != "" {
+		s.EDINET = &fetcher.EDINETConfig{
+			BaseURL: cfg.EDINETBaseURL,
+			APIKey:  cfg.EDINETAPIKey,
+		}
+	}
+
+	if cfg.FetchVCRMode != "" {
+		if err := useCassettes(&s, cfg); err != nil {
+			return s, err
+		}
+	}
+
+	limits, err := fetcher.ParseRateLimits(cfg.FetchRateLimits)
+	if err != nil {
+		return s, err
+	}
+	attempts := 0
+	if cfg.FetchMaxAttempts != "" {
+		if attempts, err = strconv.Atoi(cfg.FetchMaxAttempts); err != nil || attempts < 1 {
+			return s, fmt.Errorf("FETCH_MAX_ATTEMPTS %q: must be a positive integer", cfg.FetchMaxAttempts)
+		}
+	}
+	s.Policies = fetcher.DefaultPolicies()
+	for name, rps := range limits {
+		p, ok := s.Policies[name]
+		if !ok {
+			p = fetcher.DefaultPolicy()
+		}
+		p.RequestsPerSecond = rps
+		s.Policies[name] = p
+	}
+	if attempts > 0 {
+		for name, p := range s.Policies {
+			p.MaxAttempts = attempts
+			s.Policies[name] = p
+		}
+	}
+	return s, nil
+}
+
+// sentimentScorer scores documents with the built-in lexicons, replaced per
+// language by the JSON lexicon files of SENTIMENT_LEXICON_EN and
+// SENTIMENT_LEXICON_JA. SENTIMENT_LEXICON_EN is how a deployment scores
+// English with the Loughran-McDonald dictionary.
+func sentimentScorer(cfg config.Config) (*sentiment.Scorer, error) {
+	lexicons := []sentiment.Lexicon{sentiment.English, sentiment.Japanese}
+	for _, f := range []struct{ path, language string }{
+		{cfg.SentimentLexiconEN, "en"},
+		{cfg.SentimentLexiconJA, "ja"},
+	} {
+		if f.path == "" {
+			continue
+		}
+		l, err := sentiment.LoadLexicon(f.path)
+		if err != nil {
+			return nil, err
+		}
+		l.Language = f.language
+		lexicons = append(lexicons, l)
+	}
+	return sentiment.New(lexicons...)
+}
+
+// useCassettes routes the real fetchers through vcr cassettes,
+// FETCH_VCR_DIR/<source>.json. Replay enables every source that has a
+// cassette, without credentials, and sets its clock to the recording time so
+// that a run fetches the recorded day.
+func useCassettes(s *fetcher.Settings, cfg config.Config) error {
+	mode, err := vcr.ParseMode(cfg.FetchVCRMode)
+	if err != nil {
+		return err
+	}
+	dir := cfg.FetchVCRDir
+	if dir == "" {
+		dir = "cassettes"
+	}
+	open := func(source string, enabled bool) (*vcr.Recorder, error) {
+		if !enabled && mode == vcr.ModeRecord {
+			return nil, nil
+		}
+		rec, err := vcr.New(filepath.Join(dir, source+".json"), mode, nil)
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Printf("fetchers: no %s cassette in %s", source, dir)
+			return nil, nil
+		}
+		return rec, err
+	}
+
+	rec, err := open("sec", s.SEC != nil)
+	if err != nil {
+		return err
+	}
+	if rec != nil {
+		if s.SEC == nil {
+			s.SEC = &fetcher.SECConfig{UserAgent: "vcr replay"}
+		}
+		s.SEC.Client = rec.Client()
+	}
+	if rec, err = open("edinet", s.EDINET != nil); err != nil {
+		return err
+	}
+	if rec != nil {
+		if s.EDINET == nil {
+			s.EDINET = &fetcher.EDINETConfig{}
+		}
+		s.EDINET.Client, s.EDINET.Now = rec.Client(), rec.Now
+	}
+	if rec, err = open("ir", s.IR != nil); err != nil {
+		return err
+	}
+	if rec != nil {
+		s.IR.Client, s.IR.Now = rec.Client(), rec.Now
+	}
+	log.Printf("fetchers: vcr %s from %s", mode, dir)
+	return nil
+}
@@ -2,4 +2,7 @@ module investment_committee
 
 go 1.22
 
-require github.com/lib/pq v1.10.9
+require (
+	github.com/lib/pq v1.10.9
+	golang.org/x/text v0.22.0
+)
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/phase1/classify"
+)
+
+var eventEntityTypes = map[string]bool{"ticker": true, "industry": true, "theme": true, "macro": true}
+
+// HandleEvents serves GET /events: structured events across runs, filtered
+// by entity, category, minimum confidence and observed_at range, most
+// recently observed first.
+func (s *Server) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	var f EventFilterInput
+	if v := q.Get("entity_type"); v != "" {
+		if !eventEntityTypes[v] {
+			WriteError(w, http.StatusBadRequest, "invalid entity_type")
+			return
+		}
+		f.EntityType = &v
+	}
+	if v := q.Get("entity_id"); v != "" {
+		if f.EntityType == nil {
+			WriteError(w, http.StatusBadRequest, "entity_id requires entity_type")
+			return
+		}
+		f.EntityID = &v
+	}
+	if v := q.Get("category"); v != "" {
+		if !classify.IsCategory(v) {
+			WriteError(w, http.StatusBadRequest, "invalid category")
+			return
+		}
+		f.Category = &v
+	}
+	if v := q.Get("min_confidence"); v != "" {
+		c, err := strconv.ParseFloat(v, 64)
+		if err != nil || c < 0 || c > 1 {
+			WriteError(w, http.StatusBadRequest, "invalid min_confidence")
+			return
+		}
+		f.MinConfidence = &c
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeParam(v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid "+p.name)
+			return
+		}
+		*p.dst = &t
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		WriteError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	f.Limit, _ = strconv.Atoi(q.Get("limit"))
+	f.Cursor = q.Get("cursor")
+	if _, err := queries.ParseEventCursor(f.Cursor); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+
+	items, cursor, err := s.store.ListEvents(r.Context(), f)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "list failed")
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"items":       items,
+		"next_cursor": cursor,
+	})
+}
+
+// parseTimeParam accepts RFC 3339 timestamps and UTC dates (2006-01-02).
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
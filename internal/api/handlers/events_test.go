@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// eventsStore records the filter of ListEvents; other Store methods panic.
+type eventsStore struct {
+	Store
+	got *EventFilterInput
+}
+
+func (s eventsStore) ListEvents(_ Context, f EventFilterInput) ([]EventOutput, *string, error) {
+	*s.got = f
+	return []EventOutput{}, nil, nil
+}
+
+func TestHandleEventsFilters(t *testing.T) {
+	var got EventFilterInput
+	srv := NewServer(eventsStore{got: &got}, nil, "")
+
+	rec := httptest.NewRecorder()
+	srv.HandleEvents(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/events?entity_type=ticker&entity_id=AAPL&category=earnings&min_confidence=0.7&from=2024-10-01&to=2024-10-31T00:00:00Z&limit=20", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	if *got.EntityType != "ticker" || *got.EntityID != "AAPL" || *got.Category != "earnings" || *got.MinConfidence != 0.7 || got.Limit != 20 {
+		t.Fatalf("filter = %+v", got)
+	}
+	if !got.From.Equal(time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)) || !got.To.Equal(time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("range = %s..%s", got.From, got.To)
+	}
+
+	for _, q := range []string{
+		"entity_id=AAPL",
+		"entity_type=company",
+		"category=rumor",
+		"min_confidence=1.5",
+		"from=yesterday",
+		"from=2024-10-31&to=2024-10-01",
+		"cursor=2024-10-01",
+	} {
+		rec := httptest.NewRecorder()
+		srv.HandleEvents(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status=%d", q, rec.Code)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"investment_committee/internal/db/queries"
 	"investment_committee/internal/domain"
 )
 
@@ -21,11 +24,26 @@ func (s *Server) HandleHandoffs(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	events, _, err := s.store.ListPhase1RunEventsByRunID(r.Context(), in.RunID, 200, "")
+	in, err := s.withRunEvents(r.Context(), in)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "list failed")
+		WriteError(w, http.StatusInternalServerError, "create failed")
+		return
+	}
+	id, err := s.store.CreateHandoff(r.Context(), in)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "create failed")
 		return
 	}
+	WriteJSON(w, http.StatusOK, map[string]string{"id": id, "status": "created"})
+}
+
+// withRunEvents attaches the run's Phase1 events (packet version 1) to a
+// validated handoff.
+func (s *Server) withRunEvents(ctx Context, in HandoffInput) (HandoffInput, error) {
+	events, err := s.allPhase1RunEvents(ctx, in.RunID)
+	if err != nil {
+		return in, err
+	}
 	if in.Packet == nil {
 		in.Packet = map[string]any{}
 	}
@@ -34,12 +52,41 @@ func (s *Server) HandleHandoffs(w http.ResponseWriter, r *http.Request) {
 	in.Packet["phase1"] = phase1Packet
 	in.Packet["version"] = 1
 	in.Packet["phases"] = map[string]any{"phase1": phase1Packet}
-	id, err := s.store.CreateHandoff(r.Context(), in)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "create failed")
-		return
+	return in, nil
+}
+
+// allPhase1RunEvents pages through every Phase1 event of a run.
+func (s *Server) allPhase1RunEvents(ctx Context, runID string) ([]Phase1RunEvent, error) {
+	var out []Phase1RunEvent
+	cursor := ""
+	for {
+		page, next, err := s.store.ListPhase1RunEventsByRunID(ctx, runID, 200, cursor)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+		if next == nil || *next == "" {
+			return out, nil
+		}
+		cursor = *next
+	}
+}
+
+// allEventsByRun pages through every event extracted in a run.
+func (s *Server) allEventsByRun(ctx Context, runID string) ([]EventOutput, error) {
+	var out []EventOutput
+	cursor := ""
+	for {
+		page, next, err := s.store.ListEventsByRun(ctx, runID, 200, cursor)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+		if next == nil || *next == "" {
+			return out, nil
+		}
+		cursor = *next
 	}
-	WriteJSON(w, http.StatusOK, map[string]string{"id": id, "status": "created"})
 }
 
 func (s *Server) HandleHandoff(w http.ResponseWriter, r *http.Request, rest []string) {
@@ -52,6 +99,10 @@ func (s *Server) HandleHandoff(w http.ResponseWriter, r *http.Request, rest []st
 		WriteJSON(w, http.StatusOK, h)
 		return
 	}
+	if len(rest) == 1 && r.Method == http.MethodPatch {
+		s.patchHandoff(w, r, rest[0])
+		return
+	}
 	if len(rest) == 2 && rest[1] == "attach-case" && r.Method == http.MethodPost {
 		var body struct {
 			Case CaseInput `json:"case"`
@@ -71,6 +122,67 @@ func (s *Server) HandleHandoff(w http.ResponseWriter, r *http.Request, rest []st
 	WriteError(w, http.StatusNotFound, "not found")
 }
 
+// patchHandoff merges body.payload into the packet's payload while the
+// handoff is still created, e.g. to edit a generated summary_md before the
+// next phase consumes it. A null value removes the key.
+func (s *Server) patchHandoff(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Payload map[string]any `json:"payload"`
+	}
+	if err := DecodeJSON(r, &body); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	h, err := s.store.GetHandoff(r.Context(), id)
+	if err != nil {
+		WriteError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if h.Status != "created" {
+		WriteError(w, http.StatusConflict, "handoff not editable")
+		return
+	}
+	payload, _ := h.Packet["payload"].(map[string]any)
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	for k, v := range body.Payload {
+		if v == nil {
+			delete(payload, k)
+			continue
+		}
+		payload[k] = v
+	}
+	h.Packet["payload"] = payload
+	in := HandoffInput{
+		RunID:       h.RunID,
+		CaseID:      h.CaseID,
+		HandoffType: h.HandoffType,
+		FromPhase:   h.FromPhase,
+		ToPhase:     h.ToPhase,
+		Packet:      h.Packet,
+	}
+	if err := validateHandoffPacket(in); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	err = s.store.UpdateHandoffPacket(r.Context(), id, h.Packet)
+	if errors.Is(err, queries.ErrNotFound) {
+		WriteError(w, http.StatusConflict, "handoff not editable")
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	h, err = s.store.GetHandoff(r.Context(), id)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "get failed")
+		return
+	}
+	WriteJSON(w, http.StatusOK, h)
+}
+
 func validateHandoffPacket(in HandoffInput) error {
 	if in.FromPhase != 1 {
 		return errInvalid("from_phase must be 1")
@@ -174,9 +286,10 @@ func buildPhase1Packet(runID string, events []Phase1RunEvent) map[string]any {
 	inputs := make([]domain.Phase1EventProjectionInput, 0, len(events))
 	for _, e := range events {
 		inputs = append(inputs, domain.Phase1EventProjectionInput{
-			EventType: e.EventType,
-			Source:    e.Source,
-			Seq:       e.Seq,
+			EventType:        e.EventType,
+			Source:           e.Source,
+			Seq:              e.Seq,
+			DocumentClusters: documentClusters(e),
 		})
 	}
 	meta := domain.ProjectPhase1Events(inputs)
@@ -187,6 +300,29 @@ func buildPhase1Packet(runID string, events []Phase1RunEvent) map[string]any {
 	}
 }
 
+// documentClusters returns the near-duplicate cluster of each document of a
+// doc.fetched event: its cluster_id, else its raw item or document id, else
+// its position. It returns nil when the payload lists no documents.
+func documentClusters(e Phase1RunEvent) []string {
+	docs, ok := e.Payload["documents"].([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(docs))
+	for i, raw := range docs {
+		d, _ := raw.(map[string]any)
+		key := fmt.Sprintf("%d#%d", e.Seq, i)
+		for _, k := range []string{"cluster_id", "raw_item_id", "doc_id"} {
+			if v, ok := d[k].(string); ok && v != "" {
+				key = v
+				break
+			}
+		}
+		out = append(out, key)
+	}
+	return out
+}
+
 func validatePhase1Packet(raw any, runID string) error {
 	phase1, ok := raw.(map[string]any)
 	if !ok {
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/domain"
+)
+
+// handleGenerateHandoff builds a handoff packet from a finalized run's trigger
+// decision, anomaly summary and events. ?handoff_type=light|heavy forces the
+// type, e.g. to hand off a run whose decision is none.
+func (s *Server) handleGenerateHandoff(w http.ResponseWriter, r *http.Request, runID string) {
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	td, err := s.store.GetTriggerDecisionByRun(r.Context(), runID)
+	if errors.Is(err, queries.ErrNotFound) {
+		WriteError(w, http.StatusConflict, "run not finalized")
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "get failed")
+		return
+	}
+	var decision domain.TriggerDecision
+	if err := remarshal(td.Decision, &decision); err != nil {
+		WriteError(w, http.StatusInternalServerError, "invalid trigger decision")
+		return
+	}
+	handoffType := decision.Type
+	if t := r.URL.Query().Get("handoff_type"); t != "" {
+		if t != domain.TriggerTypeLight && t != domain.TriggerTypeHeavy {
+			WriteError(w, http.StatusBadRequest, "handoff_type must be light or heavy")
+			return
+		}
+		handoffType = t
+	}
+	if handoffType == domain.TriggerTypeNone {
+		WriteError(w, http.StatusConflict, "no handoff triggered")
+		return
+	}
+
+	// Checked again when the handoff is stored; this only saves building a
+	// packet that would be rejected.
+	existing, err := s.store.ListHandoffsByRun(r.Context(), runID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "list failed")
+		return
+	}
+	for _, h := range existing {
+		if h.HandoffType == handoffType {
+			WriteError(w, http.StatusConflict, "handoff already exists")
+			return
+		}
+	}
+
+	var anomalies domain.AnomalySummary
+	if a, err := s.store.GetAnomalySummaryByRun(r.Context(), runID); err == nil {
+		_ = remarshal(a.Summary, &anomalies)
+	}
+	events, err := s.allEventsByRun(r.Context(), runID)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "list failed")
+		return
+	}
+
+	in, err := generateHandoff(runID, handoffType, decision, anomalies, events, time.Now().UTC())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := validateHandoffPacket(in); err != nil {
+		WriteError(w, http.StatusInternalServerError, "generated packet invalid: "+err.Error())
+		return
+	}
+	in, err = s.withRunEvents(r.Context(), in)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "create failed")
+		return
+	}
+	id, err := s.store.CreateGeneratedHandoff(r.Context(), in)
+	if errors.Is(err, queries.ErrHandoffExists) {
+		WriteError(w, http.StatusConflict, "handoff already exists")
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "create failed")
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"id":           id,
+		"status":       "created",
+		"handoff_type": in.HandoffType,
+		"to_phase":     in.ToPhase,
+	})
+}
+
+// generateHandoff assembles a packet that passes validateHandoffPacket. The
+// packet goes through a JSON round trip so numbers and slices have the same
+// types as a client-submitted one.
+func generateHandoff(runID, handoffType string, d domain.TriggerDecision, a domain.AnomalySummary, events []EventOutput, now time.Time) (HandoffInput, error) {
+	toPhase := 5
+	if handoffType == domain.TriggerTypeHeavy {
+		toPhase = 3
+	}
+
+	universeIDs := []string{}
+	for _, c := range d.Candidates {
+		if c.UniverseItemID != "" {
+			universeIDs = append(universeIDs, c.UniverseItemID)
+		}
+	}
+	eventIDs := []string{}
+	for _, e := range events {
+		eventIDs = append(eventIDs, e.EventID)
+	}
+
+	keyMetrics := map[string]any{
+		"candidate_count": len(d.Candidates),
+		"event_count":     len(events),
+		"anomaly_count":   len(a.Anomalies),
+		"ruleset_version": d.RulesetVersion,
+	}
+	entities := map[string]any{}
+	for _, c := range d.Candidates {
+		entities[c.EntityID] = map[string]any{
+			"score":        c.Score,
+			"level":        c.Level,
+			"doc_count":    c.DocCount,
+			"signal_count": c.SignalCount,
+			"max_severity": c.MaxSeverity,
+		}
+	}
+	keyMetrics["entities"] = entities
+
+	payload := map[string]any{
+		"summary_md":  handoffSummaryMD(runID, handoffType, d, a, events),
+		"key_metrics": keyMetrics,
+		"candidates":  d.Candidates,
+	}
+	if handoffType == domain.TriggerTypeLight {
+		payload["hypothesis_seeds"] = hypothesisSeeds(d, a)
+	} else {
+		payload["industry_scope"] = industryScope(d)
+		payload["value_pool_notes"] = valuePoolNotes(a, events)
+		payload["key_questions"] = keyQuestions(d, a)
+	}
+
+	packet := map[string]any{
+		"handoff_type":        handoffType,
+		"from_phase":          1,
+		"to_phase":            toPhase,
+		"universe_item_ids":   universeIDs,
+		"event_ids":           eventIDs,
+		"trigger_decision_id": runID,
+		"created_at":          now.Format(time.RFC3339),
+		"generated":           true,
+		"payload":             payload,
+	}
+	var normalized map[string]any
+	if err := remarshal(packet, &normalized); err != nil {
+		return HandoffInput{}, err
+	}
+	return HandoffInput{
+		RunID:       runID,
+		HandoffType: handoffType,
+		FromPhase:   1,
+		ToPhase:     toPhase,
+		Packet:      normalized,
+	}, nil
+}
+
+func handoffSummaryMD(runID, handoffType string, d domain.TriggerDecision, a domain.AnomalySummary, events []EventOutput) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Phase1 %s handoff\n\nRun `%s`, trigger `%s` (%s).\n", handoffType, runID, d.Type, d.RulesetVersion)
+	if len(d.Candidates) > 0 {
+		b.WriteString("\n### Candidates\n")
+		for _, c := range d.Candidates {
+			fmt.Fprintf(&b, "- **%s** (%s, score %.2f): %s\n", c.EntityID, c.Level, c.Score, strings.Join(c.Reasons, ", "))
+		}
+	}
+	if len(a.Anomalies) > 0 {
+		b.WriteString("\n### Anomalies\n")
+		for _, an := range a.Anomalies {
+			fmt.Fprintf(&b, "- %s\n", an.Message)
+		}
+	}
+	if len(events) > 0 {
+		b.WriteString("\n### Events\n")
+		for _, e := range events {
+			fmt.Fprintf(&b, "- [%s] %s: %s\n", e.Category, e.EntityID, e.Title)
+		}
+	}
+	return b.String()
+}
+
+func hypothesisSeeds(d domain.TriggerDecision, a domain.AnomalySummary) []string {
+	out := []string{}
+	for _, c := range d.Candidates {
+		out = append(out, fmt.Sprintf("%s: is the recent activity (%d documents, %d signals) a sign of a change in fundamentals?", c.EntityID, c.DocCount, c.SignalCount))
+	}
+	for _, an := range a.Anomalies {
+		if an.Dimension == domain.AnomalyDimensionEntity {
+			out = append(out, fmt.Sprintf("%s: what explains the spike? %s", an.Key, an.Message))
+		}
+	}
+	return out
+}
+
+func industryScope(d domain.TriggerDecision) string {
+	byType := map[string][]string{}
+	for _, c := range d.Candidates {
+		byType[c.EntityType] = append(byType[c.EntityType], c.EntityID)
+	}
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, t+": "+strings.Join(byType[t], ", "))
+	}
+	if len(parts) == 0 {
+		return "unscoped"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func valuePoolNotes(a domain.AnomalySummary, events []EventOutput) string {
+	counts := map[string]int{}
+	for _, e := range events {
+		counts[e.Category]++
+	}
+	var lines []string
+	for _, k := range sortedCountKeys(counts) {
+		lines = append(lines, fmt.Sprintf("%d %s events", counts[k], k))
+	}
+	for _, an := range a.Anomalies {
+		lines = append(lines, an.Message)
+	}
+	if len(lines) == 0 {
+		return "No events or anomalies recorded in Phase1."
+	}
+	return strings.Join(lines, "\n")
+}
+
+func keyQuestions(d domain.TriggerDecision, a domain.AnomalySummary) []string {
+	out := []string{}
+	for _, c := range d.Candidates {
+		if c.Level == domain.TriggerTypeHeavy {
+			out = append(out, fmt.Sprintf("What is driving the activity at %s (score %.2f), and does it shift the industry's value pool?", c.EntityID, c.Score))
+		} else {
+			out = append(out, fmt.Sprintf("Is %s affected by the same drivers?", c.EntityID))
+		}
+	}
+	for _, an := range a.Anomalies {
+		if an.Dimension == domain.AnomalyDimensionCategory {
+			out = append(out, fmt.Sprintf("Why did %s events spike (%d vs. median %g)?", an.Key, an.Count, an.Median))
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, "What changed in this run compared with the baseline?")
+	}
+	return out
+}
+
+func sortedCountKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func remarshal(src any, dst any) error {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
@@ -1,6 +1,12 @@
 package handlers
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+
+	"investment_committee/internal/domain"
+)
 
 func TestValidateHandoffPacketLight(t *testing.T) {
 	in := HandoffInput{
@@ -54,3 +60,67 @@ func TestValidateHandoffPacketHeavy(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestGenerateHandoffPassesValidation(t *testing.T) {
+	d := domain.TriggerDecision{
+		ShouldHandoff: true,
+		Type:          domain.TriggerTypeHeavy,
+		Candidates: []domain.TriggerCandidate{
+			{UniverseItemID: "u1", EntityType: "ticker", EntityID: "AAPL", Score: 9, Level: domain.TriggerTypeHeavy, Reasons: []string{"9 documents (+9.0)"}},
+			{UniverseItemID: "u2", EntityType: "industry", EntityID: "semis", Score: 4, Level: domain.TriggerTypeLight, Reasons: []string{"4 documents (+4.0)"}},
+		},
+		RulesetVersion: domain.DefaultTriggerRulesVersion,
+	}
+	a := domain.AnomalySummary{Anomalies: []domain.Anomaly{{Dimension: domain.AnomalyDimensionEntity, Key: "AAPL", Count: 9, Median: 1, Message: "AAPL had 9 documents"}}}
+	events := []EventOutput{{EventID: "e1", Category: "earnings", EntityID: "AAPL", Title: "Q2 results"}}
+	now := time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC)
+
+	for _, typ := range []string{domain.TriggerTypeLight, domain.TriggerTypeHeavy} {
+		in, err := generateHandoff("run", typ, d, a, events, now)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		if err := validateHandoffPacket(in); err != nil {
+			t.Fatalf("%s: unexpected error: %v", typ, err)
+		}
+		ids, _ := in.Packet["universe_item_ids"].([]any)
+		if len(ids) != 2 || in.Packet["trigger_decision_id"] != "run" {
+			t.Fatalf("%s: packet=%v", typ, in.Packet)
+		}
+	}
+}
+
+// pagedEventsStore serves a run's Phase1 events two per page; other Store
+// methods panic.
+type pagedEventsStore struct {
+	Store
+	events []Phase1RunEvent
+}
+
+func (s pagedEventsStore) ListPhase1RunEventsByRunID(_ Context, _ string, _ int, cursor string) ([]Phase1RunEvent, *string, error) {
+	start := 0
+	if cursor != "" {
+		start = int(cursor[0] - '0')
+	}
+	if start >= len(s.events) {
+		return []Phase1RunEvent{}, nil, nil
+	}
+	end := min(start+2, len(s.events))
+	next := string(rune('0' + end))
+	return s.events[start:end], &next, nil
+}
+
+func TestAllPhase1RunEventsPages(t *testing.T) {
+	store := pagedEventsStore{}
+	for seq := 5; seq >= 1; seq-- {
+		store.events = append(store.events, Phase1RunEvent{RunID: "run", Seq: seq})
+	}
+	srv := NewServer(store, nil, "")
+	got, err := srv.allPhase1RunEvents(context.Background(), "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[4].Seq != 1 {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
+	"investment_committee/internal/db/queries"
 	"investment_committee/internal/domain"
+	"investment_committee/internal/phase/phase1"
 	"investment_committee/internal/phase1/fetcher"
 )
 
@@ -21,6 +23,10 @@ func (s *Server) HandlePhase1Runs(w http.ResponseWriter, r *http.Request, rest [
 			WriteError(w, http.StatusBadRequest, "invalid json")
 			return
 		}
+		if _, err := fetcher.ParseConfig(in.Config); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid config: "+err.Error())
+			return
+		}
 		cfg, err := json.Marshal(in.Config)
 		if err != nil {
 			WriteError(w, http.StatusBadRequest, "invalid config")
@@ -31,10 +37,8 @@ func (s *Server) HandlePhase1Runs(w http.ResponseWriter, r *http.Request, rest [
 			WriteError(w, http.StatusInternalServerError, "create failed")
 			return
 		}
-		if err := maybeFetchPhase1Docs(r.Context(), s, id, in.Config); err != nil {
-			// fetch errors are non-fatal in v1
-		}
-		WriteJSON(w, http.StatusOK, map[string]string{"run_id": id})
+		s.runs.Enqueue(id)
+		WriteJSON(w, http.StatusAccepted, map[string]string{"run_id": id, "status": "running"})
 		return
 	}
 
@@ -82,6 +86,10 @@ func (s *Server) HandlePhase1Runs(w http.ResponseWriter, r *http.Request, rest [
 				return
 			}
 			seq, err := s.store.AppendEventToRun(r.Context(), runID, in)
+			if errors.Is(err, queries.ErrRunFinalized) {
+				WriteError(w, http.StatusConflict, "run finalized")
+				return
+			}
 			if err != nil {
 				WriteError(w, http.StatusInternalServerError, "create failed")
 				return
@@ -94,6 +102,74 @@ func (s *Server) HandlePhase1Runs(w http.ResponseWriter, r *http.Request, rest [
 		}
 	}
 
+	if len(rest) == 2 && rest[1] == "finalize" {
+		if r.Method != http.MethodPost {
+			WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		err := s.store.FinalizeRun(r.Context(), runID)
+		switch {
+		case errors.Is(err, queries.ErrNotFound):
+			WriteError(w, http.StatusNotFound, "not found")
+			return
+		case errors.Is(err, queries.ErrRunFinalized):
+			WriteError(w, http.StatusConflict, "run already finalized")
+			return
+		case errors.Is(err, phase1.ErrRunNotFetched):
+			WriteError(w, http.StatusConflict, "run still fetching")
+			return
+		case err != nil:
+			WriteError(w, http.StatusInternalServerError, "finalize failed")
+			return
+		}
+		run, err := s.store.GetRun(r.Context(), runID)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "get failed")
+			return
+		}
+		WriteJSON(w, http.StatusOK, run)
+		return
+	}
+
+	if len(rest) == 2 && rest[1] == "raw-items" {
+		if r.Method != http.MethodGet {
+			WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		items, cursor, err := s.store.ListRawItemsByRun(r.Context(), runID, limit, r.URL.Query().Get("cursor"))
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "list failed")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{
+			"items":       items,
+			"next_cursor": cursor,
+		})
+		return
+	}
+
+	if len(rest) == 2 && rest[1] == "signals" {
+		if r.Method != http.MethodGet {
+			WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		items, cursor, err := s.store.ListEventsByRun(r.Context(), runID, limit, r.URL.Query().Get("cursor"))
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "list failed")
+			return
+		}
+		if items == nil {
+			items = []EventOutput{}
+		}
+		WriteJSON(w, http.StatusOK, map[string]any{
+			"items":       items,
+			"next_cursor": cursor,
+		})
+		return
+	}
+
 	if len(rest) == 2 && rest[1] == "anomaly-summary" {
 		if r.Method != http.MethodGet {
 			WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -122,6 +198,11 @@ func (s *Server) HandlePhase1Runs(w http.ResponseWriter, r *http.Request, rest [
 		return
 	}
 
+	if len(rest) == 2 && rest[1] == "handoffs:generate" {
+		s.handleGenerateHandoff(w, r, runID)
+		return
+	}
+
 	if len(rest) == 2 && rest[1] == "handoffs" {
 		if r.Method != http.MethodGet {
 			WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -138,53 +219,3 @@ func (s *Server) HandlePhase1Runs(w http.ResponseWriter, r *http.Request, rest [
 
 	WriteError(w, http.StatusNotFound, "not found")
 }
-
-func maybeFetchPhase1Docs(ctx Context, s *Server, runID string, rawCfg map[string]any) error {
-	cfg, err := fetcher.ParseConfig(rawCfg)
-	if err != nil {
-		return err
-	}
-	if len(cfg.Sources) == 0 {
-		return nil
-	}
-	reg := fetcher.NewRegistry()
-	reg.Register(fetcher.StubIRFetcher{})
-	reg.Register(fetcher.StubSECFetcher{})
-	reg.Register(fetcher.StubEDINETFetcher{})
-
-	for _, src := range cfg.Sources {
-		f, ok := reg.Get(src)
-		if !ok {
-			continue
-		}
-		docs, err := f.Fetch(ctx, cfg)
-		if err != nil {
-			continue
-		}
-		payload := map[string]any{
-			"source":    src,
-			"documents": docsToPayload(docs),
-		}
-		_, _ = s.store.AppendEventToRun(ctx, runID, RunEventInput{
-			EventType: domain.Phase1EventDocFetched,
-			Source:    domain.Phase1EventSourceOther,
-			Payload:   payload,
-		})
-	}
-	return nil
-}
-
-func docsToPayload(docs []fetcher.Document) []map[string]any {
-	out := make([]map[string]any, 0, len(docs))
-	for _, d := range docs {
-		out = append(out, map[string]any{
-			"doc_id":       d.DocID,
-			"title":        d.Title,
-			"url":          d.URL,
-			"published_at": d.PublishedAt.UTC().Format(time.RFC3339),
-			"ticker":       d.Ticker,
-			"summary":      d.Summary,
-		})
-	}
-	return out
-}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"investment_committee/internal/cron"
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/phase/phase1"
+	"investment_committee/internal/phase1/fetcher"
+)
+
+func (s *Server) HandlePhase1Schedules(w http.ResponseWriter, r *http.Request, rest []string) {
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodPost:
+			var in ScheduleInput
+			if err := DecodeJSON(r, &in); err != nil {
+				WriteError(w, http.StatusBadRequest, "invalid json")
+				return
+			}
+			if strings.TrimSpace(in.Name) == "" {
+				WriteError(w, http.StatusBadRequest, "name required")
+				return
+			}
+			if in.Timezone == "" {
+				in.Timezone = "UTC"
+			}
+			if err := validateSchedule(in.Cron, in.Timezone, in.Config); err != nil {
+				WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			var next *time.Time
+			if in.IsActive == nil || *in.IsActive {
+				next = phase1.NextScheduleRun(in.Cron, in.Timezone, time.Now())
+			}
+			id, err := s.store.CreateSchedule(r.Context(), in, next)
+			if err != nil {
+				WriteError(w, http.StatusInternalServerError, "create failed")
+				return
+			}
+			WriteJSON(w, http.StatusOK, map[string]any{"id": id, "next_run_at": next})
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			items, cursor, err := s.store.ListSchedules(r.Context(), limit, r.URL.Query().Get("cursor"))
+			if err != nil {
+				WriteError(w, http.StatusInternalServerError, "list failed")
+				return
+			}
+			WriteJSON(w, http.StatusOK, map[string]any{
+				"items":       items,
+				"next_cursor": cursor,
+			})
+		default:
+			WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	if len(rest) != 1 {
+		WriteError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id := rest[0]
+	switch r.Method {
+	case http.MethodGet:
+		sc, err := s.store.GetSchedule(r.Context(), id)
+		if err != nil {
+			WriteError(w, http.StatusNotFound, "not found")
+			return
+		}
+		WriteJSON(w, http.StatusOK, sc)
+	case http.MethodPatch:
+		var in ScheduleUpdateInput
+		if err := DecodeJSON(r, &in); err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		cur, err := s.store.GetSchedule(r.Context(), id)
+		if err != nil {
+			WriteError(w, http.StatusNotFound, "not found")
+			return
+		}
+		expr, tz, active := cur.Cron, cur.Timezone, cur.IsActive
+		if in.Cron != nil {
+			expr = *in.Cron
+		}
+		if in.Timezone != nil {
+			tz = *in.Timezone
+		}
+		if in.IsActive != nil {
+			active = *in.IsActive
+		}
+		if err := validateSchedule(expr, tz, in.Config); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Re-plan when the timing changes or a paused schedule is resumed.
+		if active && (in.Cron != nil || in.Timezone != nil || !cur.IsActive) {
+			in.NextRunAt = phase1.NextScheduleRun(expr, tz, time.Now())
+		}
+		err = s.store.UpdateSchedule(r.Context(), id, in)
+		if errors.Is(err, queries.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "update failed")
+			return
+		}
+		sc, err := s.store.GetSchedule(r.Context(), id)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "get failed")
+			return
+		}
+		WriteJSON(w, http.StatusOK, sc)
+	case http.MethodDelete:
+		err := s.store.DeleteSchedule(r.Context(), id)
+		if errors.Is(err, queries.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, "delete failed")
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// validateSchedule rejects expressions that never fire and run config
+// templates the executor could not parse.
+func validateSchedule(expr, tz string, config map[string]any) error {
+	c, err := cron.Parse(expr, tz)
+	if err != nil {
+		return errInvalid(err.Error())
+	}
+	if c.Next(time.Now()).IsZero() {
+		return errInvalid("cron never fires")
+	}
+	if config == nil {
+		return nil
+	}
+	if _, err := fetcher.ParseConfig(config); err != nil {
+		return errInvalid("invalid config")
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return errInvalid("invalid config")
+	}
+	var opts phase1.RunOptions
+	if err := json.Unmarshal(raw, &opts); err != nil {
+		return errInvalid("invalid config")
+	}
+	return nil
+}
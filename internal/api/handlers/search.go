@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"investment_committee/internal/db/queries"
+)
+
+// maxSearchQuery bounds the length of a search query in characters.
+const maxSearchQuery = 200
+
+// HandleSearch serves GET /search: raw items, events, phase artifacts and
+// decisions matching q, most recent first, with highlighted snippets. type
+// (comma-separated or repeated) limits the result types.
+func (s *Server) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	in := SearchInput{Query: strings.TrimSpace(q.Get("q"))}
+	if in.Query == "" {
+		WriteError(w, http.StatusBadRequest, "q required")
+		return
+	}
+	if utf8.RuneCountInString(in.Query) > maxSearchQuery {
+		WriteError(w, http.StatusBadRequest, "q too long")
+		return
+	}
+	for _, v := range q["type"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+			if !isSearchType(t) {
+				WriteError(w, http.StatusBadRequest, "invalid type")
+				return
+			}
+			in.Types = append(in.Types, t)
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &in.From}, {"to", &in.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeParam(v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "invalid "+p.name)
+			return
+		}
+		*p.dst = &t
+	}
+	if in.From != nil && in.To != nil && !in.From.Before(*in.To) {
+		WriteError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	in.Limit, _ = strconv.Atoi(q.Get("limit"))
+	in.Cursor = q.Get("cursor")
+	if _, err := queries.ParseSearchCursor(in.Cursor); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+
+	items, cursor, err := s.store.Search(r.Context(), in)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "search failed")
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"items":       items,
+		"next_cursor": cursor,
+	})
+}
+
+func isSearchType(t string) bool {
+	for _, v := range queries.SearchTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
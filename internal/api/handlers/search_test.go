@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// searchStore records the input of Search; other Store methods panic.
+type searchStore struct {
+	Store
+	got *SearchInput
+}
+
+func (s searchStore) Search(_ Context, in SearchInput) ([]SearchResultOutput, *string, error) {
+	*s.got = in
+	return []SearchResultOutput{}, nil, nil
+}
+
+func TestHandleSearch(t *testing.T) {
+	var got SearchInput
+	srv := NewServer(searchStore{got: &got}, nil, "")
+
+	rec := httptest.NewRecorder()
+	srv.HandleSearch(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/search?q=lithium+pricing&type=event,artifact&type=decision&from=2024-01-01&limit=10", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body)
+	}
+	if got.Query != "lithium pricing" || len(got.Types) != 3 || got.Types[2] != "decision" || got.From == nil || got.Limit != 10 {
+		t.Fatalf("input = %+v", got)
+	}
+
+	for _, q := range []string{
+		"",
+		"q=+",
+		"q=lithium&type=memo",
+		"q=lithium&to=soon",
+		"q=lithium&cursor=abc",
+	} {
+		rec := httptest.NewRecorder()
+		srv.HandleSearch(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status=%d", q, rec.Code)
+		}
+	}
+}
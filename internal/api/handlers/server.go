@@ -1,28 +1,56 @@
 package handlers
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Server struct {
-	store Store
+	store         Store
+	runs          RunQueue
+	webhookSecret string
 }
 
-func NewServer(store Store) *Server {
-	return &Server{store: store}
+func NewServer(store Store, runs RunQueue, webhookSecret string) *Server {
+	if runs == nil {
+		runs = noopRunQueue{}
+	}
+	return &Server{store: store, runs: runs, webhookSecret: webhookSecret}
 }
 
+// RunQueue hands newly created Phase1 runs to the background executor.
+type RunQueue interface {
+	Enqueue(runID string)
+}
+
+type noopRunQueue struct{}
+
+func (noopRunQueue) Enqueue(string) {}
+
 type Store interface {
 	CreateUniverseItem(ctx Context, item UniverseItemInput) (string, error)
 	ListUniverseItems(ctx Context, f UniverseFilterInput) ([]UniverseItemOutput, *string, error)
 	UpdateUniverseItem(ctx Context, id string, u UniverseUpdateInput) error
 
 	CreateRun(ctx Context, mode string, configJSON []byte) (string, error)
+	FindRunByWebhookDelivery(ctx Context, deliveryID string) (string, error)
+	ListActiveUniverseItems(ctx Context) ([]UniverseItemOutput, error)
 	GetRun(ctx Context, id string) (RunOutput, error)
 	ListEventsByRun(ctx Context, runID string, limit int, cursor string) ([]EventOutput, *string, error)
+	ListEvents(ctx Context, f EventFilterInput) ([]EventOutput, *string, error)
+	Search(ctx Context, in SearchInput) ([]SearchResultOutput, *string, error)
 	AppendEventToRun(ctx Context, runID string, input RunEventInput) (int, error)
+	FinalizeRun(ctx Context, runID string) error
 	ListPhase1RunEventsByRunID(ctx Context, runID string, limit int, cursor string) ([]Phase1RunEvent, *string, error)
+	ListRawItemsByRun(ctx Context, runID string, limit int, cursor string) ([]RawItemOutput, *string, error)
 	GetAnomalySummaryByRun(ctx Context, runID string) (AnomalySummaryOutput, error)
 	GetTriggerDecisionByRun(ctx Context, runID string) (TriggerDecisionOutput, error)
 	ListHandoffsByRun(ctx Context, runID string) ([]HandoffOutput, error)
+	CreateSchedule(ctx Context, in ScheduleInput, nextRunAt *time.Time) (string, error)
+	GetSchedule(ctx Context, id string) (ScheduleOutput, error)
+	ListSchedules(ctx Context, limit int, cursor string) ([]ScheduleOutput, *string, error)
+	UpdateSchedule(ctx Context, id string, u ScheduleUpdateInput) error
+	DeleteSchedule(ctx Context, id string) error
 	CreatePhase2Run(ctx Context, packet map[string]any) (string, error)
 	UpdatePhase2RunPacket(ctx Context, runID string, packet map[string]any) error
 	CreatePhase3Run(ctx Context, packet map[string]any) (string, error)
@@ -31,7 +59,9 @@ type Store interface {
 	UpdatePhase4RunPacket(ctx Context, runID string, packet map[string]any) error
 
 	CreateHandoff(ctx Context, input HandoffInput) (string, error)
+	CreateGeneratedHandoff(ctx Context, input HandoffInput) (string, error)
 	GetHandoff(ctx Context, id string) (HandoffOutput, error)
+	UpdateHandoffPacket(ctx Context, id string, packet map[string]any) error
 	AttachCaseToHandoff(ctx Context, handoffID string, caseInput CaseInput) (string, error)
 
 	CreateCase(ctx Context, input CaseInput) (string, error)
@@ -8,6 +8,7 @@ import (
 	"investment_committee/internal/db/models"
 	"investment_committee/internal/db/queries"
 	"investment_committee/internal/domain"
+	"investment_committee/internal/phase/phase1"
 )
 
 type StoreAdapter struct {
@@ -75,10 +76,36 @@ func (s *StoreAdapter) UpdateUniverseItem(ctx context.Context, id string, u Univ
 	})
 }
 
+func (s *StoreAdapter) ListActiveUniverseItems(ctx context.Context) ([]UniverseItemOutput, error) {
+	items, err := s.repo.ListActiveUniverseItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []UniverseItemOutput
+	for _, it := range items {
+		var keywords []string
+		_ = json.Unmarshal(it.Keywords, &keywords)
+		out = append(out, UniverseItemOutput{
+			ID:         it.ID,
+			EntityType: it.EntityType,
+			EntityID:   it.EntityID,
+			Name:       it.Name,
+			Keywords:   keywords,
+			Priority:   it.Priority,
+			IsActive:   it.IsActive,
+		})
+	}
+	return out, nil
+}
+
 func (s *StoreAdapter) CreateRun(ctx context.Context, mode string, configJSON []byte) (string, error) {
 	return s.repo.CreateRun(ctx, mode, configJSON)
 }
 
+func (s *StoreAdapter) FindRunByWebhookDelivery(ctx context.Context, deliveryID string) (string, error) {
+	return s.repo.FindRunByWebhookDelivery(ctx, deliveryID)
+}
+
 func (s *StoreAdapter) GetRun(ctx context.Context, id string) (RunOutput, error) {
 	run, err := s.repo.GetRun(ctx, id)
 	if err != nil {
@@ -115,26 +142,7 @@ func (s *StoreAdapter) ListEventsByRun(ctx context.Context, runID string, limit
 	}
 	var out []EventOutput
 	for _, e := range items {
-		var facts any
-		var impact any
-		var sources any
-		_ = json.Unmarshal(e.FactsJSON, &facts)
-		if len(e.ImpactJSON) > 0 {
-			_ = json.Unmarshal(e.ImpactJSON, &impact)
-		}
-		_ = json.Unmarshal(e.Sources, &sources)
-		out = append(out, EventOutput{
-			EventID:    e.EventID,
-			Category:   e.Category,
-			ObservedAt: e.ObservedAt,
-			Title:      e.Title,
-			Facts:      facts,
-			Impact:     impact,
-			Sources:    sources,
-			Confidence: e.Confidence,
-			EntityType: e.EntityType,
-			EntityID:   e.EntityID,
-		})
+		out = append(out, eventOutput(e))
 	}
 	var nextCursor *string
 	if next != nil {
@@ -144,6 +152,99 @@ func (s *StoreAdapter) ListEventsByRun(ctx context.Context, runID string, limit
 	return out, nextCursor, nil
 }
 
+func (s *StoreAdapter) ListEvents(ctx context.Context, f EventFilterInput) ([]EventOutput, *string, error) {
+	cur, err := queries.ParseEventCursor(f.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, next, err := s.repo.ListEvents(ctx, queries.EventFilter{
+		EntityType:    f.EntityType,
+		EntityID:      f.EntityID,
+		Category:      f.Category,
+		MinConfidence: f.MinConfidence,
+		From:          f.From,
+		To:            f.To,
+		Limit:         f.Limit,
+		Cursor:        cur,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	out := []EventOutput{}
+	for _, e := range items {
+		out = append(out, eventOutput(e))
+	}
+	var nextCursor *string
+	if next != nil {
+		s := next.String()
+		nextCursor = &s
+	}
+	return out, nextCursor, nil
+}
+
+func (s *StoreAdapter) Search(ctx context.Context, in SearchInput) ([]SearchResultOutput, *string, error) {
+	cur, err := queries.ParseSearchCursor(in.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	hits, next, err := s.repo.Search(ctx, queries.SearchFilter{
+		Query:  in.Query,
+		Types:  in.Types,
+		From:   in.From,
+		To:     in.To,
+		Limit:  in.Limit,
+		Cursor: cur,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	out := []SearchResultOutput{}
+	for _, h := range hits {
+		out = append(out, SearchResultOutput{
+			Type:       h.Type,
+			ID:         h.ID,
+			RunID:      h.RunID,
+			CaseID:     h.CaseID,
+			Title:      h.Title,
+			Snippet:    h.Snippet,
+			Rank:       h.Rank,
+			OccurredAt: h.OccurredAt,
+		})
+	}
+	var nextCursor *string
+	if next != nil {
+		s := next.String()
+		nextCursor = &s
+	}
+	return out, nextCursor, nil
+}
+
+func eventOutput(e models.Event) EventOutput {
+	var facts, impact, sources, tags any
+	_ = json.Unmarshal(e.FactsJSON, &facts)
+	if len(e.ImpactJSON) > 0 {
+		_ = json.Unmarshal(e.ImpactJSON, &impact)
+	}
+	_ = json.Unmarshal(e.Sources, &sources)
+	if len(e.TagsJSON) > 0 {
+		_ = json.Unmarshal(e.TagsJSON, &tags)
+	}
+	return EventOutput{
+		EventID:    e.EventID,
+		RunID:      e.RunID,
+		Category:   e.Category,
+		ObservedAt: e.ObservedAt,
+		Title:      e.Title,
+		Facts:      facts,
+		Impact:     impact,
+		Sources:    sources,
+		Confidence: e.Confidence,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Tags:       tags,
+	}
+}
+
 func (s *StoreAdapter) AppendEventToRun(ctx context.Context, runID string, input RunEventInput) (int, error) {
 	source := input.Source
 	if source == "" {
@@ -172,6 +273,10 @@ func (s *StoreAdapter) AppendEventToRun(ctx context.Context, runID string, input
 	return s.repo.CreatePhase1RunEvent(ctx, e)
 }
 
+func (s *StoreAdapter) FinalizeRun(ctx context.Context, runID string) error {
+	return phase1.FinalizeRun(ctx, s.repo, runID)
+}
+
 func (s *StoreAdapter) ListPhase1RunEventsByRunID(ctx context.Context, runID string, limit int, cursor string) ([]Phase1RunEvent, *string, error) {
 	cur, err := queries.ParseCursor(cursor)
 	if err != nil {
@@ -207,6 +312,56 @@ func (s *StoreAdapter) ListPhase1RunEventsByRunID(ctx context.Context, runID str
 	return out, nextCursor, nil
 }
 
+func (s *StoreAdapter) ListRawItemsByRun(ctx context.Context, runID string, limit int, cursor string) ([]RawItemOutput, *string, error) {
+	cur, err := queries.ParseCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, next, err := s.repo.ListRawItemsByRun(ctx, runID, limit, cur)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := []RawItemOutput{}
+	for _, it := range items {
+		entities := make([]RawItemEntityOutput, 0, len(it.Entities))
+		for _, e := range it.Entities {
+			entities = append(entities, RawItemEntityOutput{UniverseItemID: e.UniverseItemID, Score: e.Score, Evidence: e.Evidence})
+		}
+		var tone any
+		if len(it.Sentiment) > 0 {
+			_ = json.Unmarshal(it.Sentiment, &tone)
+		}
+		out = append(out, RawItemOutput{
+			ID:              it.ID,
+			FirstRunID:      it.RunID,
+			SourceType:      it.SourceType,
+			SourceName:      it.SourceName,
+			SourceDocID:     it.SourceDocID,
+			URL:             it.URL,
+			Title:           it.Title,
+			PublishedAt:     it.Published,
+			RawText:         it.RawText,
+			Summary:         it.Summary,
+			Language:        it.Language,
+			DocType:         it.DocType,
+			Hash:            it.Hash,
+			ClusterID:       it.ClusterID,
+			FetchedAt:       it.FetchedAt,
+			Duplicate:       it.Duplicate,
+			LinkedAt:        it.LinkedAt,
+			UniverseItemIDs: it.UniverseItemIDs,
+			Entities:        entities,
+			Sentiment:       tone,
+		})
+	}
+	var nextCursor *string
+	if next != nil {
+		s := next.Format(time.RFC3339Nano)
+		nextCursor = &s
+	}
+	return out, nextCursor, nil
+}
+
 func (s *StoreAdapter) GetAnomalySummaryByRun(ctx context.Context, runID string) (AnomalySummaryOutput, error) {
 	a, err := s.repo.GetAnomalySummaryByRun(ctx, runID)
 	if err != nil {
@@ -299,11 +454,29 @@ func (s *StoreAdapter) UpdatePhase4RunPacket(ctx context.Context, runID string,
 }
 
 func (s *StoreAdapter) CreateHandoff(ctx context.Context, input HandoffInput) (string, error) {
-	packet, err := json.Marshal(input.Packet)
+	h, err := handoffPacket(input)
+	if err != nil {
+		return "", err
+	}
+	return s.repo.CreateHandoff(ctx, h)
+}
+
+// CreateGeneratedHandoff stores a generated handoff unless the run already
+// has one of its type (queries.ErrHandoffExists).
+func (s *StoreAdapter) CreateGeneratedHandoff(ctx context.Context, input HandoffInput) (string, error) {
+	h, err := handoffPacket(input)
 	if err != nil {
 		return "", err
 	}
-	h := models.HandoffPacket{
+	return s.repo.CreateGeneratedHandoff(ctx, h)
+}
+
+func handoffPacket(input HandoffInput) (models.HandoffPacket, error) {
+	packet, err := json.Marshal(input.Packet)
+	if err != nil {
+		return models.HandoffPacket{}, err
+	}
+	return models.HandoffPacket{
 		RunID:       input.RunID,
 		CaseID:      input.CaseID,
 		HandoffType: input.HandoffType,
@@ -311,8 +484,7 @@ func (s *StoreAdapter) CreateHandoff(ctx context.Context, input HandoffInput) (s
 		ToPhase:     input.ToPhase,
 		PacketJSON:  packet,
 		Status:      "created",
-	}
-	return s.repo.CreateHandoff(ctx, h)
+	}, nil
 }
 
 func (s *StoreAdapter) GetHandoff(ctx context.Context, id string) (HandoffOutput, error) {
@@ -334,6 +506,14 @@ func (s *StoreAdapter) GetHandoff(ctx context.Context, id string) (HandoffOutput
 	}, nil
 }
 
+func (s *StoreAdapter) UpdateHandoffPacket(ctx context.Context, id string, packet map[string]any) error {
+	raw, err := json.Marshal(packet)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateHandoffPacket(ctx, id, raw)
+}
+
 func (s *StoreAdapter) AttachCaseToHandoff(ctx context.Context, handoffID string, caseInput CaseInput) (string, error) {
 	caseID, err := s.CreateCase(ctx, caseInput)
 	if err != nil {
@@ -671,3 +851,89 @@ func (s *StoreAdapter) ListAlertsByPlan(ctx context.Context, planID string, limi
 func (s *StoreAdapter) AckAlert(ctx context.Context, id string) error {
 	return s.repo.AckAlert(ctx, id)
 }
+
+func (s *StoreAdapter) CreateSchedule(ctx context.Context, in ScheduleInput, nextRunAt *time.Time) (string, error) {
+	cfg, err := json.Marshal(in.Config)
+	if err != nil {
+		return "", err
+	}
+	active := in.IsActive == nil || *in.IsActive
+	return s.repo.CreateSchedule(ctx, models.Phase1Schedule{
+		Name:       in.Name,
+		Cron:       in.Cron,
+		Timezone:   in.Timezone,
+		ConfigJSON: cfg,
+		IsActive:   active,
+		NextRunAt:  nextRunAt,
+	})
+}
+
+func (s *StoreAdapter) GetSchedule(ctx context.Context, id string) (ScheduleOutput, error) {
+	sc, err := s.repo.GetSchedule(ctx, id)
+	if err != nil {
+		return ScheduleOutput{}, err
+	}
+	return scheduleOutput(sc), nil
+}
+
+func (s *StoreAdapter) ListSchedules(ctx context.Context, limit int, cursor string) ([]ScheduleOutput, *string, error) {
+	cur, err := queries.ParseCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, next, err := s.repo.ListSchedules(ctx, limit, cur)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := []ScheduleOutput{}
+	for _, sc := range items {
+		out = append(out, scheduleOutput(sc))
+	}
+	var nextCursor *string
+	if next != nil {
+		s := next.Format(time.RFC3339Nano)
+		nextCursor = &s
+	}
+	return out, nextCursor, nil
+}
+
+func (s *StoreAdapter) UpdateSchedule(ctx context.Context, id string, u ScheduleUpdateInput) error {
+	var cfg json.RawMessage
+	if u.Config != nil {
+		raw, err := json.Marshal(u.Config)
+		if err != nil {
+			return err
+		}
+		cfg = raw
+	}
+	return s.repo.UpdateSchedule(ctx, id, queries.ScheduleUpdate{
+		Name:      u.Name,
+		Cron:      u.Cron,
+		Timezone:  u.Timezone,
+		Config:    cfg,
+		IsActive:  u.IsActive,
+		NextRunAt: u.NextRunAt,
+	})
+}
+
+func (s *StoreAdapter) DeleteSchedule(ctx context.Context, id string) error {
+	return s.repo.DeleteSchedule(ctx, id)
+}
+
+func scheduleOutput(sc models.Phase1Schedule) ScheduleOutput {
+	var cfg map[string]any
+	_ = json.Unmarshal(sc.ConfigJSON, &cfg)
+	return ScheduleOutput{
+		ID:        sc.ID,
+		Name:      sc.Name,
+		Cron:      sc.Cron,
+		Timezone:  sc.Timezone,
+		Config:    cfg,
+		IsActive:  sc.IsActive,
+		NextRunAt: sc.NextRunAt,
+		LastRunAt: sc.LastRunAt,
+		LastRunID: sc.LastRunID,
+		CreatedAt: sc.CreatedAt,
+		UpdatedAt: sc.UpdatedAt,
+	}
+}
@@ -39,6 +39,37 @@ type RunInput struct {
 	Config map[string]any `json:"config"`
 }
 
+type ScheduleInput struct {
+	Name     string         `json:"name"`
+	Cron     string         `json:"cron"`
+	Timezone string         `json:"timezone"`
+	Config   map[string]any `json:"config"`
+	IsActive *bool          `json:"is_active,omitempty"`
+}
+
+type ScheduleUpdateInput struct {
+	Name      *string        `json:"name,omitempty"`
+	Cron      *string        `json:"cron,omitempty"`
+	Timezone  *string        `json:"timezone,omitempty"`
+	Config    map[string]any `json:"config,omitempty"`
+	IsActive  *bool          `json:"is_active,omitempty"`
+	NextRunAt *time.Time     `json:"-"`
+}
+
+type ScheduleOutput struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Cron      string         `json:"cron"`
+	Timezone  string         `json:"timezone"`
+	Config    map[string]any `json:"config"`
+	IsActive  bool           `json:"is_active"`
+	NextRunAt *time.Time     `json:"next_run_at,omitempty"`
+	LastRunAt *time.Time     `json:"last_run_at,omitempty"`
+	LastRunID *string        `json:"last_run_id,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
 type RunOutput struct {
 	ID         string         `json:"id"`
 	Status     string         `json:"status"`
@@ -78,6 +109,7 @@ type Phase4RunOutput struct {
 
 type EventOutput struct {
 	EventID    string    `json:"event_id"`
+	RunID      string    `json:"run_id"`
 	Category   string    `json:"category"`
 	ObservedAt time.Time `json:"observed_at"`
 	Title      string    `json:"title"`
@@ -87,6 +119,43 @@ type EventOutput struct {
 	Confidence float64   `json:"confidence"`
 	EntityType string    `json:"entity_type"`
 	EntityID   string    `json:"entity_id"`
+	Tags       any       `json:"tags_json,omitempty"`
+}
+
+// EventFilterInput filters GET /events; From is inclusive and To exclusive.
+type EventFilterInput struct {
+	EntityType    *string
+	EntityID      *string
+	Category      *string
+	MinConfidence *float64
+	From          *time.Time
+	To            *time.Time
+	Limit         int
+	Cursor        string
+}
+
+// SearchInput is a GET /search query; From is inclusive and To exclusive.
+type SearchInput struct {
+	Query  string
+	Types  []string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Cursor string
+}
+
+// SearchResultOutput is a raw item, event, artifact or decision matching a
+// search. RunID is set for raw items and events, CaseID for artifacts and
+// decisions.
+type SearchResultOutput struct {
+	Type       string    `json:"type"`
+	ID         string    `json:"id"`
+	RunID      *string   `json:"run_id,omitempty"`
+	CaseID     *string   `json:"case_id,omitempty"`
+	Title      string    `json:"title"`
+	Snippet    string    `json:"snippet"`
+	Rank       float64   `json:"rank"`
+	OccurredAt time.Time `json:"occurred_at"`
 }
 
 type AnomalySummaryOutput struct {
@@ -106,6 +175,40 @@ type RunEventInput struct {
 	Payload    map[string]any `json:"payload,omitempty"`
 }
 
+type RawItemOutput struct {
+	ID          string     `json:"id"`
+	FirstRunID  string     `json:"first_run_id"`
+	SourceType  string     `json:"source_type"`
+	SourceName  *string    `json:"source_name,omitempty"`
+	SourceDocID *string    `json:"source_doc_id,omitempty"`
+	URL         string     `json:"url"`
+	Title       string     `json:"title"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	RawText     string     `json:"raw_text"`
+	Summary     string     `json:"summary"`
+	Language    string     `json:"language"`
+	DocType     string     `json:"doc_type,omitempty"`
+	Hash        string     `json:"hash"`
+	ClusterID   *string    `json:"cluster_id,omitempty"`
+	FetchedAt   time.Time  `json:"fetched_at"`
+	Duplicate   bool       `json:"is_duplicate"`
+	LinkedAt    time.Time  `json:"linked_at"`
+	// UniverseItemIDs are the universe items the item is linked to, best
+	// scored first.
+	UniverseItemIDs []string `json:"universe_item_ids"`
+	// Entities are the links with their score and evidence.
+	Entities []RawItemEntityOutput `json:"entities"`
+	// Sentiment is the tone of the text: polarity, uncertainty and
+	// litigiousness with the word counts behind them.
+	Sentiment any `json:"sentiment,omitempty"`
+}
+
+type RawItemEntityOutput struct {
+	UniverseItemID string   `json:"universe_item_id"`
+	Score          float64  `json:"score"`
+	Evidence       []string `json:"evidence"`
+}
+
 type Phase1RunEvent struct {
 	RunID      string         `json:"run_id"`
 	Seq        int            `json:"seq"`
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/phase1/fetcher"
+)
+
+const (
+	maxWebhookBody = 1 << 20
+	// webhookTolerance bounds how far a delivery's signed timestamp may be
+	// from now, so a captured delivery cannot be replayed later.
+	webhookTolerance = 5 * time.Minute
+)
+
+// WebhookInput is an inbound notification such as "new filing for CIK X"
+// (source sec) or "IR feed updated for ticker Y" (source ir).
+type WebhookInput struct {
+	DeliveryID string   `json:"delivery_id,omitempty"`
+	Source     string   `json:"source"`
+	EntityID   string   `json:"entity_id,omitempty"`
+	Ticker     string   `json:"ticker,omitempty"`
+	CIK        string   `json:"cik,omitempty"`
+	SecCode    string   `json:"sec_code,omitempty"`
+	FeedURL    string   `json:"feed_url,omitempty"`
+	Forms      []string `json:"forms,omitempty"`
+	Title      string   `json:"title,omitempty"`
+}
+
+// HandlePhase1Webhooks verifies X-Signature-256 (sha256=<hex HMAC of
+// "<X-Webhook-Timestamp>.<body>" keyed with WEBHOOK_SECRET>) and the
+// timestamp (unix seconds, within webhookTolerance of now), maps the
+// notification to active universe items and starts an event_driven run
+// limited to that source and those entities. Redelivered notifications (same
+// delivery id) return the run that was already started.
+func (s *Server) HandlePhase1Webhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.webhookSecret == "" {
+		WriteError(w, http.StatusServiceUnavailable, "webhooks not configured")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !validWebhookSignature(s.webhookSecret, body, r.Header.Get("X-Webhook-Timestamp"), r.Header.Get("X-Signature-256"), time.Now()) {
+		WriteError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+	var in WebhookInput
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if in.Source != "sec" && in.Source != "edinet" && in.Source != "ir" {
+		WriteError(w, http.StatusBadRequest, "source must be sec, edinet or ir")
+		return
+	}
+	if in.DeliveryID == "" {
+		in.DeliveryID = r.Header.Get("X-Delivery-ID")
+	}
+
+	if in.DeliveryID != "" {
+		id, err := s.store.FindRunByWebhookDelivery(r.Context(), in.DeliveryID)
+		if err == nil {
+			WriteJSON(w, http.StatusOK, map[string]any{"run_id": id, "status": "duplicate"})
+			return
+		}
+		if !errors.Is(err, queries.ErrNotFound) {
+			WriteError(w, http.StatusInternalServerError, "get failed")
+			return
+		}
+	}
+
+	universe, err := s.store.ListActiveUniverseItems(r.Context())
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "list failed")
+		return
+	}
+	matched := matchWebhookUniverse(in, universe)
+	if len(matched) == 0 {
+		WriteJSON(w, http.StatusAccepted, map[string]any{"status": "ignored", "reason": "no matching universe items"})
+		return
+	}
+	cfg, err := webhookRunConfig(in, matched)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "invalid config")
+		return
+	}
+	id, err := s.store.CreateRun(r.Context(), "event_driven", raw)
+	if err != nil {
+		// A concurrent redelivery may have won the unique delivery index.
+		if in.DeliveryID != "" {
+			if existing, findErr := s.store.FindRunByWebhookDelivery(r.Context(), in.DeliveryID); findErr == nil {
+				WriteJSON(w, http.StatusOK, map[string]any{"run_id": existing, "status": "duplicate"})
+				return
+			}
+		}
+		WriteError(w, http.StatusInternalServerError, "create failed")
+		return
+	}
+	s.runs.Enqueue(id)
+	ids := make([]string, 0, len(matched))
+	for _, u := range matched {
+		ids = append(ids, u.ID)
+	}
+	WriteJSON(w, http.StatusAccepted, map[string]any{"run_id": id, "status": "running", "universe_item_ids": ids})
+}
+
+// validWebhookSignature checks the signature of timestamp and body, and that
+// the timestamp is within webhookTolerance of now.
+func validWebhookSignature(secret string, body []byte, timestamp, header string, now time.Time) bool {
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > webhookTolerance || d < -webhookTolerance {
+		return false
+	}
+	sig, ok := strings.CutPrefix(strings.TrimSpace(header), "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "."))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// matchWebhookUniverse matches universe items by entity_id against the
+// notification's entity_id, ticker, securities code (4 or 5 digits) or CIK
+// (leading zeros ignored), and by keyword against its title.
+func matchWebhookUniverse(in WebhookInput, items []UniverseItemOutput) []UniverseItemOutput {
+	keys := map[string]struct{}{}
+	for _, k := range []string{in.EntityID, in.Ticker, in.SecCode} {
+		k = strings.ToUpper(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		keys[k] = struct{}{}
+		if len(k) == 5 && strings.HasSuffix(k, "0") {
+			keys[k[:4]] = struct{}{}
+		}
+	}
+	cik := strings.TrimLeft(strings.TrimSpace(in.CIK), "0")
+	title := strings.ToLower(in.Title)
+
+	var out []UniverseItemOutput
+	for _, u := range items {
+		id := strings.ToUpper(strings.TrimSpace(u.EntityID))
+		_, ok := keys[id]
+		if !ok && cik != "" && strings.TrimLeft(id, "0") == cik {
+			ok = true
+		}
+		if !ok && title != "" {
+			for _, kw := range u.Keywords {
+				if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" && strings.Contains(title, kw) {
+					ok = true
+					break
+				}
+			}
+		}
+		if ok {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// webhookRunConfig builds a run config that fetches only the notifying source
+// for the matched entities. The trigger block records where the run came from.
+func webhookRunConfig(in WebhookInput, matched []UniverseItemOutput) (map[string]any, error) {
+	var tickers []string
+	ids := make([]string, 0, len(matched))
+	for _, u := range matched {
+		ids = append(ids, u.ID)
+		if u.EntityType == "ticker" {
+			tickers = append(tickers, u.EntityID)
+		}
+	}
+	cfg := fetcher.Phase1FetchConfig{Sources: []string{in.Source}}
+	switch in.Source {
+	case "sec":
+		cik := strings.TrimLeft(in.CIK, "0")
+		if cik != "" {
+			cfg.SECCIKs = []string{in.CIK}
+		}
+		for _, t := range tickers {
+			// Items keyed by CIK are already covered by sec_ciks.
+			if cik == "" || strings.TrimLeft(t, "0") != cik {
+				cfg.SECTickers = append(cfg.SECTickers, t)
+			}
+		}
+		cfg.SECForms = in.Forms
+		if len(cfg.SECCIKs) == 0 && len(cfg.SECTickers) == 0 {
+			return nil, errInvalid("sec webhook needs a cik or a ticker universe item")
+		}
+	case "edinet":
+		if in.SecCode != "" {
+			cfg.EDINETSecCodes = []string{in.SecCode}
+		} else {
+			cfg.EDINETSecCodes = tickers
+		}
+		if len(cfg.EDINETSecCodes) == 0 {
+			return nil, errInvalid("edinet webhook needs a sec_code or a ticker universe item")
+		}
+		cfg.EDINETDays = 1
+	case "ir":
+		if in.FeedURL == "" {
+			return nil, errInvalid("feed_url required for ir")
+		}
+		ticker := in.Ticker
+		if ticker == "" && len(tickers) > 0 {
+			ticker = tickers[0]
+		}
+		cfg.IRFeeds = []fetcher.IRFeed{{URL: in.FeedURL, Ticker: ticker}}
+		cfg.IRWindowDays = 1
+	default:
+		return nil, errInvalid("source must be sec, edinet or ir")
+	}
+	var out map[string]any
+	if err := remarshal(cfg, &out); err != nil {
+		return nil, err
+	}
+	trigger := map[string]any{"type": "webhook", "universe_item_ids": ids}
+	if in.DeliveryID != "" {
+		trigger["delivery_id"] = in.DeliveryID
+	}
+	out["trigger"] = trigger
+	return out, nil
+}
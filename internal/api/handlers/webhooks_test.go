@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestValidWebhookSignature(t *testing.T) {
+	body := []byte(`{"source":"sec","cik":"320193"}`)
+	now := time.Unix(1735689600, 0)
+	ts := "1735689600"
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	good := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !validWebhookSignature("secret", body, ts, good, now) {
+		t.Fatal("valid signature rejected")
+	}
+	if !validWebhookSignature("secret", body, ts, good, now.Add(webhookTolerance)) {
+		t.Fatal("signature rejected within tolerance")
+	}
+	for _, h := range []string{"", hex.EncodeToString(mac.Sum(nil)), "sha256=zz", good[:len(good)-2] + "00"} {
+		if validWebhookSignature("secret", body, ts, h, now) {
+			t.Fatalf("signature %q accepted", h)
+		}
+	}
+	if validWebhookSignature("other", body, ts, good, now) {
+		t.Fatal("signature accepted with wrong secret")
+	}
+	if validWebhookSignature("secret", body, "1735689601", good, now) {
+		t.Fatal("signature accepted for another timestamp")
+	}
+	for _, ts := range []string{"", "yesterday"} {
+		if validWebhookSignature("secret", body, ts, good, now) {
+			t.Fatalf("timestamp %q accepted", ts)
+		}
+	}
+	if validWebhookSignature("secret", body, ts, good, now.Add(webhookTolerance+time.Second)) {
+		t.Fatal("replayed delivery accepted after the tolerance window")
+	}
+}
+
+func TestWebhookRunConfig(t *testing.T) {
+	universe := []UniverseItemOutput{
+		{ID: "u1", EntityType: "ticker", EntityID: "0000320193"},
+		{ID: "u2", EntityType: "ticker", EntityID: "7203", Keywords: []string{"Toyota"}},
+		{ID: "u3", EntityType: "theme", EntityID: "ev", Keywords: []string{"battery"}},
+	}
+	cases := []struct {
+		name    string
+		in      WebhookInput
+		matched []string
+		check   func(t *testing.T, cfg map[string]any)
+	}{
+		{
+			name:    "sec cik ignores zero padding",
+			in:      WebhookInput{Source: "sec", CIK: "320193", Forms: []string{"8-K"}, DeliveryID: "d1"},
+			matched: []string{"u1"},
+			check: func(t *testing.T, cfg map[string]any) {
+				if ciks, _ := cfg["sec_ciks"].([]any); len(ciks) != 1 || ciks[0] != "320193" {
+					t.Fatalf("sec_ciks=%v", cfg["sec_ciks"])
+				}
+				trigger, _ := cfg["trigger"].(map[string]any)
+				if trigger["delivery_id"] != "d1" {
+					t.Fatalf("trigger=%v", trigger)
+				}
+			},
+		},
+		{
+			name:    "edinet five digit code and keywords",
+			in:      WebhookInput{Source: "edinet", SecCode: "72030", Title: "Toyota battery plant"},
+			matched: []string{"u2", "u3"},
+			check: func(t *testing.T, cfg map[string]any) {
+				if srcs, _ := cfg["sources"].([]any); len(srcs) != 1 || srcs[0] != "edinet" {
+					t.Fatalf("sources=%v", cfg["sources"])
+				}
+				if codes, _ := cfg["edinet_sec_codes"].([]any); len(codes) != 1 || codes[0] != "72030" {
+					t.Fatalf("edinet_sec_codes=%v", cfg["edinet_sec_codes"])
+				}
+			},
+		},
+		{
+			name:    "ir feed for ticker",
+			in:      WebhookInput{Source: "ir", Ticker: "7203", FeedURL: "https://example.com/ir.rss"},
+			matched: []string{"u2"},
+			check: func(t *testing.T, cfg map[string]any) {
+				feeds, _ := cfg["ir_feeds"].([]any)
+				if len(feeds) != 1 {
+					t.Fatalf("ir_feeds=%v", cfg["ir_feeds"])
+				}
+			},
+		},
+		{
+			name: "no match",
+			in:   WebhookInput{Source: "sec", Ticker: "NVDA"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			matched := matchWebhookUniverse(tc.in, universe)
+			if len(matched) != len(tc.matched) {
+				t.Fatalf("matched=%+v want %v", matched, tc.matched)
+			}
+			for i, id := range tc.matched {
+				if matched[i].ID != id {
+					t.Fatalf("matched=%+v want %v", matched, tc.matched)
+				}
+			}
+			if tc.check == nil {
+				return
+			}
+			cfg, err := webhookRunConfig(tc.in, matched)
+			if err != nil {
+				t.Fatalf("config: %v", err)
+			}
+			tc.check(t, cfg)
+		})
+	}
+
+	if _, err := webhookRunConfig(WebhookInput{Source: "ir", Ticker: "7203"}, universe[1:2]); err == nil {
+		t.Fatal("ir without feed_url accepted")
+	}
+	if _, err := webhookRunConfig(WebhookInput{Source: "edinet", Title: "battery"}, universe[2:3]); err == nil {
+		t.Fatal("edinet without sec_code or ticker accepted")
+	}
+}
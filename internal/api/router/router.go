@@ -13,14 +13,19 @@ type Router struct {
 	apiKey string
 }
 
-func New(repo *queries.Repository, apiKey string) *Router {
+func New(repo *queries.Repository, apiKey, webhookSecret string, runs handlers.RunQueue) *Router {
 	return &Router{
-		server: handlers.NewServer(handlers.NewStoreAdapter(repo)),
+		server: handlers.NewServer(handlers.NewStoreAdapter(repo), runs, webhookSecret),
 		apiKey: apiKey,
 	}
 }
 
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Webhook senders sign the body with WEBHOOK_SECRET instead of using the API key.
+	if strings.Trim(req.URL.Path, "/") == "api/v1/phase1/webhooks" {
+		r.server.HandlePhase1Webhooks(w, req)
+		return
+	}
 	if r.apiKey != "" && !handlers.AuthOK(req, r.apiKey) {
 		handlers.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
@@ -52,6 +57,10 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if len(parts) >= 2 && parts[0] == "phase1" && parts[1] == "schedules" {
+		r.server.HandlePhase1Schedules(w, req, parts[2:])
+		return
+	}
 	if len(parts) >= 2 && parts[0] == "phase1" && parts[1] == "runs" {
 		r.server.HandlePhase1Runs(w, req, parts[2:])
 		return
@@ -69,6 +78,15 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if len(parts) == 1 && parts[0] == "events" {
+		r.server.HandleEvents(w, req)
+		return
+	}
+	if len(parts) == 1 && parts[0] == "search" {
+		r.server.HandleSearch(w, req)
+		return
+	}
+
 	if len(parts) == 1 && parts[0] == "handoffs" {
 		r.server.HandleHandoffs(w, req)
 		return
@@ -6,6 +6,24 @@ type Config struct {
 	Addr        string
 	DatabaseURL string
 	APIKey      string
+
+	SECUserAgent       string
+	SECDataBaseURL     string
+	SECArchivesBaseURL string
+
+	EDINETAPIKey  string
+	EDINETBaseURL string
+
+	WebhookSecret string
+
+	FetchRateLimits  string
+	FetchMaxAttempts string
+
+	FetchVCRMode string
+	FetchVCRDir  string
+
+	SentimentLexiconEN string
+	SentimentLexiconJA string
 }
 
 func Load() Config {
@@ -17,5 +35,23 @@ func Load() Config {
 		Addr:        addr,
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		APIKey:      os.Getenv("API_KEY"),
+
+		SECUserAgent:       os.Getenv("SEC_USER_AGENT"),
+		SECDataBaseURL:     os.Getenv("SEC_DATA_BASE_URL"),
+		SECArchivesBaseURL: os.Getenv("SEC_ARCHIVES_BASE_URL"),
+
+		EDINETAPIKey:  os.Getenv("EDINET_API_KEY"),
+		EDINETBaseURL: os.Getenv("EDINET_BASE_URL"),
+
+		WebhookSecret: os.Getenv("WEBHOOK_SECRET"),
+
+		FetchRateLimits:  os.Getenv("FETCH_RATE_LIMITS"),
+		FetchMaxAttempts: os.Getenv("FETCH_MAX_ATTEMPTS"),
+
+		FetchVCRMode: os.Getenv("FETCH_VCR_MODE"),
+		FetchVCRDir:  os.Getenv("FETCH_VCR_DIR"),
+
+		SentimentLexiconEN: os.Getenv("SENTIMENT_LEXICON_EN"),
+		SentimentLexiconJA: os.Getenv("SENTIMENT_LEXICON_JA"),
 	}
 }
@@ -0,0 +1,203 @@
+// Package cron parses standard 5-field cron expressions
+// (minute hour day-of-month month day-of-week) and computes their next
+// activation in a given time zone.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// Embedded zone data so schedules work in images without /usr/share/zoneinfo.
+	_ "time/tzdata"
+)
+
+// zoneAliases maps the short names used in schedules to IANA zones.
+var zoneAliases = map[string]string{
+	"JST": "Asia/Tokyo",
+	"ET":  "America/New_York",
+	"EST": "America/New_York",
+	"EDT": "America/New_York",
+	"UTC": "UTC",
+}
+
+// LoadLocation resolves an IANA zone name or one of the aliases JST and ET.
+// An empty name is UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	if alias, ok := zoneAliases[strings.ToUpper(name)]; ok {
+		name = alias
+	}
+	return time.LoadLocation(name)
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var dowNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+type Schedule struct {
+	minute, hour, dom, month, dow []bool
+	// domStar and dowStar follow the cron rule that when both day fields are
+	// restricted a day matches if either matches.
+	domStar, dowStar bool
+	loc              *time.Location
+}
+
+// Parse parses expr in the zone tz (see LoadLocation).
+func Parse(expr, tz string) (*Schedule, error) {
+	loc, err := LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("cron: timezone %q: %w", tz, err)
+	}
+	expr = strings.TrimSpace(expr)
+	if m, ok := macros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron: %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	s := &Schedule{loc: loc}
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("cron: minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("cron: hour: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("cron: day of month: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("cron: month: %w", err)
+	}
+	// 7 is accepted as Sunday.
+	if s.dow, err = parseField(fields[4], 0, 7, dowNames); err != nil {
+		return nil, fmt.Errorf("cron: day of week: %w", err)
+	}
+	if s.dow[7] {
+		s.dow[0] = true
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+func parseField(field string, min, max int, names map[string]int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], names); err != nil {
+				return nil, err
+			}
+			if hi, err = parseValue(bounds[1], names); err != nil {
+				return nil, err
+			}
+		default:
+			v, err := parseValue(rng, names)
+			if err != nil {
+				return nil, err
+			}
+			lo, hi = v, v
+			if strings.Contains(part, "/") {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+func (s *Schedule) Location() *time.Location { return s.loc }
+
+// Next returns the first activation strictly after t, or the zero time if
+// there is none within five years (e.g. "0 0 30 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.loc).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		var next time.Time
+		switch {
+		case !s.month[m]:
+			next = time.Date(y, m+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			next = time.Date(y, m, d+1, 0, 0, 0, 0, s.loc)
+		case !s.hour[t.Hour()]:
+			next = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, s.loc)
+		case !s.minute[t.Minute()]:
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+		// Around DST transitions wall-clock arithmetic can land on or
+		// before t; always move forward.
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom[t.Day()]
+	dow := s.dow[int(t.Weekday())]
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dow
+	case s.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2024, 6, 24, 12, 34, 56, 0, time.UTC) // Monday
+	cases := []struct {
+		name string
+		expr string
+		tz   string
+		from time.Time
+		want string
+	}{
+		{name: "every minute", expr: "* * * * *", want: "2024-06-24T12:35:00Z"},
+		{name: "hourly macro", expr: "@hourly", want: "2024-06-24T13:00:00Z"},
+		{name: "step", expr: "*/15 * * * *", want: "2024-06-24T12:45:00Z"},
+		{name: "range and list", expr: "0 9-10,18 * * *", want: "2024-06-24T18:00:00Z"},
+		{name: "weekday names", expr: "30 6 * * SAT,SUN", want: "2024-06-29T06:30:00Z"},
+		{name: "sunday as 7", expr: "0 0 * * 7", want: "2024-06-30T00:00:00Z"},
+		{name: "month rollover", expr: "0 0 1 * *", want: "2024-07-01T00:00:00Z"},
+		{name: "dom or dow", expr: "0 0 1 * MON", from: time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC), want: "2024-07-01T00:00:00Z"},
+		// EDINET publishes on JST business days; 18:00 JST is 09:00 UTC.
+		{name: "jst alias", expr: "0 18 * * 1-5", tz: "JST", want: "2024-06-25T09:00:00Z"},
+		// 17:00 New York is 21:00 UTC in summer and 22:00 UTC in winter.
+		{name: "et summer", expr: "0 17 * * *", tz: "ET", want: "2024-06-24T21:00:00Z"},
+		{name: "et winter", expr: "0 17 * * *", tz: "ET", from: time.Date(2024, 12, 2, 12, 0, 0, 0, time.UTC), want: "2024-12-02T22:00:00Z"},
+		// 02:30 does not exist on 2024-03-10 in New York; the next match is a day later.
+		{name: "dst gap", expr: "30 2 * * *", tz: "America/New_York", from: time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), want: "2024-03-11T06:30:00Z"},
+		{name: "impossible date", expr: "0 0 30 2 *", want: "0001-01-01T00:00:00Z"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.expr, tc.tz)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			f := tc.from
+			if f.IsZero() {
+				f = from
+			}
+			got := s.Next(f).UTC().Format(time.RFC3339)
+			if got != tc.want {
+				t.Fatalf("next=%s want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct{ expr, tz string }{
+		{"* * * *", ""},
+		{"60 * * * *", ""},
+		{"* * 0 * *", ""},
+		{"5-1 * * * *", ""},
+		{"*/0 * * * *", ""},
+		{"* * * FOO *", ""},
+		{"* * * * *", "Mars/Olympus"},
+	} {
+		if _, err := Parse(tc.expr, tc.tz); err == nil {
+			t.Fatalf("%q %q: expected error", tc.expr, tc.tz)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS run_raw_items (
+  run_id uuid NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+  raw_item_id uuid NOT NULL REFERENCES raw_items(id) ON DELETE CASCADE,
+  source_doc_id text,
+  is_duplicate boolean NOT NULL DEFAULT false,
+  linked_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (run_id, raw_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_raw_items_run
+ON run_raw_items(run_id, linked_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_run_raw_items_raw_item
+ON run_raw_items(raw_item_id);
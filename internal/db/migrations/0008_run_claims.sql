@@ -0,0 +1,8 @@
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS claimed_at timestamptz;
+
+-- Runs created before the executor existed were already fetched inline.
+UPDATE runs SET claimed_at = started_at WHERE claimed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_runs_unclaimed
+ON runs(started_at)
+WHERE status = 'running' AND claimed_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS fetched_at timestamptz;
+
+UPDATE runs SET fetched_at = claimed_at WHERE fetched_at IS NULL AND claimed_at IS NOT NULL;
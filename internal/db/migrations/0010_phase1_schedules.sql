@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS phase1_schedules (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name text NOT NULL UNIQUE,
+  cron text NOT NULL,
+  timezone text NOT NULL DEFAULT 'UTC',
+  config_json jsonb NOT NULL DEFAULT '{}'::jsonb,
+  is_active boolean NOT NULL DEFAULT true,
+  next_run_at timestamptz,
+  last_run_at timestamptz,
+  last_run_id uuid REFERENCES runs(id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_phase1_schedules_due
+ON phase1_schedules(next_run_at)
+WHERE is_active;
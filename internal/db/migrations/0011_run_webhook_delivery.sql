@@ -0,0 +1,4 @@
+-- Webhook senders retry; a delivery id starts at most one event_driven run.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_runs_webhook_delivery
+ON runs ((config_json->'trigger'->>'delivery_id'))
+WHERE mode = 'event_driven' AND config_json->'trigger'->>'delivery_id' IS NOT NULL;
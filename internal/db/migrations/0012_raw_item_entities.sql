@@ -0,0 +1,11 @@
+-- Links raw items to the universe items they were fetched for.
+CREATE TABLE IF NOT EXISTS raw_item_entities (
+  raw_item_id uuid NOT NULL REFERENCES raw_items(id) ON DELETE CASCADE,
+  universe_item_id uuid NOT NULL REFERENCES universe_items(id) ON DELETE CASCADE,
+  run_id uuid NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+  linked_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (run_id, raw_item_id, universe_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_raw_item_entities_universe
+ON raw_item_entities(universe_item_id, linked_at DESC);
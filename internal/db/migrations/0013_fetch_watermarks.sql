@@ -0,0 +1,10 @@
+-- Newest document stored per (source, entity) so runs only fetch new ones.
+CREATE TABLE IF NOT EXISTS fetch_watermarks (
+  source text NOT NULL,
+  entity_key text NOT NULL,
+  last_published_at timestamptz NOT NULL,
+  last_doc_id text NOT NULL,
+  run_id uuid REFERENCES runs(id) ON DELETE SET NULL,
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (source, entity_key)
+);
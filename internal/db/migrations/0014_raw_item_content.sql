@@ -0,0 +1,3 @@
+-- Extractive summary and detected language of a raw item's text.
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS summary text NOT NULL DEFAULT '';
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS language text NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Score and evidence of the links found by entity resolution. Links to the
+-- universe item a document was fetched for score 1.
+ALTER TABLE raw_item_entities ADD COLUMN IF NOT EXISTS score double precision NOT NULL DEFAULT 1;
+ALTER TABLE raw_item_entities ADD COLUMN IF NOT EXISTS evidence jsonb NOT NULL DEFAULT '[]'::jsonb;
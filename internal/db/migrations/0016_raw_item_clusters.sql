@@ -0,0 +1,15 @@
+-- Near-duplicate clusters of raw items. simhash fingerprints the extracted
+-- text; an item whose simhash is within a few bits of an earlier item's
+-- joins that item's cluster, and the first item of a cluster is its
+-- canonical representative (cluster_id = id).
+CREATE OR REPLACE FUNCTION simhash_bands(h bigint) RETURNS int[]
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+  SELECT array_agg(i * 256 + ((h >> (8 * i)) & 255)::int ORDER BY i) FROM generate_series(0, 7) AS i
+$$;
+
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS simhash bigint;
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS cluster_id uuid REFERENCES raw_items(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_raw_items_simhash_bands
+ON raw_items USING gin (simhash_bands(simhash)) WHERE simhash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_raw_items_cluster ON raw_items(cluster_id);
@@ -0,0 +1,36 @@
+-- Full-text search over raw items, events, phase artifacts and decisions.
+-- English text is matched through the search_tsv columns; Japanese (and
+-- other unsegmented) text through trigram indexes on the same content.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS search_tsv tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', title), 'A') ||
+  setweight(to_tsvector('english', left(raw_text, 200000)), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_raw_items_search ON raw_items USING gin (search_tsv);
+CREATE INDEX IF NOT EXISTS idx_raw_items_search_trgm
+ON raw_items USING gin ((title || ' ' || left(raw_text, 200000)) gin_trgm_ops);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS search_tsv tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', title), 'A') ||
+  setweight(jsonb_to_tsvector('english', facts_json, '["string"]'), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_events_search ON events USING gin (search_tsv);
+CREATE INDEX IF NOT EXISTS idx_events_search_trgm
+ON events USING gin ((title || ' ' || facts_json::text) gin_trgm_ops);
+
+ALTER TABLE phase_artifacts ADD COLUMN IF NOT EXISTS search_tsv tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', artifact_type), 'A') ||
+  setweight(to_tsvector('english', COALESCE(content_md, '')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_artifacts_search ON phase_artifacts USING gin (search_tsv);
+CREATE INDEX IF NOT EXISTS idx_artifacts_search_trgm
+ON phase_artifacts USING gin ((COALESCE(content_md, '')) gin_trgm_ops);
+
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS search_tsv tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', final_label), 'A') ||
+  setweight(to_tsvector('english', decision_md), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_decisions_search ON decisions USING gin (search_tsv);
+CREATE INDEX IF NOT EXISTS idx_decisions_search_trgm
+ON decisions USING gin (decision_md gin_trgm_ops);
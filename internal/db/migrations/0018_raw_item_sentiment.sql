@@ -0,0 +1,5 @@
+-- Tone of a raw item's text (sentiment.Scores) and the document type it was
+-- fetched as, so a filing can be compared with the previous filing of the
+-- same type.
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS sentiment jsonb;
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS doc_type text NOT NULL DEFAULT '';
@@ -0,0 +1,19 @@
+-- raw_items.hash now covers only the normalized title and text, so the same
+-- document under another URL or source is a duplicate. A refetch of the same
+-- URL is caught separately by (source_type, url_key). url_key is the item's
+-- url, left null for an empty url and, on rows stored before this
+-- migration, for every row but the first with a given source and url.
+ALTER TABLE raw_items ADD COLUMN IF NOT EXISTS url_key text;
+
+UPDATE raw_items r
+SET url_key = r.url
+FROM (
+  SELECT DISTINCT ON (source_type, url) id
+  FROM raw_items
+  WHERE url <> ''
+  ORDER BY source_type, url, fetched_at, id
+) first
+WHERE r.id = first.id AND r.url_key IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_raw_items_source_url
+ON raw_items(source_type, url_key);
@@ -0,0 +1,5 @@
+-- Claims of runs that are still being fetched expire unless the executor
+-- renews them, so a run claimed by a crashed executor is claimed again.
+CREATE INDEX IF NOT EXISTS idx_runs_claimed_unfetched
+ON runs(claimed_at)
+WHERE status = 'running' AND fetched_at IS NULL AND claimed_at IS NOT NULL;
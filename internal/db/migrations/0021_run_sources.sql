@@ -0,0 +1,9 @@
+-- The sorted, distinct sources of a run config, so that runs fetching the
+-- same sources can be compared.
+CREATE OR REPLACE FUNCTION run_sources(config jsonb) RETURNS text[]
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+  SELECT COALESCE(array_agg(DISTINCT s ORDER BY s), '{}')
+  FROM jsonb_array_elements_text(
+    CASE WHEN jsonb_typeof(config->'sources') = 'array' THEN config->'sources' ELSE '[]'::jsonb END
+  ) AS s
+$$;
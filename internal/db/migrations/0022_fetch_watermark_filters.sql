@@ -0,0 +1,5 @@
+-- Watermarks are now kept per filter and only advanced by successful runs.
+-- Earlier ones may have been advanced by filtered or failed runs past
+-- documents that were never stored, so fetching starts over from each
+-- source's default window.
+DELETE FROM fetch_watermarks;
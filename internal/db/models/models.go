@@ -26,6 +26,30 @@ type Run struct {
 	StartedAt  time.Time       `json:"started_at"`
 	FinishedAt *time.Time      `json:"finished_at,omitempty"`
 	Error      *string         `json:"error,omitempty"`
+	FetchedAt  *time.Time      `json:"fetched_at,omitempty"`
+}
+
+type Phase1Schedule struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Cron       string          `json:"cron"`
+	Timezone   string          `json:"timezone"`
+	ConfigJSON json.RawMessage `json:"config"`
+	IsActive   bool            `json:"is_active"`
+	NextRunAt  *time.Time      `json:"next_run_at,omitempty"`
+	LastRunAt  *time.Time      `json:"last_run_at,omitempty"`
+	LastRunID  *string         `json:"last_run_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+type FetchWatermark struct {
+	Source          string    `json:"source"`
+	EntityKey       string    `json:"entity_key"`
+	LastPublishedAt time.Time `json:"last_published_at"`
+	LastDocID       string    `json:"last_doc_id"`
+	RunID           *string   `json:"run_id,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 type RawItem struct {
@@ -39,6 +63,40 @@ type RawItem struct {
 	RawText    string     `json:"raw_text"`
 	Hash       string     `json:"hash"`
 	FetchedAt  time.Time  `json:"fetched_at"`
+	// Summary and Language are extracted from RawText.
+	Summary  string `json:"summary,omitempty"`
+	Language string `json:"language,omitempty"`
+	// ClusterID is the canonical raw item of the item's near-duplicate
+	// cluster; nil when the text was too short to be fingerprinted.
+	ClusterID *string `json:"cluster_id,omitempty"`
+	// DocType is the source's document type (form type, EDINET label).
+	DocType string `json:"doc_type,omitempty"`
+	// Sentiment is the tone of RawText (sentiment.Scores); nil when it has
+	// no lexicon for the language.
+	Sentiment json.RawMessage `json:"sentiment,omitempty"`
+}
+
+type RunRawItem struct {
+	RawItem
+	SourceDocID *string   `json:"source_doc_id,omitempty"`
+	Duplicate   bool      `json:"is_duplicate"`
+	LinkedAt    time.Time `json:"linked_at"`
+	// UniverseItemIDs are the universe items the item is linked to, best
+	// scored first.
+	UniverseItemIDs []string `json:"universe_item_ids"`
+	// Entities are the links to those universe items.
+	Entities []RawItemEntity `json:"entities"`
+	// CanonicalHash is the hash of the cluster's canonical raw item, or the
+	// item's own hash when it is not clustered.
+	CanonicalHash string `json:"canonical_hash"`
+}
+
+// RawItemEntity links a raw item to a universe item in a run, either because
+// it was fetched for the item (score 1) or by entity resolution.
+type RawItemEntity struct {
+	UniverseItemID string   `json:"universe_item_id"`
+	Score          float64  `json:"score"`
+	Evidence       []string `json:"evidence"`
 }
 
 type Event struct {
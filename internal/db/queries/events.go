@@ -0,0 +1,154 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"investment_committee/internal/db/models"
+)
+
+// EventFilter selects events across runs. Filtering by entity uses
+// idx_events_entity and by category idx_events_category.
+type EventFilter struct {
+	EntityType    *string
+	EntityID      *string
+	Category      *string
+	MinConfidence *float64
+	From          *time.Time
+	To            *time.Time
+	Limit         int
+	Cursor        *EventCursor
+}
+
+// EventCursor is the position after the last event of a page. Events are
+// ordered by observed_at, which is not unique, so the event id breaks ties.
+type EventCursor struct {
+	ObservedAt time.Time
+	EventID    string
+}
+
+func (c EventCursor) String() string {
+	return c.ObservedAt.UTC().Format(time.RFC3339Nano) + "|" + c.EventID
+}
+
+// ParseEventCursor parses EventCursor.String output; "" is no cursor.
+func ParseEventCursor(s string) (*EventCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ts, id, ok := strings.Cut(s, "|")
+	if !ok || id == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &EventCursor{ObservedAt: t, EventID: id}, nil
+}
+
+// CreateEvent stores e unless an event with the same dedupe_key exists, in
+// which case it returns the existing event's id and created=false. An empty
+// EventID is generated.
+func (r *Repository) CreateEvent(ctx context.Context, e models.Event) (id string, created bool, err error) {
+	var impact, tags any
+	if len(e.ImpactJSON) > 0 {
+		impact = []byte(e.ImpactJSON)
+	}
+	if len(e.TagsJSON) > 0 {
+		tags = []byte(e.TagsJSON)
+	}
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO events (event_id, run_id, observed_at, entity_type, entity_id, category, title,
+		                    facts_json, impact_json, sources_json, confidence, dedupe_key, tags_json)
+		VALUES (COALESCE(NULLIF($1, ''), uuid_generate_v4()::text), $2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+		ON CONFLICT (dedupe_key) DO NOTHING
+		RETURNING event_id
+	`, e.EventID, e.RunID, e.ObservedAt, e.EntityType, e.EntityID, e.Category, e.Title,
+		[]byte(e.FactsJSON), impact, []byte(e.Sources), e.Confidence, e.DedupeKey, tags).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = r.db.QueryRowContext(ctx, `SELECT event_id FROM events WHERE dedupe_key = $1`, e.DedupeKey).Scan(&id)
+		return id, false, err
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
+// ListEvents returns the events matching f, most recently observed first.
+func (r *Repository) ListEvents(ctx context.Context, f EventFilter) ([]models.Event, *EventCursor, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args := []any{}
+	where := "WHERE 1=1"
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where += " AND " + cond + " $" + itoa(len(args))
+	}
+	if f.EntityType != nil {
+		add("entity_type =", *f.EntityType)
+	}
+	if f.EntityID != nil {
+		add("entity_id =", *f.EntityID)
+	}
+	if f.Category != nil {
+		add("category =", *f.Category)
+	}
+	if f.MinConfidence != nil {
+		add("confidence >=", *f.MinConfidence)
+	}
+	if f.From != nil {
+		add("observed_at >=", *f.From)
+	}
+	if f.To != nil {
+		add("observed_at <", *f.To)
+	}
+	if f.Cursor != nil {
+		args = append(args, f.Cursor.ObservedAt, f.Cursor.EventID)
+		where += " AND (observed_at, event_id) < ($" + itoa(len(args)-1) + ", $" + itoa(len(args)) + ")"
+	}
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT event_id, run_id, observed_at, entity_type, entity_id, category, title,
+		       facts_json, impact_json, sources_json, confidence, dedupe_key, tags_json, created_at
+		FROM events
+		`+where+`
+		ORDER BY observed_at DESC, event_id DESC
+		LIMIT $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Event
+	for rows.Next() {
+		var e models.Event
+		var impact, tags sql.NullString
+		if err := rows.Scan(&e.EventID, &e.RunID, &e.ObservedAt, &e.EntityType, &e.EntityID, &e.Category, &e.Title,
+			&e.FactsJSON, &impact, &e.Sources, &e.Confidence, &e.DedupeKey, &tags, &e.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		if impact.Valid {
+			e.ImpactJSON = json.RawMessage(impact.String)
+		}
+		if tags.Valid {
+			e.TagsJSON = json.RawMessage(tags.String)
+		}
+		items = append(items, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(items) < limit {
+		return items, nil, nil
+	}
+	last := items[len(items)-1]
+	return items, &EventCursor{ObservedAt: last.ObservedAt, EventID: last.EventID}, nil
+}
@@ -0,0 +1,51 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+
+	"investment_committee/internal/db/models"
+)
+
+func (r *Repository) ListFetchWatermarks(ctx context.Context, source string) ([]models.FetchWatermark, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT source, entity_key, last_published_at, last_doc_id, run_id, updated_at
+		FROM fetch_watermarks
+		WHERE source = $1
+	`, source)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.FetchWatermark
+	for rows.Next() {
+		var w models.FetchWatermark
+		var runID sql.NullString
+		if err := rows.Scan(&w.Source, &w.EntityKey, &w.LastPublishedAt, &w.LastDocID, &runID, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if runID.Valid {
+			v := runID.String
+			w.RunID = &v
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// advanceFetchWatermark stores w unless the stored watermark is already
+// newer, so a backfill or a slow run never moves it back.
+func advanceFetchWatermark(ctx context.Context, tx *sql.Tx, w models.FetchWatermark) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO fetch_watermarks (source, entity_key, last_published_at, last_doc_id, run_id)
+		VALUES ($1,$2,$3,$4,$5)
+		ON CONFLICT (source, entity_key) DO UPDATE
+		SET last_published_at = EXCLUDED.last_published_at,
+		    last_doc_id = EXCLUDED.last_doc_id,
+		    run_id = EXCLUDED.run_id,
+		    updated_at = now()
+		WHERE fetch_watermarks.last_published_at < EXCLUDED.last_published_at
+	`, w.Source, w.EntityKey, w.LastPublishedAt, w.LastDocID, w.RunID)
+	return err
+}
@@ -3,6 +3,7 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"investment_committee/internal/db/models"
 )
@@ -11,6 +12,46 @@ func (r *Repository) CreateHandoff(ctx context.Context, h models.HandoffPacket)
 	return r.CreateHandoffPacket(ctx, h)
 }
 
+// CreateGeneratedHandoff stores h unless its run already has a handoff of
+// the same type, in which case it returns ErrHandoffExists. The check and the
+// insert run under a per-run lock, so of concurrent calls only one creates
+// the handoff.
+func (r *Repository) CreateGeneratedHandoff(ctx context.Context, h models.HandoffPacket) (id string, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('handoff:' || $1::text))`, h.RunID); err != nil {
+		return "", err
+	}
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM handoff_packets WHERE run_id = $1 AND handoff_type = $2)
+	`, h.RunID, h.HandoffType).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		err = ErrHandoffExists
+		return "", err
+	}
+	if err = tx.QueryRowContext(ctx, `
+		INSERT INTO handoff_packets (run_id, case_id, handoff_type, from_phase, to_phase, packet_json, status)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`, h.RunID, h.CaseID, h.HandoffType, h.FromPhase, h.ToPhase, h.PacketJSON, h.Status).Scan(&id); err != nil {
+		return "", err
+	}
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
 func (r *Repository) GetHandoff(ctx context.Context, id string) (models.HandoffPacket, error) {
 	var h models.HandoffPacket
 	var caseID sql.NullString
@@ -32,6 +73,27 @@ func (r *Repository) GetHandoff(ctx context.Context, id string) (models.HandoffP
 	return h, nil
 }
 
+// UpdateHandoffPacket replaces the packet of a handoff that is still in
+// status created; otherwise it returns ErrNotFound.
+func (r *Repository) UpdateHandoffPacket(ctx context.Context, id string, packet json.RawMessage) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE handoff_packets
+		SET packet_json = $1
+		WHERE id = $2 AND status = 'created'
+	`, packet, id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *Repository) AttachCaseToHandoff(ctx context.Context, handoffID string, caseID string) error {
 	res, err := r.db.ExecContext(ctx, `
 		UPDATE handoff_packets
@@ -8,6 +8,14 @@ import (
 
 var ErrNotFound = errors.New("not found")
 
+// ErrRunFinalized is returned when a Phase1 run already has a run.finalized
+// event and therefore accepts no further events.
+var ErrRunFinalized = errors.New("run finalized")
+
+// ErrHandoffExists is returned when a run already has a handoff of the type
+// being generated.
+var ErrHandoffExists = errors.New("handoff exists")
+
 func marshalJSON(v any) ([]byte, error) {
 	if v == nil {
 		return []byte("null"), nil
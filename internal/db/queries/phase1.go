@@ -19,6 +19,21 @@ func (r *Repository) CreateRun(ctx context.Context, mode string, config json.Raw
 	return id, err
 }
 
+// FindRunByWebhookDelivery returns the event_driven run created for a webhook
+// delivery, if any.
+func (r *Repository) FindRunByWebhookDelivery(ctx context.Context, deliveryID string) (string, error) {
+	var id string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id FROM runs
+		WHERE mode = 'event_driven' AND config_json->'trigger'->>'delivery_id' = $1
+		LIMIT 1
+	`, deliveryID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return id, err
+}
+
 func (r *Repository) UpdateRunStatus(ctx context.Context, runID string, status string, errMsg *string) error {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE runs
@@ -28,15 +43,51 @@ func (r *Repository) UpdateRunStatus(ctx context.Context, runID string, status s
 	return err
 }
 
+// ClaimNextRun marks the oldest unclaimed running Phase1 run as claimed and
+// returns it. SKIP LOCKED lets several executors poll the same table. A run
+// whose claim has not been renewed for lease before it was fetched belongs
+// to an executor that stopped, and is claimed again.
+func (r *Repository) ClaimNextRun(ctx context.Context, lease time.Duration) (models.Run, error) {
+	var run models.Run
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE runs
+		SET claimed_at = now()
+		WHERE id = (
+			SELECT id FROM runs
+			WHERE phase = 1 AND status = 'running'
+			  AND (claimed_at IS NULL
+			       OR (fetched_at IS NULL AND claimed_at < now() - make_interval(secs => $1)))
+			ORDER BY started_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, phase, mode, status, config_json, started_at
+	`, lease.Seconds()).Scan(&run.ID, &run.Phase, &run.Mode, &run.Status, &run.ConfigJSON, &run.StartedAt)
+	if err == sql.ErrNoRows {
+		return run, ErrNotFound
+	}
+	return run, err
+}
+
+// RenewRunClaim extends the claim of a run that is still being fetched.
+func (r *Repository) RenewRunClaim(ctx context.Context, runID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE runs SET claimed_at = now()
+		WHERE id = $1 AND status = 'running' AND fetched_at IS NULL
+	`, runID)
+	return err
+}
+
 func (r *Repository) GetRun(ctx context.Context, runID string) (models.Run, error) {
 	var run models.Run
 	var finishedAt sql.NullTime
 	var errMsg sql.NullString
+	var fetchedAt sql.NullTime
 	err := r.db.QueryRowContext(ctx, `
-		SELECT id, phase, mode, status, config_json, started_at, finished_at, error
+		SELECT id, phase, mode, status, config_json, started_at, finished_at, error, fetched_at
 		FROM runs
 		WHERE id = $1
-	`, runID).Scan(&run.ID, &run.Phase, &run.Mode, &run.Status, &run.ConfigJSON, &run.StartedAt, &finishedAt, &errMsg)
+	`, runID).Scan(&run.ID, &run.Phase, &run.Mode, &run.Status, &run.ConfigJSON, &run.StartedAt, &finishedAt, &errMsg, &fetchedAt)
 	if err == sql.ErrNoRows {
 		return run, ErrNotFound
 	}
@@ -51,9 +102,18 @@ func (r *Repository) GetRun(ctx context.Context, runID string) (models.Run, erro
 		s := errMsg.String
 		run.Error = &s
 	}
+	if fetchedAt.Valid {
+		t := fetchedAt.Time
+		run.FetchedAt = &t
+	}
 	return run, nil
 }
 
+func (r *Repository) MarkRunFetched(ctx context.Context, runID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE runs SET fetched_at = now() WHERE id = $1`, runID)
+	return err
+}
+
 func (r *Repository) ListEventsByRun(ctx context.Context, runID string, limit int, cursor *time.Time) ([]models.Event, *time.Time, error) {
 	if limit <= 0 || limit > 200 {
 		limit = 50
@@ -100,6 +160,39 @@ func (r *Repository) ListEventsByRun(ctx context.Context, runID string, limit in
 	return items, last, rows.Err()
 }
 
+// ListAllEventsByRun returns every event of a run, oldest first.
+func (r *Repository) ListAllEventsByRun(ctx context.Context, runID string) ([]models.Event, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT event_id, run_id, observed_at, entity_type, entity_id, category, title,
+		       facts_json, impact_json, sources_json, confidence, dedupe_key, tags_json, created_at
+		FROM events
+		WHERE run_id = $1
+		ORDER BY created_at, event_id
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Event
+	for rows.Next() {
+		var e models.Event
+		var impact, tags sql.NullString
+		if err := rows.Scan(&e.EventID, &e.RunID, &e.ObservedAt, &e.EntityType, &e.EntityID, &e.Category, &e.Title,
+			&e.FactsJSON, &impact, &e.Sources, &e.Confidence, &e.DedupeKey, &tags, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if impact.Valid {
+			e.ImpactJSON = json.RawMessage(impact.String)
+		}
+		if tags.Valid {
+			e.TagsJSON = json.RawMessage(tags.String)
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
 func (r *Repository) GetAnomalySummaryByRun(ctx context.Context, runID string) (models.AnomalySummary, error) {
 	var a models.AnomalySummary
 	err := r.db.QueryRowContext(ctx, `
@@ -154,22 +247,59 @@ func (r *Repository) ListHandoffsByRun(ctx context.Context, runID string) ([]mod
 	return items, rows.Err()
 }
 
-func (r *Repository) CreateAnomalySummary(ctx context.Context, runID string, summary json.RawMessage) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO anomaly_summaries (run_id, summary_json)
-		VALUES ($1, $2)
-		ON CONFLICT (run_id) DO UPDATE SET summary_json = EXCLUDED.summary_json, created_at = now()
-	`, runID, summary)
-	return err
+// ListPriorAnomalySummaries returns the summaries of up to limit successful
+// runs started before runID with its mode and sources, most recent first.
+func (r *Repository) ListPriorAnomalySummaries(ctx context.Context, runID string, limit int) ([]json.RawMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.summary_json
+		FROM anomaly_summaries a
+		JOIN runs r ON r.id = a.run_id
+		JOIN runs cur ON cur.id = $1
+		WHERE r.started_at < cur.started_at
+		  AND r.status = 'success'
+		  AND r.mode = cur.mode
+		  AND run_sources(r.config_json) = run_sources(cur.config_json)
+		ORDER BY r.started_at DESC
+		LIMIT $2
+	`, runID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []json.RawMessage
+	for rows.Next() {
+		var s json.RawMessage
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
 }
 
-func (r *Repository) CreateTriggerDecision(ctx context.Context, runID string, decision json.RawMessage) error {
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO trigger_decisions (run_id, decision_json)
-		VALUES ($1, $2)
-		ON CONFLICT (run_id) DO UPDATE SET decision_json = EXCLUDED.decision_json, created_at = now()
-	`, runID, decision)
-	return err
+func (r *Repository) CountEventsByCategory(ctx context.Context, runID string) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT category, count(*)
+		FROM events
+		WHERE run_id = $1
+		GROUP BY category
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]int{}
+	for rows.Next() {
+		var category string
+		var n int
+		if err := rows.Scan(&category, &n); err != nil {
+			return nil, err
+		}
+		out[category] = n
+	}
+	return out, rows.Err()
 }
 
 func (r *Repository) CreateHandoffPacket(ctx context.Context, h models.HandoffPacket) (string, error) {
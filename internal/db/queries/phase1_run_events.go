@@ -2,6 +2,8 @@ package queries
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"investment_committee/internal/db/models"
@@ -18,23 +20,122 @@ func (r *Repository) CreatePhase1RunEvent(ctx context.Context, e models.Phase1Ru
 			_ = tx.Rollback()
 		}
 	}()
-	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, e.RunID); err != nil {
+	if err = lockOpenRun(ctx, tx, e.RunID); err != nil {
 		return 0, err
 	}
-	err = tx.QueryRowContext(ctx, `
-		INSERT INTO phase1_run_events (run_id, seq, event_type, source, occurred_at, payload_json)
-		SELECT $1, COALESCE(MAX(seq), 0) + 1, $2, $3, $4, $5
+	if seq, err = insertPhase1RunEvent(ctx, tx, e); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// lockOpenRun takes the run's event lock until tx ends and returns
+// ErrRunFinalized when the run already has a run.finalized event.
+func lockOpenRun(ctx context.Context, tx *sql.Tx, runID string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, runID); err != nil {
+		return err
+	}
+	var finalized bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM phase1_run_events WHERE run_id = $1 AND event_type = 'run.finalized')
+	`, runID).Scan(&finalized); err != nil {
+		return err
+	}
+	if finalized {
+		return ErrRunFinalized
+	}
+	return nil
+}
+
+// insertPhase1RunEvent appends e with the next seq of its run. created_at is
+// the clock time rather than the transaction's, so that events appended in
+// one transaction still page apart by created_at.
+func insertPhase1RunEvent(ctx context.Context, tx *sql.Tx, e models.Phase1RunEvent) (int, error) {
+	var seq int
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO phase1_run_events (run_id, seq, event_type, source, occurred_at, payload_json, created_at)
+		SELECT $1, COALESCE(MAX(seq), 0) + 1, $2, $3, $4, $5, clock_timestamp()
 		FROM phase1_run_events
 		WHERE run_id = $1
 		RETURNING seq
 	`, e.RunID, e.EventType, e.Source, e.OccurredAt, e.Payload).Scan(&seq)
+	return seq, err
+}
+
+// RunFinalization is what closes a Phase1 run. AnomalySummary and
+// TriggerDecision are nil for a run that was not evaluated. Signals are
+// appended before Finalized. Watermarks are the fetch watermarks the run
+// advances; only a successful run has any.
+type RunFinalization struct {
+	RunID           string
+	AnomalySummary  json.RawMessage
+	TriggerDecision json.RawMessage
+	Signals         []models.Phase1RunEvent
+	Watermarks      []models.FetchWatermark
+	Finalized       models.Phase1RunEvent
+	Status          string
+	Error           *string
+}
+
+// FinalizePhase1Run writes f in one transaction that first claims the run
+// under its event lock. Of concurrent or retried finalizes only the first
+// writes anything; the others get ErrRunFinalized, and a finalize that fails
+// partway writes nothing.
+func (r *Repository) FinalizePhase1Run(ctx context.Context, f RunFinalization) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	if err = tx.Commit(); err != nil {
-		return 0, err
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err = lockOpenRun(ctx, tx, f.RunID); err != nil {
+		return err
 	}
-	return seq, nil
+	if f.AnomalySummary != nil {
+		if _, err = tx.ExecContext(ctx, `
+			INSERT INTO anomaly_summaries (run_id, summary_json)
+			VALUES ($1, $2)
+			ON CONFLICT (run_id) DO UPDATE SET summary_json = EXCLUDED.summary_json, created_at = now()
+		`, f.RunID, f.AnomalySummary); err != nil {
+			return err
+		}
+	}
+	if f.TriggerDecision != nil {
+		if _, err = tx.ExecContext(ctx, `
+			INSERT INTO trigger_decisions (run_id, decision_json)
+			VALUES ($1, $2)
+			ON CONFLICT (run_id) DO UPDATE SET decision_json = EXCLUDED.decision_json, created_at = now()
+		`, f.RunID, f.TriggerDecision); err != nil {
+			return err
+		}
+	}
+	for _, w := range f.Watermarks {
+		if err = advanceFetchWatermark(ctx, tx, w); err != nil {
+			return err
+		}
+	}
+	for _, e := range f.Signals {
+		if _, err = insertPhase1RunEvent(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	if _, err = insertPhase1RunEvent(ctx, tx, f.Finalized); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE runs
+		SET status = $1, finished_at = now(), error = $2
+		WHERE id = $3
+	`, f.Status, f.Error, f.RunID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *Repository) ListPhase1RunEventsByRunID(ctx context.Context, runID string, limit int, cursor *time.Time) ([]models.Phase1RunEvent, *time.Time, error) {
@@ -72,3 +173,27 @@ func (r *Repository) ListPhase1RunEventsByRunID(ctx context.Context, runID strin
 	}
 	return items, last, rows.Err()
 }
+
+// ListAllPhase1RunEvents returns every event of a run in seq order.
+func (r *Repository) ListAllPhase1RunEvents(ctx context.Context, runID string) ([]models.Phase1RunEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT run_id, seq, event_type, source, occurred_at, payload_json, created_at
+		FROM phase1_run_events
+		WHERE run_id = $1
+		ORDER BY seq
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Phase1RunEvent
+	for rows.Next() {
+		var e models.Phase1RunEvent
+		if err := rows.Scan(&e.RunID, &e.Seq, &e.EventType, &e.Source, &e.OccurredAt, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
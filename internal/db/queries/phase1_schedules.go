@@ -0,0 +1,230 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"investment_committee/internal/db/models"
+)
+
+type ScheduleUpdate struct {
+	Name      *string
+	Cron      *string
+	Timezone  *string
+	Config    json.RawMessage
+	IsActive  *bool
+	NextRunAt *time.Time
+}
+
+const scheduleColumns = `id, name, cron, timezone, config_json, is_active, next_run_at, last_run_at, last_run_id, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSchedule(row rowScanner) (models.Phase1Schedule, error) {
+	var s models.Phase1Schedule
+	var next, last sql.NullTime
+	var lastRunID sql.NullString
+	if err := row.Scan(&s.ID, &s.Name, &s.Cron, &s.Timezone, &s.ConfigJSON, &s.IsActive, &next, &last, &lastRunID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return s, err
+	}
+	if next.Valid {
+		t := next.Time
+		s.NextRunAt = &t
+	}
+	if last.Valid {
+		t := last.Time
+		s.LastRunAt = &t
+	}
+	if lastRunID.Valid {
+		v := lastRunID.String
+		s.LastRunID = &v
+	}
+	return s, nil
+}
+
+func (r *Repository) CreateSchedule(ctx context.Context, s models.Phase1Schedule) (string, error) {
+	var id string
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO phase1_schedules (name, cron, timezone, config_json, is_active, next_run_at)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING id
+	`, s.Name, s.Cron, s.Timezone, s.ConfigJSON, s.IsActive, s.NextRunAt).Scan(&id)
+	return id, err
+}
+
+func (r *Repository) GetSchedule(ctx context.Context, id string) (models.Phase1Schedule, error) {
+	s, err := scanSchedule(r.db.QueryRowContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM phase1_schedules
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return s, ErrNotFound
+	}
+	return s, err
+}
+
+func (r *Repository) ListSchedules(ctx context.Context, limit int, cursor *time.Time) ([]models.Phase1Schedule, *time.Time, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args := []any{}
+	where := "WHERE 1=1"
+	if cursor != nil {
+		args = append(args, *cursor)
+		where += " AND created_at < $" + itoa(len(args))
+	}
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM phase1_schedules
+		`+where+`
+		ORDER BY created_at DESC
+		LIMIT $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var items []models.Phase1Schedule
+	var last *time.Time
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, s)
+		t := s.CreatedAt
+		last = &t
+	}
+	return items, last, rows.Err()
+}
+
+func (r *Repository) UpdateSchedule(ctx context.Context, id string, u ScheduleUpdate) error {
+	set := "SET updated_at = now()"
+	args := []any{}
+	if u.Name != nil {
+		args = append(args, *u.Name)
+		set += ", name = $" + itoa(len(args))
+	}
+	if u.Cron != nil {
+		args = append(args, *u.Cron)
+		set += ", cron = $" + itoa(len(args))
+	}
+	if u.Timezone != nil {
+		args = append(args, *u.Timezone)
+		set += ", timezone = $" + itoa(len(args))
+	}
+	if u.Config != nil {
+		args = append(args, u.Config)
+		set += ", config_json = $" + itoa(len(args))
+	}
+	if u.IsActive != nil {
+		args = append(args, *u.IsActive)
+		set += ", is_active = $" + itoa(len(args))
+	}
+	if u.NextRunAt != nil {
+		args = append(args, *u.NextRunAt)
+		set += ", next_run_at = $" + itoa(len(args))
+	}
+	args = append(args, id)
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE phase1_schedules
+		`+set+`
+		WHERE id = $`+itoa(len(args)), args...)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) DeleteSchedule(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM phase1_schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FireDueSchedules creates a scheduled run for every active schedule whose
+// next_run_at has passed and moves next_run_at to next(schedule). Each
+// schedule is locked with SKIP LOCKED and advanced in the same transaction as
+// its run, so replicas polling concurrently fire a given slot exactly once.
+// A nil next deactivates the schedule.
+func (r *Repository) FireDueSchedules(ctx context.Context, now time.Time, next func(models.Phase1Schedule) *time.Time) (runIDs []string, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM phase1_schedules
+		WHERE is_active AND next_run_at <= $1
+		ORDER BY next_run_at
+		LIMIT 50
+		FOR UPDATE SKIP LOCKED
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	var due []models.Phase1Schedule
+	for rows.Next() {
+		s, scanErr := scanSchedule(rows)
+		if scanErr != nil {
+			rows.Close()
+			return nil, scanErr
+		}
+		due = append(due, s)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range due {
+		var runID string
+		if err = tx.QueryRowContext(ctx, `
+			INSERT INTO runs (phase, mode, status, config_json)
+			VALUES (1, 'scheduled', 'running', $1)
+			RETURNING id
+		`, s.ConfigJSON).Scan(&runID); err != nil {
+			return nil, err
+		}
+		n := next(s)
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE phase1_schedules
+			SET next_run_at = $1, is_active = $2, last_run_at = $3, last_run_id = $4
+			WHERE id = $5
+		`, n, n != nil, now, runID, s.ID); err != nil {
+			return nil, err
+		}
+		runIDs = append(runIDs, runID)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return runIDs, nil
+}
@@ -0,0 +1,273 @@
+package queries
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"investment_committee/internal/db/models"
+)
+
+// RawItemHash is the content hash used to dedupe raw items across runs: the
+// title and text case-folded with whitespace collapsed, so the same document
+// syndicated under another URL or source hashes the same. Source and URL are
+// a separate unique key (url_key).
+func RawItemHash(title, text string) string {
+	h := sha256.New()
+	for _, s := range []string{title, text} {
+		h.Write([]byte(strings.Join(strings.Fields(strings.ToLower(s)), " ")))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// UpsertRawItem stores item unless a raw item with the same hash, or the same
+// source and URL, exists, and links the stored or existing row to runID.
+// duplicate reports whether the document had already been stored.
+func (r *Repository) UpsertRawItem(ctx context.Context, runID string, item models.RawItem, sourceDocID string) (id string, duplicate bool, err error) {
+	if item.Hash == "" {
+		item.Hash = RawItemHash(item.Title, item.RawText)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	var sentiment any
+	if len(item.Sentiment) > 0 {
+		sentiment = []byte(item.Sentiment)
+	}
+	var urlKey *string
+	if item.URL != "" {
+		urlKey = &item.URL
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO raw_items (run_id, source_type, source_name, url, title, published_at, raw_text, hash, summary, language,
+		                       doc_type, sentiment, url_key)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, runID, item.SourceType, item.SourceName, item.URL, item.Title, item.Published, item.RawText, item.Hash,
+		item.Summary, item.Language, item.DocType, sentiment, urlKey).Scan(&id)
+	if err == sql.ErrNoRows {
+		// Items stored before they were typed and scored get both now.
+		duplicate = true
+		err = tx.QueryRowContext(ctx, `
+			UPDATE raw_items
+			SET doc_type = COALESCE(NULLIF(doc_type, ''), $4),
+			    sentiment = COALESCE(sentiment, $5)
+			WHERE id = (
+				SELECT id FROM raw_items
+				WHERE hash = $1 OR (source_type = $2 AND url_key = $3)
+				ORDER BY hash = $1 DESC
+				LIMIT 1
+			)
+			RETURNING id
+		`, item.Hash, item.SourceType, urlKey, item.DocType, sentiment).Scan(&id)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	var docID *string
+	if sourceDocID != "" {
+		docID = &sourceDocID
+	}
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO run_raw_items (run_id, raw_item_id, source_doc_id, is_duplicate)
+		VALUES ($1,$2,$3,$4)
+		ON CONFLICT (run_id, raw_item_id) DO NOTHING
+	`, runID, id, docID, duplicate); err != nil {
+		return "", false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return "", false, err
+	}
+	return id, duplicate, nil
+}
+
+// runRawItemColumns selects a run's raw item links (alias l) with their
+// raw items (alias ri), in the order scanRunRawItems reads them.
+const runRawItemColumns = `
+		SELECT ri.id, ri.run_id, ri.source_type, ri.source_name, ri.url, ri.title, ri.published_at,
+		       ri.raw_text, ri.hash, ri.fetched_at, ri.summary, ri.language, ri.cluster_id, COALESCE(c.hash, ri.hash),
+		       ri.doc_type, ri.sentiment,
+		       l.source_doc_id, l.is_duplicate, l.linked_at,
+		       (SELECT COALESCE(json_agg(json_build_object(
+		                   'universe_item_id', e.universe_item_id, 'score', e.score, 'evidence', e.evidence)
+		                 ORDER BY e.score DESC, e.linked_at), '[]'::json)
+		          FROM raw_item_entities e
+		         WHERE e.raw_item_id = ri.id AND e.run_id = l.run_id)
+		FROM run_raw_items l
+		JOIN raw_items ri ON ri.id = l.raw_item_id
+		LEFT JOIN raw_items c ON c.id = ri.cluster_id`
+
+func (r *Repository) ListRawItemsByRun(ctx context.Context, runID string, limit int, cursor *time.Time) ([]models.RunRawItem, *time.Time, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args := []any{runID}
+	where := "WHERE l.run_id = $1"
+	if cursor != nil {
+		args = append(args, *cursor)
+		where += " AND l.linked_at < $" + itoa(len(args))
+	}
+	args = append(args, limit)
+	rows, err := r.db.QueryContext(ctx, runRawItemColumns+`
+		`+where+`
+		ORDER BY l.linked_at DESC
+		LIMIT $`+itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	items, err := scanRunRawItems(rows)
+	if err != nil || len(items) == 0 {
+		return items, nil, err
+	}
+	last := items[len(items)-1].LinkedAt
+	return items, &last, nil
+}
+
+// ListAllRawItemsByRun returns every raw item linked to a run, oldest link
+// first.
+func (r *Repository) ListAllRawItemsByRun(ctx context.Context, runID string) ([]models.RunRawItem, error) {
+	rows, err := r.db.QueryContext(ctx, runRawItemColumns+`
+		WHERE l.run_id = $1
+		ORDER BY l.linked_at, ri.id
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRunRawItems(rows)
+}
+
+func scanRunRawItems(rows *sql.Rows) ([]models.RunRawItem, error) {
+	var items []models.RunRawItem
+	for rows.Next() {
+		var it models.RunRawItem
+		var sourceName, docID, clusterID, sentiment sql.NullString
+		var published sql.NullTime
+		var entities []byte
+		if err := rows.Scan(&it.ID, &it.RunID, &it.SourceType, &sourceName, &it.URL, &it.Title, &published,
+			&it.RawText, &it.Hash, &it.FetchedAt, &it.Summary, &it.Language, &clusterID, &it.CanonicalHash,
+			&it.DocType, &sentiment, &docID, &it.Duplicate, &it.LinkedAt, &entities); err != nil {
+			return nil, err
+		}
+		if sentiment.Valid {
+			it.Sentiment = json.RawMessage(sentiment.String)
+		}
+		if err := json.Unmarshal(entities, &it.Entities); err != nil {
+			return nil, err
+		}
+		it.UniverseItemIDs = make([]string, 0, len(it.Entities))
+		for _, e := range it.Entities {
+			it.UniverseItemIDs = append(it.UniverseItemIDs, e.UniverseItemID)
+		}
+		if sourceName.Valid {
+			v := sourceName.String
+			it.SourceName = &v
+		}
+		if published.Valid {
+			t := published.Time
+			it.Published = &t
+		}
+		if docID.Valid {
+			v := docID.String
+			it.SourceDocID = &v
+		}
+		if clusterID.Valid {
+			v := clusterID.String
+			it.ClusterID = &v
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// ClusterRawItem stores the simhash of a raw item's text and puts the item
+// in the cluster of its nearest near-duplicate (at most maxDistance bits
+// apart) among the raw items fetched since since, or in a cluster of its
+// own. An item keeps the cluster it is already in. It returns the cluster id.
+func (r *Repository) ClusterRawItem(ctx context.Context, id string, simhash uint64, maxDistance int, since time.Time) (string, error) {
+	var clusterID string
+	err := r.db.QueryRowContext(ctx, `
+		WITH nearest AS (
+			SELECT COALESCE(c.cluster_id, c.id) AS cluster_id
+			FROM raw_items c
+			WHERE c.id <> $1 AND c.simhash IS NOT NULL
+			  AND simhash_bands(c.simhash) && simhash_bands($2)
+			  AND c.fetched_at >= $4
+			  AND bit_count((c.simhash # $2)::bit(64)) <= $3
+			ORDER BY bit_count((c.simhash # $2)::bit(64)), c.fetched_at, c.id
+			LIMIT 1
+		)
+		UPDATE raw_items
+		SET simhash = $2,
+		    cluster_id = COALESCE(raw_items.cluster_id, (SELECT cluster_id FROM nearest), raw_items.id)
+		WHERE id = $1
+		RETURNING cluster_id
+	`, id, int64(simhash), maxDistance, since).Scan(&clusterID)
+	return clusterID, err
+}
+
+// LinkRawItemEntity links a raw item to a universe item in a run with the
+// score and evidence of the link. Linking again keeps the better score.
+func (r *Repository) LinkRawItemEntity(ctx context.Context, runID, rawItemID string, link models.RawItemEntity) error {
+	evidence := link.Evidence
+	if evidence == nil {
+		evidence = []string{}
+	}
+	b, err := json.Marshal(evidence)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO raw_item_entities (raw_item_id, universe_item_id, run_id, score, evidence)
+		VALUES ($1,$2,$3,$4,$5)
+		ON CONFLICT (run_id, raw_item_id, universe_item_id) DO UPDATE
+		SET score = EXCLUDED.score, evidence = EXCLUDED.evidence
+		WHERE EXCLUDED.score > raw_item_entities.score
+	`, rawItemID, link.UniverseItemID, runID, link.Score, b)
+	return err
+}
+
+// PreviousFiling returns the latest scored raw item of the same source and
+// document type linked to universeItemID, published before before, other
+// than rawItemID. Only ID, SourceType, DocType, Published and Sentiment are
+// set. It returns ErrNotFound when there is none.
+func (r *Repository) PreviousFiling(ctx context.Context, universeItemID, source, docType, rawItemID string, before time.Time) (models.RawItem, error) {
+	var it models.RawItem
+	var published sql.NullTime
+	var sentiment []byte
+	err := r.db.QueryRowContext(ctx, `
+		SELECT ri.id, ri.source_type, ri.doc_type, ri.published_at, ri.sentiment
+		FROM raw_items ri
+		WHERE ri.source_type = $2 AND ri.doc_type = $3 AND ri.id <> $4
+		  AND ri.published_at < $5 AND ri.sentiment IS NOT NULL
+		  AND EXISTS (SELECT 1 FROM raw_item_entities e WHERE e.raw_item_id = ri.id AND e.universe_item_id = $1)
+		ORDER BY ri.published_at DESC, ri.id DESC
+		LIMIT 1
+	`, universeItemID, source, docType, rawItemID, before).Scan(&it.ID, &it.SourceType, &it.DocType, &published, &sentiment)
+	if err == sql.ErrNoRows {
+		return it, ErrNotFound
+	}
+	if err != nil {
+		return it, err
+	}
+	if published.Valid {
+		t := published.Time
+		it.Published = &t
+	}
+	it.Sentiment = sentiment
+	return it, nil
+}
@@ -0,0 +1,291 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Search result types.
+const (
+	SearchTypeRawItem  = "raw_item"
+	SearchTypeEvent    = "event"
+	SearchTypeArtifact = "artifact"
+	SearchTypeDecision = "decision"
+)
+
+// SearchTypes lists the searchable types.
+var SearchTypes = []string{SearchTypeRawItem, SearchTypeEvent, SearchTypeArtifact, SearchTypeDecision}
+
+// SearchFilter selects GET /search results; From is inclusive and To
+// exclusive. Empty Types searches every type.
+type SearchFilter struct {
+	Query  string
+	Types  []string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Cursor *SearchCursor
+}
+
+// SearchHit is one matching raw item, event, artifact or decision. Snippet
+// is an HTML-escaped excerpt of its text with the matches wrapped in <mark>
+// tags.
+type SearchHit struct {
+	Type       string
+	ID         string
+	RunID      *string
+	CaseID     *string
+	Title      string
+	Snippet    string
+	Rank       float64
+	OccurredAt time.Time
+}
+
+// SearchCursor is the keyset position after a search result.
+type SearchCursor struct {
+	OccurredAt time.Time
+	ID         string
+}
+
+func (c SearchCursor) String() string {
+	return c.OccurredAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+}
+
+// ParseSearchCursor parses SearchCursor.String output; "" is no cursor.
+func ParseSearchCursor(s string) (*SearchCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ts, id, ok := strings.Cut(s, "|")
+	if !ok || id == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchCursor{OccurredAt: t, ID: id}, nil
+}
+
+// searchSource maps a searchable table (alias t) to the columns of a hit.
+// text is the trigram-indexed expression of migration 0017.
+type searchSource struct {
+	typ    string
+	from   string
+	id     string
+	runID  string
+	caseID string
+	title  string
+	at     string
+	body   string
+	text   string
+}
+
+var searchSources = []searchSource{
+	{
+		typ: SearchTypeRawItem, from: "raw_items t",
+		id: "t.id::text", runID: "t.run_id::text", caseID: "NULL::text",
+		title: "t.title", at: "COALESCE(t.published_at, t.fetched_at)",
+		body: "left(t.raw_text, 200000)", text: "t.title || ' ' || left(t.raw_text, 200000)",
+	},
+	{
+		typ: SearchTypeEvent, from: "events t",
+		id: "t.event_id", runID: "t.run_id::text", caseID: "NULL::text",
+		title: "t.title", at: "t.observed_at",
+		body: `COALESCE((SELECT string_agg(v #>> '{}', ' ')
+		                  FROM jsonb_path_query(t.facts_json, 'strict $.** ? (@.type() == "string")') v), '')`,
+		text: "t.title || ' ' || t.facts_json::text",
+	},
+	{
+		typ: SearchTypeArtifact, from: "phase_artifacts t",
+		id: "t.id::text", runID: "NULL::text", caseID: "t.case_id::text",
+		title: "'phase ' || t.phase || ' ' || t.artifact_type", at: "t.created_at",
+		body: "COALESCE(t.content_md, '')", text: "COALESCE(t.content_md, '')",
+	},
+	{
+		typ: SearchTypeDecision, from: "decisions t",
+		id: "t.id::text", runID: "NULL::text", caseID: "t.case_id::text",
+		title: "t.final_label || ' (' || t.overall_score || ')'", at: "t.created_at",
+		body: "t.decision_md", text: "t.decision_md",
+	},
+}
+
+// searchHeadline are the ts_headline options of full-text snippets. Matches
+// are delimited by control characters, which are removed from the text
+// first, and turned into <mark> tags once the snippet is escaped.
+const searchHeadline = "StartSel=\"\x02\", StopSel=\"\x03\", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=\" ... \""
+
+var headlineMarks = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
+// trigramSnippetRunes is the length of trigram snippets, which start a
+// little before the first match.
+const trigramSnippetRunes = 240
+
+// Search finds the raw items, events, artifacts and decisions matching
+// f.Query, most recent first. English queries use full-text search
+// (websearch syntax: quoted phrases, OR, -exclusions) with stemming; queries
+// with Japanese, Chinese or Korean text, which the English parser does not
+// segment, match every whitespace-separated term as a substring through the
+// trigram indexes.
+func (r *Repository) Search(ctx context.Context, f SearchFilter) ([]SearchHit, *SearchCursor, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	types := map[string]bool{}
+	for _, t := range f.Types {
+		types[t] = true
+	}
+	trigram := isUnsegmented(f.Query)
+	terms := strings.Fields(f.Query)
+
+	// match and rank are formats of a source's trigram text (%[1]s) and
+	// title (%[2]s).
+	args := []any{f.Query}
+	var match, rank, snippet string
+	if trigram {
+		args = append(args, terms[0])
+		snippet = "substr(hit.body, greatest(strpos(lower(hit.body), lower($2)) - 60, 1), " + itoa(trigramSnippetRunes) + ")"
+		var conds []string
+		for _, t := range terms {
+			args = append(args, "%"+escapeLike(t)+"%")
+			conds = append(conds, "(%[1]s) ILIKE $"+itoa(len(args)))
+		}
+		match = strings.Join(conds, " AND ")
+		rank = "word_similarity($1, %[2]s)"
+	} else {
+		match = "t.search_tsv @@ websearch_to_tsquery('english', $1)"
+		rank = "ts_rank(t.search_tsv, websearch_to_tsquery('english', $1))"
+		args = append(args, searchHeadline)
+		snippet = "ts_headline('english', translate(hit.body, chr(2) || chr(3), ''), websearch_to_tsquery('english', $1), $2::text)"
+	}
+	var branches []string
+	for _, s := range searchSources {
+		if len(types) > 0 && !types[s.typ] {
+			continue
+		}
+		branches = append(branches, `
+			SELECT '`+s.typ+`' AS type, `+s.id+` AS id, `+s.runID+` AS run_id, `+s.caseID+` AS case_id,
+			       `+s.title+` AS title, `+s.at+` AS at, `+fmt.Sprintf(rank, s.text, s.title)+` AS rank, `+s.body+` AS body
+			FROM `+s.from+`
+			WHERE `+fmt.Sprintf(match, s.text, s.title))
+	}
+	if len(branches) == 0 {
+		return nil, nil, nil
+	}
+
+	where := "WHERE 1=1"
+	if f.From != nil {
+		args = append(args, *f.From)
+		where += " AND u.at >= $" + itoa(len(args))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		where += " AND u.at < $" + itoa(len(args))
+	}
+	if f.Cursor != nil {
+		args = append(args, f.Cursor.OccurredAt, f.Cursor.ID)
+		where += " AND (u.at, u.id) < ($" + itoa(len(args)-1) + ", $" + itoa(len(args)) + ")"
+	}
+	args = append(args, limit)
+	// Snippets are only built for the page.
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT hit.type, hit.id, hit.run_id, hit.case_id, hit.title, hit.at, hit.rank, `+snippet+`
+		FROM (
+			SELECT * FROM (`+strings.Join(branches, "\n\t\t\tUNION ALL")+`
+			) u
+			`+where+`
+			ORDER BY u.at DESC, u.id DESC
+			LIMIT $`+itoa(len(args))+`
+		) hit
+		ORDER BY hit.at DESC, hit.id DESC
+	`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var h SearchHit
+		var runID, caseID sql.NullString
+		if err := rows.Scan(&h.Type, &h.ID, &runID, &caseID, &h.Title, &h.OccurredAt, &h.Rank, &h.Snippet); err != nil {
+			return nil, nil, err
+		}
+		if runID.Valid {
+			v := runID.String
+			h.RunID = &v
+		}
+		if caseID.Valid {
+			v := caseID.String
+			h.CaseID = &v
+		}
+		if trigram {
+			h.Snippet = markTerms(h.Snippet, terms)
+		} else {
+			h.Snippet = markHeadline(h.Snippet)
+		}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(hits) < limit {
+		return hits, nil, nil
+	}
+	last := hits[len(hits)-1]
+	return hits, &SearchCursor{OccurredAt: last.OccurredAt, ID: last.ID}, nil
+}
+
+// isUnsegmented reports whether s contains Han, Hiragana, Katakana or Hangul
+// text, which full-text search cannot split into words.
+func isUnsegmented(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards of s.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// markHeadline HTML-escapes a ts_headline snippet and turns its match
+// delimiters into <mark> tags.
+func markHeadline(s string) string {
+	return headlineMarks.Replace(html.EscapeString(s))
+}
+
+// markTerms HTML-escapes s and wraps the case-insensitive occurrences of
+// terms in it in <mark> tags.
+func markTerms(s string, terms []string) string {
+	if len(terms) == 0 {
+		return html.EscapeString(s)
+	}
+	quoted := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(t))
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(s, -1) {
+		b.WriteString(html.EscapeString(s[last:m[0]]))
+		b.WriteString("<mark>" + html.EscapeString(s[m[0]:m[1]]) + "</mark>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(s[last:]))
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package queries
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	for in, want := range map[string]string{
+		"トヨタ":      "トヨタ",
+		"100%":     `100\%`,
+		"a_b":      `a\_b`,
+		`C:\path`:  `C:\\path`,
+		`50%_\off`: `50\%\_\\off`,
+	} {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMarkTerms(t *testing.T) {
+	cases := []struct {
+		s     string
+		terms []string
+		want  string
+	}{
+		{"トヨタ自動車の決算", []string{"トヨタ", "決算"}, "<mark>トヨタ</mark>自動車の<mark>決算</mark>"},
+		{"Sony ソニー sony", []string{"SONY"}, "<mark>Sony</mark> ソニー <mark>sony</mark>"},
+		{"a.b axb", []string{"a.b"}, "<mark>a.b</mark> axb"},
+		{`<script>alert("決算")</script>`, []string{"決算"}, "&lt;script&gt;alert(&#34;<mark>決算</mark>&#34;)&lt;/script&gt;"},
+		{"<b>&</b>", []string{"<b>"}, "<mark>&lt;b&gt;</mark>&amp;&lt;/b&gt;"},
+		{"<i>", nil, "&lt;i&gt;"},
+	}
+	for _, tc := range cases {
+		if got := markTerms(tc.s, tc.terms); got != tc.want {
+			t.Errorf("markTerms(%q, %q) = %q, want %q", tc.s, tc.terms, got, tc.want)
+		}
+	}
+}
+
+func TestMarkHeadline(t *testing.T) {
+	got := markHeadline("<img src=x onerror=alert(1)> \x02lithium\x03 prices ... \x02Lithium\x03 & cobalt")
+	want := "&lt;img src=x onerror=alert(1)&gt; <mark>lithium</mark> prices ... <mark>Lithium</mark> &amp; cobalt"
+	if got != want {
+		t.Errorf("markHeadline = %q, want %q", got, want)
+	}
+}
+
+func TestIsUnsegmented(t *testing.T) {
+	for in, want := range map[string]bool{
+		"lithium price":  false,
+		"":               false,
+		"トヨタ":            true,
+		"ひらがな":           true,
+		"決算 earnings":    true,
+		"삼성전자":           true,
+		"ｶﾀｶﾅ":           true,
+		"Ünïcödé résumé": false,
+	} {
+		if got := isUnsegmented(in); got != want {
+			t.Errorf("isUnsegmented(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
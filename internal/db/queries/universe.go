@@ -86,6 +86,35 @@ func (r *Repository) ListUniverseItems(ctx context.Context, f UniverseFilter) ([
 	return items, lastCreated, rows.Err()
 }
 
+// ListActiveUniverseItems returns every active universe item, highest
+// priority first.
+func (r *Repository) ListActiveUniverseItems(ctx context.Context) ([]models.UniverseItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, entity_type, entity_id, name, keywords, priority, is_active, created_at, updated_at
+		FROM universe_items
+		WHERE is_active = true
+		ORDER BY priority DESC, entity_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.UniverseItem
+	for rows.Next() {
+		var item models.UniverseItem
+		var keywords sql.NullString
+		if err := rows.Scan(&item.ID, &item.EntityType, &item.EntityID, &item.Name, &keywords, &item.Priority, &item.IsActive, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if keywords.Valid {
+			item.Keywords = json.RawMessage(keywords.String)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
 func (r *Repository) UpdateUniverseItem(ctx context.Context, id string, u UniverseUpdate) error {
 	set := "SET updated_at = now()"
 	args := []any{}
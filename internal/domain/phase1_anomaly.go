@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	AnomalyDimensionEntity   = "entity"
+	AnomalyDimensionSource   = "source"
+	AnomalyDimensionCategory = "category"
+)
+
+const DefaultAnomalyRulesVersion = "anomaly-rules/v1"
+
+// ActivityCounts is one run's activity: documents per entity and per source,
+// and events per events.category. It is stored in the anomaly summary so
+// later runs can use it as their baseline.
+type ActivityCounts struct {
+	Entity   map[string]int `json:"entity"`
+	Source   map[string]int `json:"source"`
+	Category map[string]int `json:"category"`
+}
+
+func NewActivityCounts() ActivityCounts {
+	return ActivityCounts{Entity: map[string]int{}, Source: map[string]int{}, Category: map[string]int{}}
+}
+
+// AnomalyRules flag a key when its count is at least MinCount and its z-score
+// against the last BaselineRuns runs reaches ZThreshold. The baseline standard
+// deviation is floored at MinStdDev so a flat history does not turn every
+// small change into an infinite z-score. Nothing is flagged until
+// MinBaselineRuns runs of history exist.
+type AnomalyRules struct {
+	Version         string  `json:"version"`
+	BaselineRuns    int     `json:"baseline_runs"`
+	MinBaselineRuns int     `json:"min_baseline_runs"`
+	ZThreshold      float64 `json:"z_threshold"`
+	MinCount        int     `json:"min_count"`
+	MinStdDev       float64 `json:"min_std_dev"`
+}
+
+func DefaultAnomalyRules() AnomalyRules {
+	return AnomalyRules{
+		Version:         DefaultAnomalyRulesVersion,
+		BaselineRuns:    20,
+		MinBaselineRuns: 3,
+		ZThreshold:      3,
+		MinCount:        3,
+		MinStdDev:       1,
+	}
+}
+
+// ParseAnomalyRules overlays a run config's anomaly_rules object on the
+// defaults, tagging versionless overrides like ParseTriggerRules.
+func ParseAnomalyRules(raw json.RawMessage) (AnomalyRules, error) {
+	rules := DefaultAnomalyRules()
+	if len(raw) == 0 || string(raw) == "null" {
+		return rules, nil
+	}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return rules, err
+	}
+	var v struct {
+		Version *string `json:"version"`
+	}
+	_ = json.Unmarshal(raw, &v)
+	if v.Version == nil || *v.Version == "" {
+		rules.Version = DefaultAnomalyRulesVersion + "+override"
+	}
+	return rules, nil
+}
+
+type Anomaly struct {
+	Dimension string  `json:"dimension"`
+	Key       string  `json:"key"`
+	Count     int     `json:"count"`
+	Mean      float64 `json:"mean"`
+	Median    float64 `json:"median"`
+	StdDev    float64 `json:"std_dev"`
+	ZScore    float64 `json:"z_score"`
+	Message   string  `json:"message"`
+}
+
+// AnomalySummary is the shape of anomaly_summaries.summary_json.
+type AnomalySummary struct {
+	RulesVersion string         `json:"rules_version"`
+	BaselineRuns int            `json:"baseline_runs"`
+	Activity     ActivityCounts `json:"activity"`
+	Anomalies    []Anomaly      `json:"anomalies"`
+}
+
+// DetectAnomalies compares current against history (most recent first). A key
+// missing from a historical run counts as zero for that run.
+func DetectAnomalies(current ActivityCounts, history []ActivityCounts, rules AnomalyRules) AnomalySummary {
+	if rules.BaselineRuns > 0 && len(history) > rules.BaselineRuns {
+		history = history[:rules.BaselineRuns]
+	}
+	out := AnomalySummary{
+		RulesVersion: rules.Version,
+		BaselineRuns: len(history),
+		Activity:     current,
+		Anomalies:    []Anomaly{},
+	}
+	if len(history) == 0 || len(history) < rules.MinBaselineRuns {
+		return out
+	}
+
+	dims := []struct {
+		name string
+		get  func(ActivityCounts) map[string]int
+	}{
+		{AnomalyDimensionEntity, func(a ActivityCounts) map[string]int { return a.Entity }},
+		{AnomalyDimensionSource, func(a ActivityCounts) map[string]int { return a.Source }},
+		{AnomalyDimensionCategory, func(a ActivityCounts) map[string]int { return a.Category }},
+	}
+	for _, dim := range dims {
+		counts := dim.get(current)
+		keys := make([]string, 0, len(counts))
+		for k := range counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			n := counts[k]
+			if n < rules.MinCount {
+				continue
+			}
+			series := make([]float64, len(history))
+			for i, h := range history {
+				series[i] = float64(dim.get(h)[k])
+			}
+			mean, sd := meanStdDev(series)
+			if sd < rules.MinStdDev {
+				sd = rules.MinStdDev
+			}
+			if sd <= 0 {
+				continue
+			}
+			z := (float64(n) - mean) / sd
+			if z < rules.ZThreshold {
+				continue
+			}
+			median := medianOf(series)
+			out.Anomalies = append(out.Anomalies, Anomaly{
+				Dimension: dim.name,
+				Key:       k,
+				Count:     n,
+				Mean:      roundScore(mean),
+				Median:    median,
+				StdDev:    roundScore(sd),
+				ZScore:    roundScore(z),
+				Message:   anomalyMessage(dim.name, k, n, median, len(history), z),
+			})
+		}
+	}
+	sort.SliceStable(out.Anomalies, func(i, j int) bool { return out.Anomalies[i].ZScore > out.Anomalies[j].ZScore })
+	return out
+}
+
+func anomalyMessage(dim, key string, n int, median float64, runs int, z float64) string {
+	switch dim {
+	case AnomalyDimensionEntity:
+		return fmt.Sprintf("%s had %d documents vs. a median of %g over the last %d runs (z=%.1f)", key, n, median, runs, z)
+	case AnomalyDimensionSource:
+		return fmt.Sprintf("source %s returned %d documents vs. a median of %g over the last %d runs (z=%.1f)", key, n, median, runs, z)
+	default:
+		return fmt.Sprintf("%d %s events vs. a median of %g over the last %d runs (z=%.1f)", n, key, median, runs, z)
+	}
+}
+
+func meanStdDev(xs []float64) (float64, float64) {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	v := 0.0
+	for _, x := range xs {
+		v += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(v / float64(len(xs)))
+}
+
+func medianOf(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	m := len(s) / 2
+	if len(s)%2 == 1 {
+		return s[m]
+	}
+	return (s[m-1] + s[m]) / 2
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func activity(entity map[string]int) ActivityCounts {
+	a := NewActivityCounts()
+	for k, v := range entity {
+		a.Entity[k] = v
+		a.Source["sec"] += v
+	}
+	return a
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	quiet := []ActivityCounts{
+		activity(map[string]int{"AAPL": 1, "MSFT": 2}),
+		activity(map[string]int{"AAPL": 1, "MSFT": 3}),
+		activity(map[string]int{"MSFT": 2}),
+		activity(map[string]int{"AAPL": 2, "MSFT": 2}),
+	}
+	cases := []struct {
+		name    string
+		current ActivityCounts
+		history []ActivityCounts
+		rules   AnomalyRules
+		want    []string // dimension:key
+	}{
+		{
+			name:    "no history",
+			current: activity(map[string]int{"AAPL": 9}),
+			rules:   DefaultAnomalyRules(),
+		},
+		{
+			name:    "too little history",
+			current: activity(map[string]int{"AAPL": 9}),
+			history: quiet[:2],
+			rules:   DefaultAnomalyRules(),
+		},
+		{
+			name:    "entity spike",
+			current: activity(map[string]int{"AAPL": 9, "MSFT": 2}),
+			history: quiet,
+			rules:   DefaultAnomalyRules(),
+			want:    []string{"entity:AAPL", "source:sec"},
+		},
+		{
+			name:    "new entity counts against zeros",
+			current: activity(map[string]int{"NVDA": 4}),
+			history: quiet,
+			rules:   DefaultAnomalyRules(),
+			want:    []string{"entity:NVDA"},
+		},
+		{
+			name:    "below min count",
+			current: activity(map[string]int{"NVDA": 2}),
+			history: quiet,
+			rules:   DefaultAnomalyRules(),
+		},
+		{
+			name: "category spike",
+			current: ActivityCounts{
+				Entity:   map[string]int{},
+				Source:   map[string]int{},
+				Category: map[string]int{"earnings": 6, "ir": 1},
+			},
+			history: []ActivityCounts{
+				{Category: map[string]int{"earnings": 1, "ir": 1}},
+				{Category: map[string]int{"ir": 2}},
+				{Category: map[string]int{"earnings": 1}},
+			},
+			rules: DefaultAnomalyRules(),
+			want:  []string{"category:earnings"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := DetectAnomalies(tc.current, tc.history, tc.rules)
+			got := map[string]Anomaly{}
+			for _, a := range s.Anomalies {
+				got[a.Dimension+":"+a.Key] = a
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("anomalies=%+v want %v", s.Anomalies, tc.want)
+			}
+			for _, w := range tc.want {
+				if _, ok := got[w]; !ok {
+					t.Fatalf("missing %s in %+v", w, s.Anomalies)
+				}
+			}
+		})
+	}
+
+	s := DetectAnomalies(activity(map[string]int{"AAPL": 9}), quiet, DefaultAnomalyRules())
+	a := s.Anomalies[0]
+	if a.Key != "AAPL" || a.Median != 1 || a.ZScore < 6 {
+		t.Fatalf("anomaly=%+v", a)
+	}
+	if !strings.HasPrefix(a.Message, "AAPL had 9 documents vs. a median of 1 over the last 4 runs") {
+		t.Fatalf("message=%q", a.Message)
+	}
+	sig := VolumeAnomalyDetector{}.Detect(SignalInput{Anomalies: s, AnomalyRules: DefaultAnomalyRules()})
+	if len(sig) != 1 || sig[0].EntityID != "AAPL" || sig[0].Severity != SeverityHigh || sig[0].Message != a.Message {
+		t.Fatalf("signals=%+v", sig)
+	}
+}
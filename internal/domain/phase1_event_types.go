@@ -5,6 +5,8 @@ import "strings"
 const (
 	Phase1EventRunFinalized       = "run.finalized"
 	Phase1EventDocFetched         = "doc.fetched"
+	Phase1EventDocFetchFailed     = "doc.fetch_failed"
+	Phase1EventFetchAttempt       = "fetch.attempt"
 	Phase1EventNoteAdded          = "note.added"
 	Phase1EventSignalDetected     = "signal.detected"
 	Phase1EventUniverseMemberAdded = "universe.member_added"
@@ -13,6 +15,8 @@ const (
 var AllowedPhase1EventTypes = map[string]struct{}{
 	Phase1EventRunFinalized:       {},
 	Phase1EventDocFetched:         {},
+	Phase1EventDocFetchFailed:     {},
+	Phase1EventFetchAttempt:       {},
 	Phase1EventNoteAdded:          {},
 	Phase1EventSignalDetected:     {},
 	Phase1EventUniverseMemberAdded: {},
@@ -4,6 +4,9 @@ type Phase1EventProjectionInput struct {
 	EventType string
 	Source    string
 	Seq       int
+	// DocumentClusters holds the near-duplicate cluster of each document
+	// of a doc.fetched event. nil means the event lists no documents.
+	DocumentClusters []string
 }
 
 type Phase1Projection struct {
@@ -11,10 +14,16 @@ type Phase1Projection struct {
 	CountsByType    map[string]int `json:"counts_by_type"`
 	CountsBySource  map[string]int `json:"counts_by_source"`
 	DocFetchedCount int            `json:"doc_fetched_count"`
+	// NearDuplicateCount is the number of fetched documents not counted in
+	// DocFetchedCount because a document of their cluster was.
+	NearDuplicateCount int         `json:"near_duplicate_count"`
 	FinalizedPresent bool          `json:"finalized_present"`
 	LastSeq         int            `json:"last_seq"`
 }
 
+// ProjectPhase1Events summarizes a run's events. doc_fetched_count counts
+// fetched documents once per near-duplicate cluster; a doc.fetched event
+// without documents counts as one.
 func ProjectPhase1Events(events []Phase1EventProjectionInput) Phase1Projection {
 	out := Phase1Projection{
 		TotalEvents:      len(events),
@@ -24,12 +33,23 @@ func ProjectPhase1Events(events []Phase1EventProjectionInput) Phase1Projection {
 		FinalizedPresent: false,
 		LastSeq:          0,
 	}
+	clusters := map[string]struct{}{}
 	for _, e := range events {
 		out.CountsByType[e.EventType]++
 		src := NormalizePhase1EventSource(e.Source)
 		out.CountsBySource[src]++
 		if e.EventType == Phase1EventDocFetched {
-			out.DocFetchedCount++
+			if e.DocumentClusters == nil {
+				out.DocFetchedCount++
+			}
+			for _, c := range e.DocumentClusters {
+				if _, ok := clusters[c]; ok {
+					out.NearDuplicateCount++
+					continue
+				}
+				clusters[c] = struct{}{}
+				out.DocFetchedCount++
+			}
 		}
 		if e.EventType == Phase1EventRunFinalized {
 			out.FinalizedPresent = true
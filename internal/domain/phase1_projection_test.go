@@ -41,6 +41,22 @@ func TestProjectPhase1Events(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "near-duplicates counted once",
+			input: []Phase1EventProjectionInput{
+				{EventType: Phase1EventDocFetched, Source: Phase1EventSourceOther, Seq: 1, DocumentClusters: []string{"c1", "c2"}},
+				{EventType: Phase1EventDocFetched, Source: Phase1EventSourceOther, Seq: 2, DocumentClusters: []string{"c1"}},
+				{EventType: Phase1EventDocFetched, Source: Phase1EventSourceOther, Seq: 3, DocumentClusters: []string{}},
+			},
+			checks: func(t *testing.T, p Phase1Projection) {
+				if p.DocFetchedCount != 2 {
+					t.Fatalf("doc_fetched_count=%d", p.DocFetchedCount)
+				}
+				if p.NearDuplicateCount != 1 {
+					t.Fatalf("near_duplicate_count=%d", p.NearDuplicateCount)
+				}
+			},
+		},
 		{
 			name: "source normalized",
 			input: []Phase1EventProjectionInput{
@@ -0,0 +1,571 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Built-in signal detectors.
+const (
+	SignalDetectorKeyword        = "keyword"
+	SignalDetectorFilingType     = "filing_type"
+	SignalDetectorGuidanceChange = "guidance_change"
+	SignalDetectorVolumeAnomaly  = "volume_anomaly"
+	SignalDetectorToneShift      = "tone_shift"
+)
+
+const DefaultSignalRulesVersion = "signal-rules/v2"
+
+// SignalRules choose the detectors of a run and tune the built-in ones.
+//
+// The keyword detector flags entities whose universe keywords appear in a
+// run's documents: low, or mid once KeywordMidDocs distinct documents match.
+// FilingTypes maps a filing, as "<source>:<doc type>" or
+// "sec:<form>:<8-K item>", to the severity it raises; an empty severity
+// disables a default entry. GuidanceMinChange and GuidanceHighChange are the
+// relative changes of a guidance figure that raise a low and a high signal,
+// with mid in between at twice GuidanceMinChange. ToneMinShift is the change
+// in polarity from an entity's previous filing of the same type that raises
+// a low signal (mid at twice, high at three times), counted only when both
+// filings have ToneMinWords positive or negative words.
+type SignalRules struct {
+	Version            string            `json:"version"`
+	Detectors          []string          `json:"detectors"`
+	KeywordMidDocs     int               `json:"keyword_mid_docs"`
+	FilingTypes        map[string]string `json:"filing_types"`
+	GuidanceMinChange  float64           `json:"guidance_min_change"`
+	GuidanceHighChange float64           `json:"guidance_high_change"`
+	ToneMinShift       float64           `json:"tone_min_shift"`
+	ToneMinWords       int               `json:"tone_min_words"`
+}
+
+func DefaultSignalRules() SignalRules {
+	return SignalRules{
+		Version: DefaultSignalRulesVersion,
+		Detectors: []string{
+			SignalDetectorKeyword,
+			SignalDetectorFilingType,
+			SignalDetectorGuidanceChange,
+			SignalDetectorVolumeAnomaly,
+			SignalDetectorToneShift,
+		},
+		KeywordMidDocs: 3,
+		FilingTypes: map[string]string{
+			"sec:8-K:2.02":                        SeverityMid,  // results of operations
+			"sec:8-K:1.03":                        SeverityHigh, // bankruptcy
+			"sec:8-K:2.01":                        SeverityMid,  // acquisition or disposition
+			"sec:8-K:3.01":                        SeverityHigh, // delisting
+			"sec:8-K:4.02":                        SeverityHigh, // non-reliance on financials
+			"sec:8-K:5.02":                        SeverityLow,  // officers and directors
+			"sec:NT 10-K":                         SeverityMid,
+			"sec:NT 10-Q":                         SeverityMid,
+			"sec:SC 13D":                          SeverityMid,
+			"edinet:extraordinary_report":         SeverityMid,
+			"edinet:large_shareholding_report":    SeverityLow,
+			"edinet:share_buyback_report":         SeverityLow,
+			"edinet:amended_extraordinary_report": SeverityLow,
+		},
+		GuidanceMinChange:  0.02,
+		GuidanceHighChange: 0.1,
+		ToneMinShift:       0.3,
+		ToneMinWords:       10,
+	}
+}
+
+// ParseSignalRules overlays a run config's signal_rules object on the
+// defaults, tagging versionless overrides like ParseTriggerRules. Entries of
+// filing_types are merged into the defaults; detectors replaces the default
+// list and must name built-in detectors.
+func ParseSignalRules(raw json.RawMessage) (SignalRules, error) {
+	rules := DefaultSignalRules()
+	if len(raw) == 0 || string(raw) == "null" {
+		return rules, nil
+	}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return rules, err
+	}
+	for _, name := range rules.Detectors {
+		if _, ok := builtinSignalDetectors[name]; !ok {
+			return rules, fmt.Errorf("unknown signal detector %q", name)
+		}
+	}
+	for k, sev := range rules.FilingTypes {
+		if sev == "" {
+			delete(rules.FilingTypes, k)
+		} else if _, ok := severityRank[sev]; !ok {
+			return rules, fmt.Errorf("filing type %s: unknown severity %q", k, sev)
+		}
+	}
+	var v struct {
+		Version *string `json:"version"`
+	}
+	_ = json.Unmarshal(raw, &v)
+	if v.Version == nil || *v.Version == "" {
+		rules.Version = DefaultSignalRulesVersion + "+override"
+	}
+	return rules, nil
+}
+
+var builtinSignalDetectors = map[string]func(SignalRules) SignalDetector{
+	SignalDetectorKeyword:    func(r SignalRules) SignalDetector { return KeywordDetector{MidDocs: r.KeywordMidDocs} },
+	SignalDetectorFilingType: func(r SignalRules) SignalDetector { return FilingTypeDetector{Types: r.FilingTypes} },
+	SignalDetectorGuidanceChange: func(r SignalRules) SignalDetector {
+		return GuidanceChangeDetector{MinChange: r.GuidanceMinChange, HighChange: r.GuidanceHighChange}
+	},
+	SignalDetectorVolumeAnomaly: func(SignalRules) SignalDetector { return VolumeAnomalyDetector{} },
+	SignalDetectorToneShift: func(r SignalRules) SignalDetector {
+		return ToneShiftDetector{MinShift: r.ToneMinShift, MinWords: r.ToneMinWords}
+	},
+}
+
+// Enabled returns the enabled built-in detectors, in the order listed.
+func (r SignalRules) Enabled() []SignalDetector {
+	var out []SignalDetector
+	for _, name := range r.Detectors {
+		if f, ok := builtinSignalDetectors[name]; ok {
+			out = append(out, f(r))
+		}
+	}
+	return out
+}
+
+// SignalDetector finds signals in a run's documents and events.
+type SignalDetector interface {
+	Name() string
+	Detect(in SignalInput) []DetectedSignal
+}
+
+// SignalInput is what detectors see of a run. PriorEvents are earlier events
+// of the entities with events in the run, for detectors that compare against
+// history. Anomalies is the run's anomaly summary, detected with
+// AnomalyRules.
+type SignalInput struct {
+	Documents    []SignalDocument
+	Events       []SignalEvent
+	PriorEvents  []SignalEvent
+	Anomalies    AnomalySummary
+	AnomalyRules AnomalyRules
+}
+
+// SignalDocument is a document of a doc.fetched event. EntityID is the
+// ticker or entity of UniverseItemID, the item the document was fetched for,
+// if any; Entities are the universe items it is linked to. Previous is the
+// entity's previous filing of the same type, for documents with a Tone.
+type SignalDocument struct {
+	RawItemID      string
+	DocID          string
+	ClusterID      string
+	Source         string
+	DocType        string
+	Title          string
+	Meta           map[string]string
+	PublishedAt    time.Time
+	UniverseItemID string
+	EntityID       string
+	Entities       []SignalEntity
+	Tone           *Tone
+	Previous       *SignalFiling
+}
+
+// Tone is the sentiment of a document's text, as stored by the sentiment
+// scorer. Scores of different lexicons are not comparable.
+type Tone struct {
+	Lexicon       string  `json:"lexicon"`
+	PositiveWords int     `json:"positive_words"`
+	NegativeWords int     `json:"negative_words"`
+	Polarity      float64 `json:"polarity"`
+	Uncertainty   float64 `json:"uncertainty"`
+	Litigiousness float64 `json:"litigiousness"`
+}
+
+// SignalFiling is an earlier filing a document is compared with.
+type SignalFiling struct {
+	RawItemID   string
+	PublishedAt time.Time
+	Tone        Tone
+}
+
+// SignalEntity is a document's link to a universe item, with the evidence
+// of entity resolution ("keyword:<kw>", "name:<name>", ...).
+type SignalEntity struct {
+	EntityID string
+	Evidence []string
+}
+
+// SignalEvent is a stored event. Guidance lists its guidance key facts.
+type SignalEvent struct {
+	EventID    string
+	EntityID   string
+	Category   string
+	ObservedAt time.Time
+	Guidance   []GuidanceFact
+}
+
+// GuidanceFact is one forecast figure of an earnings event.
+type GuidanceFact struct {
+	Metric    string  `json:"metric"`
+	Bound     string  `json:"bound,omitempty"`
+	PeriodEnd string  `json:"period_end"`
+	Value     float64 `json:"value"`
+	Unit      string  `json:"unit"`
+}
+
+// DetectedSignal is one detector hit, stored as a signal.detected event.
+type DetectedSignal struct {
+	Detector string         `json:"detector"`
+	Severity string         `json:"severity"`
+	EntityID string         `json:"entity_id"`
+	Message  string         `json:"message"`
+	Evidence SignalEvidence `json:"evidence"`
+}
+
+// SignalEvidence references the raw items, documents and events behind a
+// signal.
+type SignalEvidence struct {
+	RawItemIDs []string `json:"raw_item_ids,omitempty"`
+	DocIDs     []string `json:"doc_ids,omitempty"`
+	EventIDs   []string `json:"event_ids,omitempty"`
+}
+
+// DetectSignals runs the detectors over in, in order. The signals of each
+// detector are ordered by entity.
+func DetectSignals(in SignalInput, detectors []SignalDetector) []DetectedSignal {
+	var out []DetectedSignal
+	for _, d := range detectors {
+		sigs := d.Detect(in)
+		sort.SliceStable(sigs, func(i, j int) bool { return sigs[i].EntityID < sigs[j].EntityID })
+		out = append(out, sigs...)
+	}
+	return out
+}
+
+// KeywordDetector flags entities whose universe keywords appear in the run's
+// documents. Near-duplicates count as one document.
+type KeywordDetector struct {
+	MidDocs int
+}
+
+func (KeywordDetector) Name() string { return SignalDetectorKeyword }
+
+func (d KeywordDetector) Detect(in SignalInput) []DetectedSignal {
+	type hits struct {
+		clusters map[string]bool
+		keywords map[string]int
+		evidence SignalEvidence
+	}
+	byEntity := map[string]*hits{}
+	var order []string
+	for _, doc := range in.Documents {
+		for _, e := range doc.Entities {
+			var kws []string
+			for _, ev := range e.Evidence {
+				if kw, ok := strings.CutPrefix(ev, "keyword:"); ok {
+					kws = append(kws, kw)
+				}
+			}
+			key := entityKey(e.EntityID)
+			if len(kws) == 0 || key == "" {
+				continue
+			}
+			h := byEntity[key]
+			if h == nil {
+				h = &hits{clusters: map[string]bool{}, keywords: map[string]int{}}
+				byEntity[key] = h
+				order = append(order, key)
+			}
+			h.clusters[documentCluster(doc)] = true
+			for _, kw := range kws {
+				h.keywords[kw]++
+			}
+			h.evidence.add(doc)
+		}
+	}
+	var out []DetectedSignal
+	for _, key := range order {
+		h := byEntity[key]
+		sev := SeverityLow
+		if d.MidDocs > 0 && len(h.clusters) >= d.MidDocs {
+			sev = SeverityMid
+		}
+		out = append(out, DetectedSignal{
+			Detector: d.Name(),
+			Severity: sev,
+			EntityID: key,
+			Message:  fmt.Sprintf("%s keywords in %d document(s): %s", key, len(h.clusters), strings.Join(sortedKeys(h.keywords), ", ")),
+			Evidence: h.evidence,
+		})
+	}
+	return out
+}
+
+// FilingTypeDetector flags filings whose type is listed in Types, once per
+// filing, for the filer only. Entities a filing merely mentions are not
+// flagged.
+type FilingTypeDetector struct {
+	Types map[string]string
+}
+
+func (FilingTypeDetector) Name() string { return SignalDetectorFilingType }
+
+func (d FilingTypeDetector) Detect(in SignalInput) []DetectedSignal {
+	var out []DetectedSignal
+	for _, doc := range in.Documents {
+		key, sev := d.match(doc)
+		entity := entityKey(doc.EntityID)
+		if sev == "" || entity == "" {
+			continue
+		}
+		var ev SignalEvidence
+		ev.add(doc)
+		out = append(out, DetectedSignal{
+			Detector: d.Name(),
+			Severity: sev,
+			EntityID: entity,
+			Message:  fmt.Sprintf("%s filed %s: %s", entity, key, doc.Title),
+			Evidence: ev,
+		})
+	}
+	return out
+}
+
+// match returns the most severe entry of Types the document matches. 8-K
+// filings match per item, listed in meta "items".
+func (d FilingTypeDetector) match(doc SignalDocument) (string, string) {
+	docType := strings.TrimSpace(doc.DocType)
+	if doc.Source == "sec" {
+		docType = strings.ToUpper(docType)
+	}
+	keys := []string{doc.Source + ":" + docType}
+	if form := strings.TrimSuffix(docType, "/A"); doc.Source == "sec" && form == "8-K" {
+		for _, it := range strings.FieldsFunc(doc.Meta["items"], func(r rune) bool { return r == ',' || r == ' ' }) {
+			keys = append(keys, "sec:8-K:"+it)
+		}
+	}
+	var bestKey, best string
+	for _, k := range keys {
+		if sev, ok := d.Types[k]; ok && severityRank[sev] > severityRank[best] {
+			bestKey, best = k, sev
+		}
+	}
+	return bestKey, best
+}
+
+// GuidanceChangeDetector flags guidance figures of the run's earnings events
+// that differ from the latest earlier guidance for the same entity, metric,
+// bound and period.
+type GuidanceChangeDetector struct {
+	MinChange  float64
+	HighChange float64
+}
+
+func (GuidanceChangeDetector) Name() string { return SignalDetectorGuidanceChange }
+
+func (d GuidanceChangeDetector) Detect(in SignalInput) []DetectedSignal {
+	type prior struct {
+		eventID string
+		value   float64
+	}
+	factKey := func(entity string, f GuidanceFact) string {
+		return entity + "|" + f.Metric + "|" + f.Bound + "|" + f.PeriodEnd + "|" + f.Unit
+	}
+	all := append(append([]SignalEvent(nil), in.PriorEvents...), in.Events...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].ObservedAt.Before(all[j].ObservedAt) })
+	current := map[string]bool{}
+	for _, e := range in.Events {
+		current[e.EventID] = true
+	}
+
+	latest := map[string]prior{}
+	var out []DetectedSignal
+	for _, e := range all {
+		entity := entityKey(e.EntityID)
+		for _, f := range e.Guidance {
+			k := factKey(entity, f)
+			p, seen := latest[k]
+			latest[k] = prior{e.EventID, f.Value}
+			if !seen || !current[e.EventID] || p.eventID == e.EventID || p.value == 0 {
+				continue
+			}
+			change := (f.Value - p.value) / math.Abs(p.value)
+			sev := d.severity(math.Abs(change))
+			if sev == "" {
+				continue
+			}
+			metric := f.Metric
+			if f.Bound != "" {
+				metric += " (" + f.Bound + ")"
+			}
+			direction := "raised"
+			if change < 0 {
+				direction = "lowered"
+			}
+			out = append(out, DetectedSignal{
+				Detector: d.Name(),
+				Severity: sev,
+				EntityID: entity,
+				Message: fmt.Sprintf("%s %s %s guidance for %s by %.1f%% (%g to %g)",
+					entity, direction, metric, f.PeriodEnd, math.Abs(change)*100, p.value, f.Value),
+				Evidence: SignalEvidence{EventIDs: []string{p.eventID, e.EventID}},
+			})
+		}
+	}
+	return out
+}
+
+func (d GuidanceChangeDetector) severity(change float64) string {
+	switch {
+	case d.MinChange <= 0 || change < d.MinChange:
+		return ""
+	case d.HighChange > 0 && change >= d.HighChange:
+		return SeverityHigh
+	case change >= 2*d.MinChange:
+		return SeverityMid
+	default:
+		return SeverityLow
+	}
+}
+
+// VolumeAnomalyDetector turns the entity anomalies of the run's anomaly
+// summary into signals: high when the z-score is at least twice the
+// threshold, mid otherwise.
+type VolumeAnomalyDetector struct{}
+
+func (VolumeAnomalyDetector) Name() string { return SignalDetectorVolumeAnomaly }
+
+func (d VolumeAnomalyDetector) Detect(in SignalInput) []DetectedSignal {
+	var out []DetectedSignal
+	for _, a := range in.Anomalies.Anomalies {
+		if a.Dimension != AnomalyDimensionEntity {
+			continue
+		}
+		sev := SeverityMid
+		if a.ZScore >= 2*in.AnomalyRules.ZThreshold {
+			sev = SeverityHigh
+		}
+		var ev SignalEvidence
+		for _, doc := range in.Documents {
+			for _, entity := range documentEntities(doc) {
+				if entity == entityKey(a.Key) {
+					ev.add(doc)
+					break
+				}
+			}
+		}
+		out = append(out, DetectedSignal{
+			Detector: d.Name(),
+			Severity: sev,
+			EntityID: entityKey(a.Key),
+			Message:  a.Message,
+			Evidence: ev,
+		})
+	}
+	return out
+}
+
+// ToneShiftDetector flags filings whose polarity moved by at least MinShift
+// from the entity's previous filing of the same type. Near-duplicates are
+// flagged once.
+type ToneShiftDetector struct {
+	MinShift float64
+	MinWords int
+}
+
+func (ToneShiftDetector) Name() string { return SignalDetectorToneShift }
+
+func (d ToneShiftDetector) Detect(in SignalInput) []DetectedSignal {
+	var out []DetectedSignal
+	seen := map[string]bool{}
+	for _, doc := range in.Documents {
+		cur, prev := doc.Tone, doc.Previous
+		entity := entityKey(doc.EntityID)
+		if cur == nil || prev == nil || entity == "" || cur.Lexicon != prev.Tone.Lexicon ||
+			!d.enoughWords(*cur) || !d.enoughWords(prev.Tone) {
+			continue
+		}
+		shift := cur.Polarity - prev.Tone.Polarity
+		sev := d.severity(math.Abs(shift))
+		if sev == "" || seen[documentCluster(doc)+"|"+entity] {
+			continue
+		}
+		seen[documentCluster(doc)+"|"+entity] = true
+		direction := "more positive"
+		if shift < 0 {
+			direction = "more negative"
+		}
+		out = append(out, DetectedSignal{
+			Detector: d.Name(),
+			Severity: sev,
+			EntityID: entity,
+			Message: fmt.Sprintf("%s %s is %s than the filing of %s (polarity %.2f to %.2f, uncertainty %.3f to %.3f, litigiousness %.3f to %.3f)",
+				entity, doc.DocType, direction, prev.PublishedAt.Format("2006-01-02"), prev.Tone.Polarity, cur.Polarity,
+				prev.Tone.Uncertainty, cur.Uncertainty, prev.Tone.Litigiousness, cur.Litigiousness),
+			Evidence: SignalEvidence{RawItemIDs: []string{prev.RawItemID, doc.RawItemID}, DocIDs: []string{doc.DocID}},
+		})
+	}
+	return out
+}
+
+func (d ToneShiftDetector) enoughWords(t Tone) bool {
+	return t.PositiveWords+t.NegativeWords >= d.MinWords
+}
+
+func (d ToneShiftDetector) severity(shift float64) string {
+	switch {
+	case d.MinShift <= 0 || shift < d.MinShift:
+		return ""
+	case shift >= 3*d.MinShift:
+		return SeverityHigh
+	case shift >= 2*d.MinShift:
+		return SeverityMid
+	default:
+		return SeverityLow
+	}
+}
+
+// documentEntities returns the keys of the entity a document was fetched for
+// and of the universe items it is linked to.
+func documentEntities(doc SignalDocument) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, id := range append([]string{doc.EntityID}, entityIDs(doc.Entities)...) {
+		if k := entityKey(id); k != "" && !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func entityIDs(es []SignalEntity) []string {
+	out := make([]string, len(es))
+	for i, e := range es {
+		out[i] = e.EntityID
+	}
+	return out
+}
+
+// documentCluster identifies a document's near-duplicate cluster, or the
+// document itself.
+func documentCluster(doc SignalDocument) string {
+	switch {
+	case doc.ClusterID != "":
+		return doc.ClusterID
+	case doc.RawItemID != "":
+		return doc.RawItemID
+	default:
+		return doc.Source + ":" + doc.DocID
+	}
+}
+
+func (e *SignalEvidence) add(doc SignalDocument) {
+	if doc.RawItemID != "" {
+		e.RawItemIDs = append(e.RawItemIDs, doc.RawItemID)
+	}
+	if doc.DocID != "" {
+		e.DocIDs = append(e.DocIDs, doc.DocID)
+	}
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSignalRules(t *testing.T) {
+	r, err := ParseSignalRules([]byte(`{"detectors":["filing_type"],"filing_types":{"sec:8-K:5.02":"","sec:8-K:7.01":"low"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != DefaultSignalRulesVersion+"+override" {
+		t.Fatalf("version=%q", r.Version)
+	}
+	if d := r.Enabled(); len(d) != 1 || d[0].Name() != SignalDetectorFilingType {
+		t.Fatalf("detectors=%v", r.Detectors)
+	}
+	if _, ok := r.FilingTypes["sec:8-K:5.02"]; ok || r.FilingTypes["sec:8-K:7.01"] != SeverityLow || r.FilingTypes["sec:8-K:2.02"] != SeverityMid {
+		t.Fatalf("filing_types=%v", r.FilingTypes)
+	}
+	if _, err := ParseSignalRules([]byte(`{"detectors":["sentiment"]}`)); err == nil {
+		t.Fatal("unknown detector accepted")
+	}
+	if _, err := ParseSignalRules([]byte(`{"filing_types":{"sec:8-K":"urgent"}}`)); err == nil {
+		t.Fatal("unknown severity accepted")
+	}
+}
+
+func TestDetectSignals(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	guidance := func(v float64) []GuidanceFact {
+		return []GuidanceFact{{Metric: "revenue", PeriodEnd: "2025-03-31", Value: v, Unit: "JPY"}}
+	}
+	ai := []SignalEntity{{EntityID: "generative-ai", Evidence: []string{"keyword:生成ai", "keyword:llm"}}}
+	in := SignalInput{
+		Documents: []SignalDocument{
+			{RawItemID: "r1", DocID: "d1", ClusterID: "c1", Source: "sec", DocType: "8-K", Meta: map[string]string{"items": "2.02,9.01"}, Title: "Results", EntityID: "aapl"},
+			{RawItemID: "r2", DocID: "d2", ClusterID: "c2", Source: "edinet", DocType: "extraordinary_report", Title: "臨時報告書", EntityID: "7203", Entities: ai},
+			{RawItemID: "r3", DocID: "d3", ClusterID: "c2", Source: "ir", Title: "copy", Entities: ai},
+			{RawItemID: "r4", DocID: "d4", Source: "sec", DocType: "10-Q", EntityID: "MSFT"},
+			{RawItemID: "r5", DocID: "d5", Source: "edinet", DocType: "quarterly_report", EntityID: "6758",
+				Tone:     &Tone{Lexicon: "ja-fin/v1", PositiveWords: 4, NegativeWords: 12, Polarity: -0.5},
+				Previous: &SignalFiling{RawItemID: "r0", PublishedAt: day(1), Tone: Tone{Lexicon: "ja-fin/v1", PositiveWords: 12, NegativeWords: 4, Polarity: 0.5}}},
+			{RawItemID: "r6", DocID: "d6", Source: "sec", DocType: "10-Q", EntityID: "MSFT",
+				Tone:     &Tone{Lexicon: "custom-en/v1", PositiveWords: 2, NegativeWords: 6, Polarity: -0.5},
+				Previous: &SignalFiling{RawItemID: "r7", PublishedAt: day(1), Tone: Tone{Lexicon: "custom-en/v1", PositiveWords: 20, NegativeWords: 5, Polarity: 0.6}}},
+		},
+		Events: []SignalEvent{
+			{EventID: "e2", EntityID: "7203", Category: "earnings", ObservedAt: day(10), Guidance: guidance(47000)},
+			{EventID: "e3", EntityID: "6758", Category: "earnings", ObservedAt: day(10), Guidance: guidance(101)},
+		},
+		PriorEvents: []SignalEvent{
+			{EventID: "e1", EntityID: "7203", Category: "earnings", ObservedAt: day(1), Guidance: guidance(50000)},
+			{EventID: "e0", EntityID: "6758", Category: "earnings", ObservedAt: day(1), Guidance: guidance(100)},
+		},
+		Anomalies: AnomalySummary{Anomalies: []Anomaly{
+			{Dimension: AnomalyDimensionEntity, Key: "AAPL", ZScore: 4, Message: "AAPL had 9 documents"},
+			{Dimension: AnomalyDimensionSource, Key: "sec", ZScore: 9},
+		}},
+		AnomalyRules: DefaultAnomalyRules(),
+	}
+
+	var got []string
+	for _, s := range DetectSignals(in, DefaultSignalRules().Enabled()) {
+		got = append(got, s.Detector+":"+s.EntityID+":"+s.Severity)
+		switch s.Detector {
+		case SignalDetectorKeyword:
+			if strings.Join(s.Evidence.RawItemIDs, ",") != "r2,r3" || s.Message != "GENERATIVE-AI keywords in 1 document(s): llm, 生成ai" {
+				t.Errorf("keyword signal=%+v", s)
+			}
+		case SignalDetectorGuidanceChange:
+			if strings.Join(s.Evidence.EventIDs, ",") != "e1,e2" || !strings.Contains(s.Message, "lowered revenue guidance for 2025-03-31 by 6.0%") {
+				t.Errorf("guidance signal=%+v", s)
+			}
+		case SignalDetectorToneShift:
+			if strings.Join(s.Evidence.RawItemIDs, ",") != "r0,r5" || !strings.HasPrefix(s.Message, "6758 quarterly_report is more negative than the filing of 2024-05-01 (polarity 0.50 to -0.50") {
+				t.Errorf("tone signal=%+v", s)
+			}
+		case SignalDetectorVolumeAnomaly:
+			if strings.Join(s.Evidence.DocIDs, ",") != "d1" || s.Message != "AAPL had 9 documents" {
+				t.Errorf("anomaly signal=%+v", s)
+			}
+		}
+	}
+	want := "keyword:GENERATIVE-AI:low," +
+		"filing_type:7203:mid,filing_type:AAPL:mid," +
+		"guidance_change:7203:mid," +
+		"volume_anomaly:AAPL:mid," +
+		"tone_shift:6758:high"
+	if s := strings.Join(got, ","); s != want {
+		t.Fatalf("got  %s\nwant %s", s, want)
+	}
+}
+
+func TestFilingTypeDetectorFilerOnly(t *testing.T) {
+	doc := SignalDocument{RawItemID: "r1", Source: "edinet", DocType: "extraordinary_report", Title: "臨時報告書", EntityID: "7203",
+		Entities: []SignalEntity{{EntityID: "7203"}, {EntityID: "6758", Evidence: []string{"name:ソニー"}}}}
+	d := FilingTypeDetector{Types: DefaultSignalRules().FilingTypes}
+
+	got := d.Detect(SignalInput{Documents: []SignalDocument{doc}})
+	if len(got) != 1 || got[0].EntityID != "7203" {
+		t.Fatalf("signals=%+v", got)
+	}
+	doc.EntityID = ""
+	if got := d.Detect(SignalInput{Documents: []SignalDocument{doc}}); len(got) != 0 {
+		t.Fatalf("signals without a filer=%+v", got)
+	}
+}
@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TriggerTypeNone  = "none"
+	TriggerTypeLight = "light"
+	TriggerTypeHeavy = "heavy"
+)
+
+const (
+	SeverityLow  = "low"
+	SeverityMid  = "mid"
+	SeverityHigh = "high"
+)
+
+const DefaultTriggerRulesVersion = "trigger-rules/v1"
+
+// TriggerRules score each universe item from its Phase1 activity:
+// docs*DocWeight plus the severity weight of every signal, scaled by the
+// item's priority (50 = x1.0, 100 = x1.5, 0 = x0.5). Items scoring at least
+// LightScore become light candidates and at least HeavyScore heavy ones.
+// Any change to the fields must come with a new Version.
+type TriggerRules struct {
+	Version         string             `json:"version"`
+	DocWeight       float64            `json:"doc_weight"`
+	SeverityWeights map[string]float64 `json:"severity_weights"`
+	PriorityScaling bool               `json:"priority_scaling"`
+	LightScore      float64            `json:"light_score"`
+	HeavyScore      float64            `json:"heavy_score"`
+	MaxCandidates   int                `json:"max_candidates"`
+}
+
+func DefaultTriggerRules() TriggerRules {
+	return TriggerRules{
+		Version:   DefaultTriggerRulesVersion,
+		DocWeight: 1,
+		SeverityWeights: map[string]float64{
+			SeverityLow:  1,
+			SeverityMid:  3,
+			SeverityHigh: 6,
+		},
+		PriorityScaling: true,
+		LightScore:      3,
+		HeavyScore:      8,
+		MaxCandidates:   10,
+	}
+}
+
+// ParseTriggerRules overlays a run config's trigger_rules object on the
+// defaults. An override without its own version is tagged so decisions never
+// claim the default version for a modified ruleset.
+func ParseTriggerRules(raw json.RawMessage) (TriggerRules, error) {
+	rules := DefaultTriggerRules()
+	if len(raw) == 0 || string(raw) == "null" {
+		return rules, nil
+	}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return rules, err
+	}
+	var v struct {
+		Version *string `json:"version"`
+	}
+	_ = json.Unmarshal(raw, &v)
+	if v.Version == nil || *v.Version == "" {
+		rules.Version = DefaultTriggerRulesVersion + "+override"
+	}
+	return rules, nil
+}
+
+type TriggerUniverseItem struct {
+	ID         string
+	EntityType string
+	EntityID   string
+	Priority   int
+}
+
+type TriggerSignal struct {
+	EntityID string
+	Severity string
+	Detector string
+}
+
+type TriggerInput struct {
+	// DocCounts is keyed by entity ID (ticker or securities code).
+	DocCounts map[string]int
+	Signals   []TriggerSignal
+	Universe  []TriggerUniverseItem
+}
+
+type TriggerCandidate struct {
+	UniverseItemID string   `json:"universe_item_id"`
+	EntityType     string   `json:"entity_type"`
+	EntityID       string   `json:"entity_id"`
+	Priority       int      `json:"priority"`
+	DocCount       int      `json:"doc_count"`
+	SignalCount    int      `json:"signal_count"`
+	MaxSeverity    string   `json:"max_severity,omitempty"`
+	Score          float64  `json:"score"`
+	Level          string   `json:"level"`
+	Reasons        []string `json:"reasons"`
+}
+
+type TriggerDecision struct {
+	ShouldHandoff  bool               `json:"should_handoff"`
+	Type           string             `json:"type"`
+	Reasons        []string           `json:"reasons"`
+	Candidates     []TriggerCandidate `json:"candidates"`
+	RulesetVersion string             `json:"ruleset_version"`
+}
+
+var severityRank = map[string]int{SeverityLow: 1, SeverityMid: 2, SeverityHigh: 3}
+
+// EvaluateTrigger applies rules to a run's activity. Only universe items can
+// become candidates; the run's type is the highest candidate level.
+func EvaluateTrigger(in TriggerInput, rules TriggerRules) TriggerDecision {
+	out := TriggerDecision{
+		Type:           TriggerTypeNone,
+		Reasons:        []string{},
+		Candidates:     []TriggerCandidate{},
+		RulesetVersion: rules.Version,
+	}
+
+	docs := map[string]int{}
+	for k, v := range in.DocCounts {
+		docs[entityKey(k)] += v
+	}
+	signals := map[string][]TriggerSignal{}
+	for _, s := range in.Signals {
+		k := entityKey(s.EntityID)
+		signals[k] = append(signals[k], s)
+	}
+
+	for _, u := range in.Universe {
+		k := entityKey(u.EntityID)
+		c := TriggerCandidate{
+			UniverseItemID: u.ID,
+			EntityType:     u.EntityType,
+			EntityID:       u.EntityID,
+			Priority:       u.Priority,
+			DocCount:       docs[k],
+			SignalCount:    len(signals[k]),
+			Reasons:        []string{},
+		}
+		if c.DocCount == 0 && c.SignalCount == 0 {
+			continue
+		}
+		score := 0.0
+		if c.DocCount > 0 && rules.DocWeight != 0 {
+			add := float64(c.DocCount) * rules.DocWeight
+			score += add
+			c.Reasons = append(c.Reasons, fmt.Sprintf("%d documents (+%.1f)", c.DocCount, add))
+		}
+		bySeverity := map[string]int{}
+		for _, s := range signals[k] {
+			sev := strings.ToLower(strings.TrimSpace(s.Severity))
+			bySeverity[sev]++
+			if severityRank[sev] > severityRank[c.MaxSeverity] {
+				c.MaxSeverity = sev
+			}
+		}
+		for _, sev := range sortedKeys(bySeverity) {
+			n := bySeverity[sev]
+			add := float64(n) * rules.SeverityWeights[sev]
+			score += add
+			c.Reasons = append(c.Reasons, fmt.Sprintf("%d %s-severity signals (+%.1f)", n, sev, add))
+		}
+		if rules.PriorityScaling {
+			factor := 1 + float64(u.Priority-50)/100
+			if factor < 0 {
+				factor = 0
+			}
+			if factor != 1 {
+				c.Reasons = append(c.Reasons, fmt.Sprintf("priority %d (x%.2f)", u.Priority, factor))
+			}
+			score *= factor
+		}
+		c.Score = roundScore(score)
+		switch {
+		case c.Score >= rules.HeavyScore:
+			c.Level = TriggerTypeHeavy
+			c.Reasons = append(c.Reasons, fmt.Sprintf("score %.2f >= heavy threshold %.2f", c.Score, rules.HeavyScore))
+		case c.Score >= rules.LightScore:
+			c.Level = TriggerTypeLight
+			c.Reasons = append(c.Reasons, fmt.Sprintf("score %.2f >= light threshold %.2f", c.Score, rules.LightScore))
+		default:
+			continue
+		}
+		out.Candidates = append(out.Candidates, c)
+	}
+
+	sort.SliceStable(out.Candidates, func(i, j int) bool {
+		if out.Candidates[i].Score != out.Candidates[j].Score {
+			return out.Candidates[i].Score > out.Candidates[j].Score
+		}
+		return out.Candidates[i].EntityID < out.Candidates[j].EntityID
+	})
+	if rules.MaxCandidates > 0 && len(out.Candidates) > rules.MaxCandidates {
+		out.Reasons = append(out.Reasons, fmt.Sprintf("%d candidates truncated to %d", len(out.Candidates), rules.MaxCandidates))
+		out.Candidates = out.Candidates[:rules.MaxCandidates]
+	}
+
+	for _, c := range out.Candidates {
+		if c.Level == TriggerTypeHeavy {
+			out.Type = TriggerTypeHeavy
+		} else if out.Type == TriggerTypeNone {
+			out.Type = TriggerTypeLight
+		}
+		out.Reasons = append(out.Reasons, fmt.Sprintf("%s %s: %s", c.Level, c.EntityID, strings.Join(c.Reasons, ", ")))
+	}
+	out.ShouldHandoff = out.Type != TriggerTypeNone
+	if !out.ShouldHandoff {
+		out.Reasons = append(out.Reasons, "no universe item reached the light threshold")
+	}
+	return out
+}
+
+func entityKey(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if severityRank[keys[i]] != severityRank[keys[j]] {
+			return severityRank[keys[i]] > severityRank[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func roundScore(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEvaluateTrigger(t *testing.T) {
+	universe := []TriggerUniverseItem{
+		{ID: "u1", EntityType: "ticker", EntityID: "AAPL", Priority: 50},
+		{ID: "u2", EntityType: "ticker", EntityID: "7203", Priority: 100},
+		{ID: "u3", EntityType: "ticker", EntityID: "MSFT", Priority: 0},
+	}
+	cases := []struct {
+		name   string
+		input  TriggerInput
+		checks func(t *testing.T, d TriggerDecision)
+	}{
+		{
+			name:  "no activity",
+			input: TriggerInput{Universe: universe},
+			checks: func(t *testing.T, d TriggerDecision) {
+				if d.ShouldHandoff || d.Type != TriggerTypeNone {
+					t.Fatalf("type=%s should_handoff=%v", d.Type, d.ShouldHandoff)
+				}
+				if len(d.Candidates) != 0 || len(d.Reasons) == 0 {
+					t.Fatalf("candidates=%d reasons=%v", len(d.Candidates), d.Reasons)
+				}
+				if d.RulesetVersion != DefaultTriggerRulesVersion {
+					t.Fatalf("ruleset_version=%s", d.RulesetVersion)
+				}
+			},
+		},
+		{
+			name: "doc volume makes light candidate",
+			input: TriggerInput{
+				DocCounts: map[string]int{"aapl": 3, "UNKNOWN": 50},
+				Universe:  universe,
+			},
+			checks: func(t *testing.T, d TriggerDecision) {
+				if d.Type != TriggerTypeLight || len(d.Candidates) != 1 {
+					t.Fatalf("type=%s candidates=%+v", d.Type, d.Candidates)
+				}
+				if d.Candidates[0].UniverseItemID != "u1" || d.Candidates[0].Score != 3 {
+					t.Fatalf("candidate=%+v", d.Candidates[0])
+				}
+			},
+		},
+		{
+			name: "high signal on priority item goes heavy",
+			input: TriggerInput{
+				DocCounts: map[string]int{"7203": 1, "AAPL": 3},
+				Signals:   []TriggerSignal{{EntityID: "7203", Severity: "high"}},
+				Universe:  universe,
+			},
+			checks: func(t *testing.T, d TriggerDecision) {
+				if d.Type != TriggerTypeHeavy || !d.ShouldHandoff {
+					t.Fatalf("type=%s", d.Type)
+				}
+				if len(d.Candidates) != 2 || d.Candidates[0].EntityID != "7203" {
+					t.Fatalf("candidates=%+v", d.Candidates)
+				}
+				c := d.Candidates[0]
+				if c.Level != TriggerTypeHeavy || c.Score != 10.5 || c.MaxSeverity != SeverityHigh {
+					t.Fatalf("candidate=%+v", c)
+				}
+			},
+		},
+		{
+			name: "low priority damps score",
+			input: TriggerInput{
+				DocCounts: map[string]int{"MSFT": 5},
+				Universe:  universe,
+			},
+			checks: func(t *testing.T, d TriggerDecision) {
+				if d.Type != TriggerTypeNone {
+					t.Fatalf("type=%s candidates=%+v", d.Type, d.Candidates)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := EvaluateTrigger(tc.input, DefaultTriggerRules())
+			tc.checks(t, d)
+		})
+	}
+}
+
+func TestParseTriggerRules(t *testing.T) {
+	rules, err := ParseTriggerRules(json.RawMessage(`{"light_score":1,"severity_weights":{"high":10}}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rules.LightScore != 1 || rules.HeavyScore != 8 {
+		t.Fatalf("light=%v heavy=%v", rules.LightScore, rules.HeavyScore)
+	}
+	if rules.SeverityWeights[SeverityHigh] != 10 || rules.SeverityWeights[SeverityMid] != 3 {
+		t.Fatalf("severity_weights=%v", rules.SeverityWeights)
+	}
+	if rules.Version != DefaultTriggerRulesVersion+"+override" {
+		t.Fatalf("version=%s", rules.Version)
+	}
+
+	rules, err = ParseTriggerRules(json.RawMessage(`{"version":"desk-a/v3","heavy_score":20}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rules.Version != "desk-a/v3" || rules.HeavyScore != 20 {
+		t.Fatalf("rules=%+v", rules)
+	}
+	if DefaultTriggerRules().SeverityWeights[SeverityHigh] != 6 {
+		t.Fatalf("defaults mutated")
+	}
+}
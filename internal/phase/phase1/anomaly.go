@@ -0,0 +1,91 @@
+package phase1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/domain"
+)
+
+// runActivity counts the documents each doc.fetched event reported per ticker
+// (or universe entity) and per source, plus the run's events per category.
+func runActivity(events []models.Phase1RunEvent, categories map[string]int) domain.ActivityCounts {
+	a := domain.NewActivityCounts()
+	// Near-duplicates of a document count once per entity.
+	counted := map[string]bool{}
+	count := func(cluster, key string) {
+		if cluster != "" {
+			if counted[cluster+"|"+key] {
+				return
+			}
+			counted[cluster+"|"+key] = true
+		}
+		a.Entity[key]++
+	}
+	for _, e := range events {
+		if e.EventType != domain.Phase1EventDocFetched {
+			continue
+		}
+		var p docFetchedPayload
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			continue
+		}
+		if p.Source != "" {
+			a.Source[p.Source] += len(p.Documents)
+		}
+		for _, d := range p.Documents {
+			// Documents matched to a non-ticker universe item by keyword
+			// count for that item.
+			key := d.Ticker
+			if key == "" {
+				key = d.EntityID
+			}
+			if key = strings.ToUpper(strings.TrimSpace(key)); key != "" {
+				count(d.ClusterID, key)
+			}
+			// Every other universe item the document mentions counts too.
+			for _, l := range d.Entities {
+				if l.UniverseItemID == d.UniverseItemID {
+					continue
+				}
+				if k := strings.ToUpper(strings.TrimSpace(l.EntityID)); k != "" && k != key {
+					count(d.ClusterID, k)
+				}
+			}
+		}
+	}
+	for k, v := range categories {
+		a.Category[k] = v
+	}
+	return a
+}
+
+// detectAnomalies compares the run's activity with the summaries of earlier
+// successful runs of the same mode and sources. Summaries written before
+// anomaly detection existed have no activity and are skipped.
+func detectAnomalies(ctx context.Context, repo *queries.Repository, runID string, events []models.Phase1RunEvent, rules domain.AnomalyRules) (domain.AnomalySummary, error) {
+	categories, err := repo.CountEventsByCategory(ctx, runID)
+	if err != nil {
+		return domain.AnomalySummary{}, err
+	}
+	limit := rules.BaselineRuns
+	if limit <= 0 {
+		limit = domain.DefaultAnomalyRules().BaselineRuns
+	}
+	prior, err := repo.ListPriorAnomalySummaries(ctx, runID, limit)
+	if err != nil {
+		return domain.AnomalySummary{}, err
+	}
+	var history []domain.ActivityCounts
+	for _, raw := range prior {
+		var s domain.AnomalySummary
+		if err := json.Unmarshal(raw, &s); err != nil || s.Activity.Entity == nil {
+			continue
+		}
+		history = append(history, s.Activity)
+	}
+	return domain.DetectAnomalies(runActivity(events, categories), history, rules), nil
+}
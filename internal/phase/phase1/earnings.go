@@ -0,0 +1,70 @@
+package phase1
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/phase1/fetcher"
+	"investment_committee/internal/phase1/xbrl"
+)
+
+// earningsEvent builds the earnings event of a document with XBRL key facts.
+// It reports false when there are no facts or the document cannot be tied to
+// a ticker. The dedupe key is per filing, so refetching a document does not
+// add a second event.
+func earningsEvent(runID, src string, d fetcher.Document, rawItemID string, r *xbrl.Report, targets []fetcher.UniverseTarget) (models.Event, bool) {
+	if r == nil {
+		return models.Event{}, false
+	}
+	ticker := d.Ticker
+	if ticker == "" {
+		for _, t := range targets {
+			if t.ID == d.UniverseItemID && t.EntityType == "ticker" {
+				ticker = t.EntityID
+			}
+		}
+	}
+	if ticker == "" {
+		return models.Event{}, false
+	}
+
+	title := fmt.Sprintf("%s earnings guidance", ticker)
+	periodEnd := ""
+	if !r.PeriodEnd.IsZero() {
+		periodEnd = r.PeriodEnd.Format("2006-01-02")
+		title = fmt.Sprintf("%s earnings for the period ended %s", ticker, periodEnd)
+	}
+	facts, _ := json.Marshal(map[string]any{
+		"period_end": periodEnd,
+		"doc_type":   d.DocType,
+		"facts":      r.Facts,
+	})
+	sources, _ := json.Marshal([]map[string]any{{
+		"source":       src,
+		"doc_id":       d.DocID,
+		"url":          d.URL,
+		"title":        d.Title,
+		"published_at": d.PublishedAt.UTC().Format(time.RFC3339),
+		"raw_item_id":  rawItemID,
+	}})
+	tags := []string{"xbrl"}
+	if r.HasGuidance() {
+		tags = append(tags, "guidance")
+	}
+	tagsJSON, _ := json.Marshal(tags)
+	return models.Event{
+		RunID:      runID,
+		ObservedAt: d.PublishedAt.UTC(),
+		EntityType: "ticker",
+		EntityID:   ticker,
+		Category:   "earnings",
+		Title:      title,
+		FactsJSON:  facts,
+		Sources:    sources,
+		Confidence: r.Confidence,
+		DedupeKey:  "earnings:" + src + ":" + d.DocID,
+		TagsJSON:   tagsJSON,
+	}, true
+}
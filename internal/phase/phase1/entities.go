@@ -0,0 +1,49 @@
+package phase1
+
+import (
+	"investment_committee/internal/phase1/entity"
+	"investment_committee/internal/phase1/fetcher"
+)
+
+// fetchedEvidence is the evidence of a link to the universe item a document
+// was fetched for.
+const fetchedEvidence = "fetched"
+
+func newResolver(targets []fetcher.UniverseTarget) *entity.Resolver {
+	items := make([]entity.Item, 0, len(targets))
+	for _, t := range targets {
+		items = append(items, entity.Item{
+			ID:         t.ID,
+			EntityType: t.EntityType,
+			EntityID:   t.EntityID,
+			Name:       t.Name,
+			Keywords:   t.Keywords,
+		})
+	}
+	return entity.NewResolver(items)
+}
+
+// documentLinks returns the universe items a document is linked to: the item
+// it was fetched for, with score 1, then the items res finds in its title
+// and text, best first.
+func documentLinks(d fetcher.Document, text string, targets []fetcher.UniverseTarget, res *entity.Resolver) []entity.Link {
+	links := []entity.Link{}
+	if d.UniverseItemID != "" {
+		l := entity.Link{UniverseItemID: d.UniverseItemID, Score: 1, Evidence: []string{fetchedEvidence}}
+		for _, t := range targets {
+			if t.ID == d.UniverseItemID {
+				l.EntityType, l.EntityID = t.EntityType, t.EntityID
+				break
+			}
+		}
+		links = append(links, l)
+	}
+	for _, l := range res.Resolve(d.Title, text) {
+		if l.UniverseItemID == d.UniverseItemID {
+			links[0].Evidence = append(links[0].Evidence, l.Evidence...)
+			continue
+		}
+		links = append(links, l)
+	}
+	return links
+}
@@ -0,0 +1,306 @@
+package phase1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/domain"
+	"investment_committee/internal/phase1/classify"
+	"investment_committee/internal/phase1/fetcher"
+)
+
+const (
+	// unclassifiedConfidence is given to documents no classifier recognized.
+	unclassifiedConfidence = 0.3
+	// keywordEntityFactor discounts events whose entity was only inferred
+	// from universe keywords.
+	keywordEntityFactor = 0.8
+)
+
+// fetchedDocument is a document of a doc.fetched payload.
+type fetchedDocument struct {
+	DocID          string            `json:"doc_id"`
+	Title          string            `json:"title"`
+	URL            string            `json:"url"`
+	PublishedAt    time.Time         `json:"published_at"`
+	Ticker         string            `json:"ticker"`
+	DocType        string            `json:"doc_type"`
+	Meta           map[string]string `json:"meta"`
+	UniverseItemID string            `json:"universe_item_id"`
+	RawItemID      string            `json:"raw_item_id"`
+	EventIDs       []string          `json:"event_ids"`
+}
+
+// ExtractEvents turns the raw items of a run into events. Near-duplicate
+// items (the same release from an IR feed and an 8-K exhibit) count once:
+// each cluster is classified into a category by its most confident member
+// and stored, with the sources of all members and a confidence, once for
+// every universe entity its members are linked to. Clusters with an item
+// that already produced an XBRL earnings event, or that cannot be tied to an
+// entity, are skipped. The dedupe key combines category, entity and the
+// content hash of the cluster's canonical item, so a document fetched again
+// by a later run does not add an event. It returns the number of events
+// created.
+func ExtractEvents(ctx context.Context, repo *queries.Repository, runID string, classifier classify.Classifier) (int, error) {
+	runEvents, err := repo.ListAllPhase1RunEvents(ctx, runID)
+	if err != nil {
+		return 0, err
+	}
+	docs := map[string]fetchedDocument{}
+	for _, e := range runEvents {
+		if e.EventType != domain.Phase1EventDocFetched {
+			continue
+		}
+		var p struct {
+			Documents []fetchedDocument `json:"documents"`
+		}
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			continue
+		}
+		for _, d := range p.Documents {
+			if d.RawItemID != "" {
+				docs[d.RawItemID] = d
+			}
+		}
+	}
+	items, err := repo.ListAllRawItemsByRun(ctx, runID)
+	if err != nil {
+		return 0, err
+	}
+	universe, err := repo.ListActiveUniverseItems(ctx)
+	if err != nil {
+		return 0, err
+	}
+	targets := universeTargets(universe)
+
+	created := 0
+	for _, members := range clusterRawItems(items, docs) {
+		for _, ev := range classifyCluster(runID, members, targets, classifier) {
+			_, isNew, err := repo.CreateEvent(ctx, ev)
+			if err != nil {
+				return created, fmt.Errorf("store event for raw item %s: %w", members[0].item.ID, err)
+			}
+			if isNew {
+				created++
+			}
+		}
+	}
+	return created, nil
+}
+
+// clusterMember is a raw item of a run with its doc.fetched document.
+type clusterMember struct {
+	item models.RunRawItem
+	doc  fetchedDocument
+}
+
+// clusterRawItems groups the raw items of a run by near-duplicate cluster,
+// in the order of each cluster's first item. A cluster's canonical item
+// comes first when the run fetched it.
+func clusterRawItems(items []models.RunRawItem, docs map[string]fetchedDocument) [][]clusterMember {
+	var out [][]clusterMember
+	index := map[string]int{}
+	for _, it := range items {
+		key := it.ID
+		if it.ClusterID != nil {
+			key = *it.ClusterID
+		}
+		m := clusterMember{it, docs[it.ID]}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(out)
+			out = append(out, []clusterMember{m})
+			continue
+		}
+		if it.ID == key {
+			out[i] = append([]clusterMember{m}, out[i]...)
+		} else {
+			out[i] = append(out[i], m)
+		}
+	}
+	return out
+}
+
+// classifyCluster builds the events of a cluster of raw items, one per
+// entity, or none when the cluster is skipped. The first member stands for
+// the cluster.
+func classifyCluster(runID string, members []clusterMember, targets []fetcher.UniverseTarget, classifier classify.Classifier) []models.Event {
+	var entities []eventEntity
+	for _, m := range members {
+		if len(m.doc.EventIDs) > 0 {
+			return nil
+		}
+		entities = mergeEntities(entities, resolveEntities(m.item, m.doc, targets))
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+	r, ok := classify.Result{}, false
+	for _, m := range members {
+		mr, mok := classifier.Classify(classify.Document{
+			Source:   m.item.SourceType,
+			DocType:  m.doc.DocType,
+			Title:    m.item.Title,
+			Summary:  m.item.Summary,
+			Text:     m.item.RawText,
+			Language: m.item.Language,
+			Meta:     m.doc.Meta,
+		})
+		if mok && (!ok || mr.Confidence > r.Confidence) {
+			r, ok = mr, true
+		}
+	}
+	if !ok {
+		r = classify.Result{Category: classify.CategoryOther, Confidence: unclassifiedConfidence, Rule: "none"}
+	}
+
+	it, doc := members[0].item, members[0].doc
+	observed := it.FetchedAt
+	if it.Published != nil {
+		observed = *it.Published
+	}
+	facts, _ := json.Marshal(map[string]any{
+		"summary":    it.Summary,
+		"language":   it.Language,
+		"doc_type":   doc.DocType,
+		"rule":       r.Rule,
+		"duplicates": len(members) - 1,
+	})
+	sourceList := make([]map[string]any, 0, len(members))
+	for _, m := range members {
+		published := m.item.FetchedAt
+		if m.item.Published != nil {
+			published = *m.item.Published
+		}
+		sourceList = append(sourceList, map[string]any{
+			"source":       m.item.SourceType,
+			"doc_id":       m.doc.DocID,
+			"url":          m.item.URL,
+			"title":        m.item.Title,
+			"published_at": published.UTC().Format(time.RFC3339),
+			"raw_item_id":  m.item.ID,
+		})
+	}
+	sources, _ := json.Marshal(sourceList)
+	tags := r.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, _ := json.Marshal(tags)
+	hash := it.CanonicalHash
+	if hash == "" {
+		hash = it.Hash
+	}
+	// The tone is the first scored member's.
+	var impact json.RawMessage
+	for _, m := range members {
+		if impact = sentimentImpact(m.item.Sentiment); impact != nil {
+			break
+		}
+	}
+	events := make([]models.Event, 0, len(entities))
+	for _, e := range entities {
+		events = append(events, models.Event{
+			RunID:      runID,
+			ObservedAt: observed.UTC(),
+			EntityType: e.entityType,
+			EntityID:   e.entityID,
+			Category:   r.Category,
+			Title:      it.Title,
+			FactsJSON:  facts,
+			ImpactJSON: impact,
+			Sources:    sources,
+			Confidence: math.Round(r.Confidence*e.factor*100) / 100,
+			DedupeKey:  fmt.Sprintf("%s:%s:%s:%s", r.Category, e.entityType, e.entityID, hash),
+			TagsJSON:   tagsJSON,
+		})
+	}
+	return events
+}
+
+// sentimentImpact is the impact_json of an event about a raw item with the
+// given sentiment, or nil when the item was not scored.
+func sentimentImpact(sentiment json.RawMessage) json.RawMessage {
+	if len(sentiment) == 0 || string(sentiment) == "null" {
+		return nil
+	}
+	b, _ := json.Marshal(map[string]json.RawMessage{"sentiment": sentiment})
+	return b
+}
+
+// mergeEntities adds the entities of more to list, keeping the larger factor
+// of an entity in both.
+func mergeEntities(list, more []eventEntity) []eventEntity {
+	for _, e := range more {
+		found := false
+		for i, x := range list {
+			if x.entityType == e.entityType && x.entityID == e.entityID {
+				list[i].factor = max(x.factor, e.factor)
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// eventEntity is an entity an event is about. factor discounts the event's
+// confidence by how surely the raw item refers to the entity.
+type eventEntity struct {
+	entityType string
+	entityID   string
+	factor     float64
+}
+
+// resolveEntities ties a raw item to entities: the universe items it was
+// fetched for or mentions, discounted by the score of their link, else a
+// universe item matching its ticker or keywords, else its ticker outside the
+// universe.
+func resolveEntities(it models.RunRawItem, doc fetchedDocument, targets []fetcher.UniverseTarget) []eventEntity {
+	byID := map[string]fetcher.UniverseTarget{}
+	for _, t := range targets {
+		byID[t.ID] = t
+	}
+	scores := map[string]float64{}
+	for _, e := range it.Entities {
+		scores[e.UniverseItemID] = e.Score
+	}
+	var out []eventEntity
+	seen := map[string]bool{}
+	for _, id := range append([]string{doc.UniverseItemID}, it.UniverseItemIDs...) {
+		t, ok := byID[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		factor := 1.0
+		if s, ok := scores[id]; ok {
+			factor = s
+		}
+		out = append(out, eventEntity{t.EntityType, t.EntityID, factor})
+	}
+	if len(out) > 0 {
+		return out
+	}
+	d := fetcher.Document{Ticker: doc.Ticker, Title: it.Title, Summary: it.Summary}
+	t, matched := fetcher.MatchTarget(d, targets)
+	switch {
+	case matched && t.EntityType == "ticker" && strings.EqualFold(t.EntityID, doc.Ticker):
+		return []eventEntity{{t.EntityType, t.EntityID, 1}}
+	case doc.Ticker != "":
+		// A filer's own ticker beats keywords of other items.
+		return []eventEntity{{"ticker", doc.Ticker, 1}}
+	case matched:
+		return []eventEntity{{t.EntityType, t.EntityID, keywordEntityFactor}}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package phase1
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/phase1/classify"
+	"investment_committee/internal/phase1/fetcher"
+)
+
+func TestClassifyCluster(t *testing.T) {
+	published := time.Date(2024, 10, 30, 20, 5, 0, 0, time.UTC)
+	targets := []fetcher.UniverseTarget{
+		{ID: "u-aapl", EntityType: "ticker", EntityID: "AAPL", Priority: 80},
+		{ID: "u-ai", EntityType: "theme", EntityID: "generative-ai", Keywords: []string{"generative ai"}, Priority: 60},
+	}
+	item := func(id, source, title string, universe ...string) models.RunRawItem {
+		it := models.RunRawItem{
+			RawItem:         models.RawItem{ID: id, SourceType: source, Title: title, Published: &published, Hash: "h-" + id},
+			UniverseItemIDs: universe,
+		}
+		for _, u := range universe {
+			it.Entities = append(it.Entities, models.RawItemEntity{UniverseItemID: u, Score: 1})
+		}
+		return it
+	}
+	mentions := item("r7", "ir", "Apple to integrate generative AI models", "u-aapl", "u-ai")
+	mentions.Entities[1].Score = 0.6
+	cluster := func(it models.RunRawItem, canonical string) models.RunRawItem {
+		it.ClusterID = &canonical
+		return it
+	}
+	cases := []struct {
+		name string
+		it   models.RunRawItem
+		doc  fetchedDocument
+		dups []clusterMember
+		want string // category entity confidence of each event
+	}{
+		{"linked 8-K", item("r1", "sec", "Apple Inc. 8-K 2024-10-31", "u-aapl"),
+			fetchedDocument{DocID: "0000320193-24-000120", DocType: "8-K", Ticker: "AAPL", Meta: map[string]string{"items": "2.02,9.01"}},
+			nil, "earnings ticker:AAPL 0.9"},
+		{"ticker outside universe", item("r2", "sec", "Microsoft Corp 10-Q"),
+			fetchedDocument{DocType: "10-Q", Ticker: "MSFT"}, nil, "earnings ticker:MSFT 0.9"},
+		{"keyword theme", item("r3", "ir", "Partner unveils generative AI platform"),
+			fetchedDocument{DocType: "ir_news"}, nil, "technology theme:generative-ai 0.64"},
+		{"unclassified", item("r4", "ir", "Office relocation notice", "u-aapl"),
+			fetchedDocument{DocType: "ir_news"}, nil, "other ticker:AAPL 0.3"},
+		{"no entity", item("r5", "ir", "Quarterly dividend declared"), fetchedDocument{DocType: "ir_news"}, nil, ""},
+		{"xbrl event exists", item("r6", "sec", "Apple Inc. 10-K", "u-aapl"),
+			fetchedDocument{DocType: "10-K", Ticker: "AAPL", EventIDs: []string{"e1"}}, nil, ""},
+		{"resolved mentions", mentions, fetchedDocument{DocType: "ir_news"}, nil,
+			"technology ticker:AAPL 0.6; technology theme:generative-ai 0.36"},
+		// The IR copy of an 8-K exhibit counts once, classified by the
+		// filing's item and linked to the entities of both copies.
+		{"near-duplicates", cluster(item("r8", "ir", "Apple reports fourth quarter results"), "r8"),
+			fetchedDocument{DocType: "ir_news"},
+			[]clusterMember{{cluster(item("r9", "sec", "Apple Inc. 8-K 2024-10-31", "u-aapl"), "r8"),
+				fetchedDocument{DocType: "8-K", Ticker: "AAPL", Meta: map[string]string{"items": "2.02"}}}},
+			"earnings ticker:AAPL 0.9"},
+	}
+	for _, tc := range cases {
+		var got []string
+		members := append([]clusterMember{{tc.it, tc.doc}}, tc.dups...)
+		for _, ev := range classifyCluster("run-1", members, targets, classify.Default()) {
+			got = append(got, fmt.Sprintf("%s %s:%s %v", ev.Category, ev.EntityType, ev.EntityID, ev.Confidence))
+			if want := ev.Category + ":" + ev.EntityType + ":" + ev.EntityID + ":h-" + tc.it.ID; ev.DedupeKey != want {
+				t.Errorf("%s: dedupe_key=%q, want %q", tc.name, ev.DedupeKey, want)
+			}
+			if !ev.ObservedAt.Equal(published) {
+				t.Errorf("%s: observed_at=%s", tc.name, ev.ObservedAt)
+			}
+		}
+		if s := strings.Join(got, "; "); s != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, s, tc.want)
+		}
+	}
+
+	// The tone of a cluster is its first scored member's.
+	scored := item("r11", "sec", "Apple Inc. 8-K 2024-10-31", "u-aapl")
+	scored.Sentiment = []byte(`{"polarity":0.5}`)
+	members := []clusterMember{{item("r10", "ir", "Apple reports fourth quarter results", "u-aapl"), fetchedDocument{}}, {scored, fetchedDocument{}}}
+	for _, ev := range classifyCluster("run-1", members, targets, classify.Default()) {
+		if string(ev.ImpactJSON) != `{"sentiment":{"polarity":0.5}}` {
+			t.Errorf("impact_json=%s", ev.ImpactJSON)
+		}
+	}
+}
+
+func TestClusterRawItems(t *testing.T) {
+	canonical := "r1"
+	items := []models.RunRawItem{
+		{RawItem: models.RawItem{ID: "r2", ClusterID: &canonical}},
+		{RawItem: models.RawItem{ID: "r3"}},
+		{RawItem: models.RawItem{ID: "r1", ClusterID: &canonical}},
+		{RawItem: models.RawItem{ID: "r4", ClusterID: &canonical}},
+	}
+	var got []string
+	for _, members := range clusterRawItems(items, nil) {
+		var ids []string
+		for _, m := range members {
+			ids = append(ids, m.item.ID)
+		}
+		got = append(got, strings.Join(ids, ","))
+	}
+	if s := strings.Join(got, " "); s != "r1,r2,r4 r3" {
+		t.Errorf("clusters = %q", s)
+	}
+}
@@ -0,0 +1,173 @@
+package phase1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/phase1/classify"
+	"investment_committee/internal/phase1/fetcher"
+	"investment_committee/internal/phase1/sentiment"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultClaimLease   = 5 * time.Minute
+)
+
+// Executor picks up newly created Phase1 runs from the runs table, fetches
+// their sources and finalizes them. Enqueue only wakes the loop early; the
+// runs table is the queue, so runs created by another replica are also
+// picked up on the next poll.
+type Executor struct {
+	repo         *queries.Repository
+	fetchers     *fetcher.Registry
+	wake         chan struct{}
+	PollInterval time.Duration
+	// ClaimLease is how long a claim lasts unless renewed. The executor
+	// renews its claim every third of it until the run is fetched, and a
+	// run whose claim lapsed, because its executor crashed, is claimed
+	// again.
+	ClaimLease time.Duration
+	// Classifier turns the fetched raw items into events; it defaults to
+	// classify.Default().
+	Classifier classify.Classifier
+	// Sentiment scores the tone of fetched documents; it defaults to
+	// sentiment.Default().
+	Sentiment *sentiment.Scorer
+}
+
+func NewExecutor(repo *queries.Repository, fetchers *fetcher.Registry) *Executor {
+	return &Executor{
+		repo:         repo,
+		fetchers:     fetchers,
+		wake:         make(chan struct{}, 1),
+		PollInterval: defaultPollInterval,
+		ClaimLease:   defaultClaimLease,
+		Classifier:   classify.Default(),
+		Sentiment:    sentiment.Default(),
+	}
+}
+
+func (e *Executor) Enqueue(runID string) {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes runs until ctx is cancelled.
+func (e *Executor) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.PollInterval)
+	defer ticker.Stop()
+	for {
+		e.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Executor) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		run, err := e.repo.ClaimNextRun(ctx, e.ClaimLease)
+		if errors.Is(err, queries.ErrNotFound) {
+			return
+		}
+		if err != nil {
+			log.Printf("phase1 executor: claim: %v", err)
+			return
+		}
+		if err := e.Execute(ctx, run); err != nil {
+			log.Printf("phase1 executor: run %s: %v", run.ID, err)
+		}
+	}
+}
+
+// Execute fetches all sources of a claimed run, extracts events from the
+// fetched documents and, unless auto_finalize is false, finalizes it. Source
+// failures are recorded as events and turn the run into failed at finalize.
+// A run that cannot be fetched at all is failed and marked fetched, so that
+// it can still be finalized.
+func (e *Executor) Execute(ctx context.Context, run models.Run) error {
+	stop := e.renewClaim(ctx, run.ID)
+	defer stop()
+	var cfg fetcher.Phase1FetchConfig
+	var opts RunOptions
+	if len(run.ConfigJSON) > 0 {
+		err := json.Unmarshal(run.ConfigJSON, &cfg)
+		if err == nil {
+			err = json.Unmarshal(run.ConfigJSON, &opts)
+		}
+		if err != nil {
+			return e.fail(ctx, run.ID, "invalid config: "+err.Error())
+		}
+	}
+	cfg, err := selectUniverse(ctx, e.repo, run.ID, cfg)
+	if err != nil {
+		return e.fail(ctx, run.ID, "universe: "+err.Error())
+	}
+	if err := FetchDocuments(ctx, e.repo, e.fetchers, run.ID, cfg, e.Sentiment); err != nil {
+		log.Printf("phase1 executor: run %s: fetch: %v", run.ID, err)
+	}
+	if _, err := ExtractEvents(ctx, e.repo, run.ID, e.Classifier); err != nil {
+		log.Printf("phase1 executor: run %s: extract events: %v", run.ID, err)
+	}
+	if err := e.repo.MarkRunFetched(ctx, run.ID); err != nil {
+		return err
+	}
+	stop()
+	if !opts.autoFinalize() {
+		return nil
+	}
+	// A failed finalize leaves the run open, to be finalized again with
+	// POST /phase1/runs/{id}/finalize.
+	if err := FinalizeRun(ctx, e.repo, run.ID); err != nil {
+		return fmt.Errorf("finalize: %w", err)
+	}
+	return nil
+}
+
+func (e *Executor) fail(ctx context.Context, runID, msg string) error {
+	if err := e.repo.UpdateRunStatus(ctx, runID, "failed", &msg); err != nil {
+		return err
+	}
+	return e.repo.MarkRunFetched(ctx, runID)
+}
+
+// renewClaim renews the run's claim until the returned stop is called.
+func (e *Executor) renewClaim(ctx context.Context, runID string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(e.ClaimLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.repo.RenewRunClaim(ctx, runID); err != nil && ctx.Err() == nil {
+					log.Printf("phase1 executor: run %s: renew claim: %v", runID, err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
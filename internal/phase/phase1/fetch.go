@@ -0,0 +1,633 @@
+package phase1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/domain"
+	"investment_committee/internal/phase1/dedupe"
+	"investment_committee/internal/phase1/entity"
+	"investment_committee/internal/phase1/extract"
+	"investment_committee/internal/phase1/fetcher"
+	"investment_committee/internal/phase1/sentiment"
+	"investment_committee/internal/phase1/xbrl"
+)
+
+const (
+	defaultFetchConcurrency = 4
+	defaultSourceTimeout    = 2 * time.Minute
+	defaultRunTimeout       = 10 * time.Minute
+	// extractTimeout bounds the download of one document's content.
+	extractTimeout = time.Minute
+	// clusterWindow is how far back near-duplicates of a document are
+	// looked for.
+	clusterWindow = 14 * 24 * time.Hour
+)
+
+// fetchLimits bound the fetch stage of one run.
+type fetchLimits struct {
+	concurrency   int
+	sourceTimeout time.Duration
+	runTimeout    time.Duration
+}
+
+func limitsFromConfig(cfg fetcher.Phase1FetchConfig) fetchLimits {
+	l := fetchLimits{
+		concurrency:   cfg.Concurrency,
+		sourceTimeout: time.Duration(cfg.SourceTimeoutSeconds) * time.Second,
+		runTimeout:    time.Duration(cfg.RunTimeoutSeconds) * time.Second,
+	}
+	if l.concurrency <= 0 {
+		l.concurrency = defaultFetchConcurrency
+	}
+	if l.sourceTimeout <= 0 {
+		l.sourceTimeout = defaultSourceTimeout
+	}
+	if l.runTimeout <= 0 {
+		l.runTimeout = defaultRunTimeout
+	}
+	return l
+}
+
+// FetchDocuments fetches every configured source, split into partitions
+// (tickers, feeds) that run on a bounded worker pool. Once all sources are
+// fetched, the documents of each are stored as raw items and one doc.fetched
+// event is appended; each failed partition is recorded as doc.fetch_failed
+// and does not stop the others. The returned error joins all failures.
+//
+// A source, including the downloads of its documents' content, is bounded by
+// source_timeout_seconds from its first request and the whole stage by
+// run_timeout_seconds. Attempt events are appended concurrently;
+// CreatePhase1RunEvent serializes them per run.
+//
+// Each partition is given the watermark of its (source, entity, filter) so
+// that only new documents come back; a backfill ignores watermarks. The
+// newest document stored per partition is recorded in doc.fetched, and
+// FinalizeRun advances the watermarks to it only when the run succeeds.
+//
+// Stored documents are linked to every active universe item they mention,
+// not only to the one they were fetched for, and their text is scored by
+// scorer.
+func FetchDocuments(ctx context.Context, repo *queries.Repository, reg *fetcher.Registry, runID string, cfg fetcher.Phase1FetchConfig, scorer *sentiment.Scorer) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	addErr := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	fail := func(src, partition string, err error) {
+		addErr(fmt.Errorf("%s: %w", src, err))
+		payload := map[string]any{"source": src, "error": err.Error()}
+		if partition != "" {
+			payload["partition"] = partition
+		}
+		if aerr := appendEvent(ctx, repo, runID, domain.Phase1EventDocFetchFailed, domain.Phase1EventSourceSystem, payload); aerr != nil {
+			addErr(aerr)
+		}
+	}
+	marks := watermarks{}
+	if cfg.Backfill == nil {
+		for _, src := range cfg.Sources {
+			list, err := repo.ListFetchWatermarks(ctx, src)
+			if err != nil {
+				addErr(fmt.Errorf("%s: watermarks: %w", src, err))
+				continue
+			}
+			for _, w := range list {
+				marks.set(src, w.EntityKey, fetcher.Watermark{PublishedAt: w.LastPublishedAt, DocID: w.LastDocID})
+			}
+		}
+	}
+	var resolver *entity.Resolver
+	if universe, err := repo.ListActiveUniverseItems(ctx); err != nil {
+		addErr(fmt.Errorf("universe: %w", err))
+	} else {
+		resolver = newResolver(universeTargets(universe))
+	}
+	observe := func(a fetcher.Attempt) {
+		_ = appendEvent(ctx, repo, runID, domain.Phase1EventFetchAttempt, domain.Phase1EventSourceSystem, attemptPayload(a))
+	}
+	var results []sourceResult
+	fanOut(ctx, reg, cfg, limitsFromConfig(cfg), marks, observe, func(res sourceResult) {
+		mu.Lock()
+		results = append(results, res)
+		mu.Unlock()
+	})
+	// Results are recorded with ctx rather than the fetch deadlines so that
+	// a source that timed out is still recorded as failed and its documents
+	// stored.
+	for _, res := range results {
+		for _, f := range res.Failed {
+			fail(res.Source, f.Partition, f.Err)
+		}
+		if res.Fetched == 0 {
+			continue
+		}
+		if err := storeSource(ctx, repo, runID, res.Source, res.Docs, res.Contents, res.Watermarks, cfg.Targets, resolver, scorer); err != nil {
+			fail(res.Source, "", err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// selectUniverse resolves cfg.Universe against the active universe items,
+// adds their identifiers to cfg and appends one universe.member_added event
+// per selected item.
+func selectUniverse(ctx context.Context, repo *queries.Repository, runID string, cfg fetcher.Phase1FetchConfig) (fetcher.Phase1FetchConfig, error) {
+	if cfg.Universe == nil {
+		return cfg, nil
+	}
+	items, err := repo.ListActiveUniverseItems(ctx)
+	if err != nil {
+		return cfg, err
+	}
+	cfg = cfg.WithTargets(cfg.Universe.Select(universeTargets(items)))
+	for _, t := range cfg.Targets {
+		payload := map[string]any{
+			"universe_item_id": t.ID,
+			"entity_type":      t.EntityType,
+			"entity_id":        t.EntityID,
+			"priority":         t.Priority,
+		}
+		if err := appendEvent(ctx, repo, runID, domain.Phase1EventUniverseMemberAdded, domain.Phase1EventSourceSystem, payload); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// watermarks holds the fetch watermarks of a run by source and entity key.
+type watermarks map[string]map[string]fetcher.Watermark
+
+// watermarkKey is the entity key of a partition, followed by its filter
+// when it has one ("ticker:AAPL|forms=10-K"), so that runs with different
+// filters keep separate watermarks. A source fetched as a whole has its
+// watermark under "*".
+func watermarkKey(p fetcher.Partition) string {
+	key := p.Key
+	if key == "" {
+		key = "*"
+	}
+	if f := p.Filter(); f != "" {
+		key += "|" + f
+	}
+	return key
+}
+
+func (m watermarks) set(src, key string, w fetcher.Watermark) {
+	if m[src] == nil {
+		m[src] = map[string]fetcher.Watermark{}
+	}
+	m[src][key] = w
+}
+
+func (m watermarks) get(src, key string) (fetcher.Watermark, bool) {
+	w, ok := m[src][key]
+	return w, ok
+}
+
+// watermarkPayload is a partition's new watermark in doc.fetched.
+type watermarkPayload struct {
+	EntityKey   string    `json:"entity_key"`
+	PublishedAt time.Time `json:"published_at"`
+	DocID       string    `json:"doc_id"`
+}
+
+type partitionFailure struct {
+	Partition string
+	Err       error
+}
+
+// sourceResult is the merged outcome of all partitions of one source.
+// Fetched counts the partitions that succeeded, Contents holds the extracted
+// content of each of Docs and Watermarks the newest kept document of each
+// partition by entity key.
+type sourceResult struct {
+	Source     string
+	Docs       []fetcher.Document
+	Contents   []extracted
+	Fetched    int
+	Failed     []partitionFailure
+	Watermarks map[string]fetcher.Watermark
+}
+
+type partitionDocs struct {
+	key  string
+	docs []fetcher.Document
+}
+
+// sourceState collects the partitions of one source. Its deadline starts
+// with the first partition so that time spent queued behind other sources
+// does not count against it.
+type sourceState struct {
+	once      sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	mu        sync.Mutex
+	remaining int
+	parts     []partitionDocs
+	res       sourceResult
+}
+
+func (s *sourceState) start(parent context.Context, timeout time.Duration) context.Context {
+	s.once.Do(func() { s.ctx, s.cancel = context.WithTimeout(parent, timeout) })
+	return s.ctx
+}
+
+type fetchTask struct {
+	part  fetcher.Partition
+	f     fetcher.DocumentFetcher
+	state *sourceState
+}
+
+// fanOut fetches the partitions of all sources with at most
+// limits.concurrency in flight and calls done once per source, from the
+// worker that finished its last partition once it has downloaded the content
+// of the source's documents within the source's deadline (see
+// downloadContents). It returns when every source is
+// done; cancelling ctx or reaching the run timeout fails the partitions that
+// have not finished.
+func fanOut(ctx context.Context, reg *fetcher.Registry, cfg fetcher.Phase1FetchConfig, limits fetchLimits, marks watermarks, observe func(fetcher.Attempt), done func(sourceResult)) {
+	runCtx, cancel := context.WithTimeout(ctx, limits.runTimeout)
+	defer cancel()
+
+	var tasks []fetchTask
+	for _, src := range cfg.Sources {
+		f, ok := reg.Get(src)
+		if !ok {
+			done(sourceResult{Source: src, Failed: []partitionFailure{{Err: errors.New("unknown source")}}})
+			continue
+		}
+		parts := fetcher.Partitions(src, cfg)
+		state := &sourceState{remaining: len(parts), res: sourceResult{Source: src}}
+		for _, p := range parts {
+			if w, ok := marks.get(src, watermarkKey(p)); ok {
+				p.Config.Watermark = &w
+			}
+			tasks = append(tasks, fetchTask{part: p, f: f, state: state})
+		}
+	}
+
+	jobs := make(chan fetchTask)
+	var wg sync.WaitGroup
+	for i := 0; i < limits.concurrency && i < len(tasks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				runTask(runCtx, t, limits, observe, done)
+			}
+		}()
+	}
+	for _, t := range tasks {
+		jobs <- t
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func runTask(runCtx context.Context, t fetchTask, limits fetchLimits, observe func(fetcher.Attempt), done func(sourceResult)) {
+	s := t.state
+	sctx := s.start(runCtx, limits.sourceTimeout)
+	var docs []fetcher.Document
+	err := sctx.Err()
+	if err == nil {
+		docs, err = t.f.Fetch(fetcher.WithAttemptObserver(sctx, observe), t.part.Config)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("run timeout after %s: %w", limits.runTimeout, err)
+		case errors.Is(sctx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("source timeout after %s: %w", limits.sourceTimeout, err)
+		}
+	}
+
+	s.mu.Lock()
+	if err != nil {
+		s.res.Failed = append(s.res.Failed, partitionFailure{Partition: t.part.Key, Err: err})
+	} else {
+		s.res.Fetched++
+		s.parts = append(s.parts, partitionDocs{key: watermarkKey(t.part), docs: docs})
+	}
+	s.remaining--
+	last := s.remaining == 0
+	s.mu.Unlock()
+	if !last {
+		return
+	}
+	defer s.cancel()
+	all := make([][]fetcher.Document, 0, len(s.parts))
+	for _, p := range s.parts {
+		all = append(all, p.docs)
+	}
+	if len(all) == 1 {
+		s.res.Docs = all[0]
+	} else {
+		// The partitions share the source's max_items_per_source budget.
+		s.res.Docs = fetcher.MergeDocuments(t.part.Config.MaxItemsPerSource, all...)
+	}
+	kept := map[string]struct{}{}
+	for _, d := range s.res.Docs {
+		kept[d.DocID] = struct{}{}
+	}
+	s.res.Watermarks = map[string]fetcher.Watermark{}
+	for _, p := range s.parts {
+		if w, ok := partitionWatermark(p.docs, kept); ok {
+			s.res.Watermarks[p.key] = w
+		}
+	}
+	s.res.Contents = downloadContents(s.ctx, t.f, t.part.Config, s.res.Docs, limits.concurrency)
+	done(s.res)
+}
+
+// extracted is the content of one document and the error of its download.
+type extracted struct {
+	content documentContent
+	err     error
+}
+
+// downloadContents extracts the content of docs with at most concurrency
+// downloads in flight, under the source's deadline ctx. A document left
+// without time is given the text of its listing.
+func downloadContents(ctx context.Context, f fetcher.DocumentFetcher, cfg fetcher.Phase1FetchConfig, docs []fetcher.Document, concurrency int) []extracted {
+	var dl fetcher.ContentDownloader
+	if cfg.ExtractEnabled() {
+		dl, _ = fetcher.AsDownloader(f)
+	}
+	out := make([]extracted, len(docs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, d := range docs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			out[i].content, out[i].err = extractDocument(ctx, dl, d)
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// partitionWatermark is the watermark of the newest of docs that was kept,
+// unless the merge dropped some of docs: those must be fetched again, so it
+// does not pass the oldest of them.
+func partitionWatermark(docs []fetcher.Document, kept map[string]struct{}) (fetcher.Watermark, bool) {
+	var stored []fetcher.Document
+	var oldestDropped time.Time
+	for _, d := range docs {
+		if _, ok := kept[d.DocID]; ok {
+			stored = append(stored, d)
+		} else if oldestDropped.IsZero() || d.PublishedAt.Before(oldestDropped) {
+			oldestDropped = d.PublishedAt
+		}
+	}
+	if !oldestDropped.IsZero() {
+		n := 0
+		for _, d := range stored {
+			if !d.PublishedAt.After(oldestDropped) {
+				stored[n] = d
+				n++
+			}
+		}
+		stored = stored[:n]
+	}
+	return fetcher.NewWatermark(stored)
+}
+
+// universeTargets converts universe items to fetch targets. An item whose
+// keywords cannot be read is logged and kept without keywords.
+func universeTargets(items []models.UniverseItem) []fetcher.UniverseTarget {
+	targets := make([]fetcher.UniverseTarget, 0, len(items))
+	for _, it := range items {
+		var keywords []string
+		if len(it.Keywords) > 0 {
+			if err := json.Unmarshal(it.Keywords, &keywords); err != nil {
+				log.Printf("phase1: universe item %s (%s): invalid keywords: %v", it.ID, it.EntityID, err)
+				keywords = nil
+			}
+		}
+		targets = append(targets, fetcher.UniverseTarget{
+			ID:         it.ID,
+			EntityType: it.EntityType,
+			EntityID:   it.EntityID,
+			Name:       it.Name,
+			Keywords:   keywords,
+			Priority:   it.Priority,
+		})
+	}
+	return targets
+}
+
+// storeSource stores the documents of one source as raw items with their
+// extracted text and its tone, groups them with their near-duplicates, links
+// them to the universe items they were fetched for or that res finds in them
+// and appends its doc.fetched event with the partitions' new watermarks. A
+// document whose content cannot be downloaded is still stored, with the text
+// of its listing. contents holds the extracted content of each of docs.
+func storeSource(ctx context.Context, repo *queries.Repository, runID, src string, docs []fetcher.Document, contents []extracted, marks map[string]fetcher.Watermark, targets []fetcher.UniverseTarget, res *entity.Resolver, scorer *sentiment.Scorer) error {
+	fetcher.TagDocuments(docs, targets)
+	documents := docsToPayload(docs, targets)
+	rawItemIDs := []string{}
+	for i, d := range docs {
+		content, err := contents[i].content, contents[i].err
+		documents[i]["summary"] = content.Summary
+		documents[i]["language"] = content.Language
+		if err != nil {
+			documents[i]["extract_error"] = err.Error()
+		}
+		published := d.PublishedAt.UTC()
+		item := models.RawItem{
+			SourceType: src,
+			URL:        d.URL,
+			Title:      d.Title,
+			Published:  &published,
+			RawText:    content.Text,
+			Summary:    content.Summary,
+			Language:   content.Language,
+			DocType:    d.DocType,
+			Hash:       rawItemHash(d, content),
+		}
+		tone, scored := scorer.Score(content.Text, content.Language)
+		if scored {
+			item.Sentiment, _ = json.Marshal(tone)
+			documents[i]["sentiment"] = tone
+		}
+		id, dup, err := repo.UpsertRawItem(ctx, runID, item, d.DocID)
+		if err != nil {
+			return fmt.Errorf("store raw item %s: %w", d.DocID, err)
+		}
+		documents[i]["raw_item_id"] = id
+		documents[i]["duplicate"] = dup
+		rawItemIDs = append(rawItemIDs, id)
+		clusterID := id
+		if h, ok := dedupe.Simhash(content.Text); ok {
+			clusterID, err = repo.ClusterRawItem(ctx, id, h, dedupe.MaxDistance, time.Now().Add(-clusterWindow))
+			if err != nil {
+				return fmt.Errorf("cluster raw item %s: %w", d.DocID, err)
+			}
+		}
+		documents[i]["cluster_id"] = clusterID
+		documents[i]["canonical"] = clusterID == id
+		links := documentLinks(d, content.Text, targets, res)
+		for _, l := range links {
+			link := models.RawItemEntity{UniverseItemID: l.UniverseItemID, Score: l.Score, Evidence: l.Evidence}
+			if err := repo.LinkRawItemEntity(ctx, runID, id, link); err != nil {
+				return fmt.Errorf("link raw item %s: %w", d.DocID, err)
+			}
+		}
+		documents[i]["entities"] = links
+		if ev, ok := earningsEvent(runID, src, d, id, content.Earnings, targets); ok {
+			ev.ImpactJSON = sentimentImpact(item.Sentiment)
+			eventID, _, err := repo.CreateEvent(ctx, ev)
+			if err != nil {
+				return fmt.Errorf("store earnings event %s: %w", d.DocID, err)
+			}
+			documents[i]["event_ids"] = []string{eventID}
+		}
+	}
+	payload := map[string]any{
+		"source":       src,
+		"documents":    documents,
+		"raw_item_ids": rawItemIDs,
+	}
+	if len(marks) > 0 {
+		list := make([]watermarkPayload, 0, len(marks))
+		for key, w := range marks {
+			list = append(list, watermarkPayload{EntityKey: key, PublishedAt: w.PublishedAt, DocID: w.DocID})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].EntityKey < list[j].EntityKey })
+		payload["watermarks"] = list
+	}
+	return appendEvent(ctx, repo, runID, domain.Phase1EventDocFetched, domain.Phase1EventSourceOther, payload)
+}
+
+func attemptPayload(a fetcher.Attempt) map[string]any {
+	p := map[string]any{
+		"source":      a.Source,
+		"duration_ms": a.Duration.Milliseconds(),
+		"ok":          a.Err == nil,
+	}
+	if a.Err != nil {
+		p["url"] = a.URL
+		p["attempt"] = a.Number
+		p["max_attempts"] = a.MaxAttempts
+		p["error"] = a.Err.Error()
+		p["retryable"] = a.Retryable
+		p["next_delay_ms"] = a.NextDelay.Milliseconds()
+	} else {
+		p["documents"] = a.Documents
+	}
+	return p
+}
+
+func appendEvent(ctx context.Context, repo *queries.Repository, runID, eventType, source string, payload map[string]any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = repo.CreatePhase1RunEvent(ctx, models.Phase1RunEvent{
+		RunID:      runID,
+		EventType:  eventType,
+		Source:     domain.NormalizePhase1EventSource(source),
+		OccurredAt: time.Now().UTC(),
+		Payload:    b,
+	})
+	return err
+}
+
+// documentContent is what was extracted from a document: its text and, for
+// XBRL filings, the key facts of its earnings report.
+type documentContent struct {
+	extract.Result
+	Earnings *xbrl.Report
+	// Listing is set when the text is the listing's summary rather than the
+	// document's own.
+	Listing bool
+}
+
+// rawItemHash hashes the document's title and text. A listing's summary says
+// little about the document (an EDINET 臨時報告書 is its filer and type), so
+// it only identifies the document together with its URL.
+func rawItemHash(d fetcher.Document, c documentContent) string {
+	if c.Listing {
+		return queries.RawItemHash(d.Title, d.URL+"\n"+c.Text)
+	}
+	return queries.RawItemHash(d.Title, c.Text)
+}
+
+// extractDocument downloads the document's content with dl and extracts its
+// text and XBRL facts. Without a downloader, or when the download fails, the
+// text is extracted from the listing's summary instead and the download
+// error is returned with it. XBRL instances (EDINET) carry no prose, so
+// their text also comes from the summary.
+func extractDocument(ctx context.Context, dl fetcher.ContentDownloader, d fetcher.Document) (documentContent, error) {
+	fallback := func() extract.Result {
+		return extract.Process([]byte(d.Summary), "")
+	}
+	if dl == nil {
+		return documentContent{Result: fallback(), Listing: true}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, extractTimeout)
+	defer cancel()
+	body, contentType, err := dl.Download(ctx, d)
+	if errors.Is(err, fetcher.ErrNoContent) {
+		return documentContent{Result: fallback(), Listing: true}, nil
+	}
+	if err != nil {
+		return documentContent{Result: fallback(), Listing: true}, fmt.Errorf("download: %w", err)
+	}
+	var c documentContent
+	if inst, err := xbrl.Parse(body); err == nil {
+		c.Earnings = xbrl.Earnings(inst)
+	}
+	if xbrl.IsInstance(body) {
+		c.Result, c.Listing = fallback(), true
+		return c, nil
+	}
+	c.Result = extract.Process(body, contentType)
+	if c.Text == "" {
+		c.Result, c.Listing = fallback(), true
+		return c, errors.New("no text in document")
+	}
+	return c, nil
+}
+
+func docsToPayload(docs []fetcher.Document, targets []fetcher.UniverseTarget) []map[string]any {
+	entities := map[string]string{}
+	for _, t := range targets {
+		entities[t.ID] = t.EntityID
+	}
+	out := make([]map[string]any, 0, len(docs))
+	for _, d := range docs {
+		p := map[string]any{
+			"doc_id":       d.DocID,
+			"title":        d.Title,
+			"url":          d.URL,
+			"published_at": d.PublishedAt.UTC().Format(time.RFC3339),
+			"ticker":       d.Ticker,
+			"summary":      d.Summary,
+			"doc_type":     d.DocType,
+			"meta":         d.Meta,
+		}
+		if d.UniverseItemID != "" {
+			p["universe_item_id"] = d.UniverseItemID
+			p["entity_id"] = entities[d.UniverseItemID]
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+package phase1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"investment_committee/internal/phase1/fetcher"
+)
+
+// fakeFetcher returns one document per SEC ticker or IR feed after delay and
+// tracks how many calls are in flight.
+type fakeFetcher struct {
+	source   string
+	delay    time.Duration
+	inFlight *atomic.Int32
+	maxSeen  *atomic.Int32
+}
+
+func (f fakeFetcher) Source() string { return f.source }
+
+func (f fakeFetcher) Fetch(ctx context.Context, cfg fetcher.Phase1FetchConfig) ([]fetcher.Document, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		m := f.maxSeen.Load()
+		if n <= m || f.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(f.delay):
+	}
+	keys := cfg.SECTickers
+	for _, feed := range cfg.IRFeeds {
+		keys = append(keys, feed.URL)
+	}
+	var out []fetcher.Document
+	for i, k := range keys {
+		out = append(out, fetcher.Document{
+			DocID:       f.source + ":" + k,
+			PublishedAt: time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return out, nil
+}
+
+func TestFanOut(t *testing.T) {
+	var inFlight, maxSeen atomic.Int32
+	reg := fetcher.NewRegistry()
+	reg.Register(fakeFetcher{source: "sec", delay: 20 * time.Millisecond, inFlight: &inFlight, maxSeen: &maxSeen})
+	reg.Register(fakeFetcher{source: "ir", delay: time.Second, inFlight: &inFlight, maxSeen: &maxSeen})
+
+	cfg := fetcher.Phase1FetchConfig{
+		Sources:           []string{"sec", "ir", "nope"},
+		MaxItemsPerSource: 3,
+		SECTickers:        []string{"A", "B", "C", "D", "E"},
+		IRFeeds:           []fetcher.IRFeed{{URL: "f1"}, {URL: "f2"}},
+	}
+	limits := fetchLimits{concurrency: 2, sourceTimeout: 100 * time.Millisecond, runTimeout: 5 * time.Second}
+
+	var mu sync.Mutex
+	results := map[string]sourceResult{}
+	fanOut(context.Background(), reg, cfg, limits, watermarks{}, func(fetcher.Attempt) {}, func(res sourceResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, dup := results[res.Source]; dup {
+			t.Errorf("%s reported twice", res.Source)
+		}
+		results[res.Source] = res
+	})
+
+	if got := maxSeen.Load(); got > 2 {
+		t.Fatalf("max in flight = %d, want <= 2", got)
+	}
+	sec := results["sec"]
+	if sec.Fetched != 5 || len(sec.Failed) != 0 {
+		t.Fatalf("sec = %+v", sec)
+	}
+	var ids []string
+	for _, d := range sec.Docs {
+		ids = append(ids, d.DocID)
+	}
+	// Every partition returns its ticker's document; the budget keeps 3.
+	if len(ids) != 3 {
+		t.Fatalf("sec docs = %v, want 3", ids)
+	}
+	ir := results["ir"]
+	if ir.Fetched != 0 || len(ir.Failed) != 2 {
+		t.Fatalf("ir = %+v, want both feeds timed out", ir)
+	}
+	for _, f := range ir.Failed {
+		if !strings.HasPrefix(f.Partition, "feed:") || !strings.Contains(f.Err.Error(), "source timeout") {
+			t.Fatalf("ir failure = %s %v", f.Partition, f.Err)
+		}
+	}
+	if nope := results["nope"]; len(nope.Failed) != 1 || fmt.Sprint(nope.Failed[0].Err) != "unknown source" {
+		t.Fatalf("nope = %+v", nope)
+	}
+}
+
+func TestRawItemHash(t *testing.T) {
+	content := func(text string, listing bool) documentContent {
+		c := documentContent{Listing: listing}
+		c.Text = text
+		return c
+	}
+	ir := fetcher.Document{Title: "Example Corp Reports Q4 Results", URL: "https://ir.example.com/q4"}
+	wire := fetcher.Document{Title: "Example Corp reports Q4 results", URL: "https://news.example.net/x/1"}
+	if rawItemHash(ir, content("Revenue rose 8%.\n\nMargins  improved.", false)) != rawItemHash(wire, content("revenue rose 8%. margins improved.", false)) {
+		t.Fatal("syndicated copy hashed differently")
+	}
+	a := fetcher.Document{Title: "トヨタ自動車株式会社 臨時報告書", URL: "https://disclosure.edinet-fsa.go.jp/S100AAAA"}
+	b := fetcher.Document{Title: a.Title, URL: "https://disclosure.edinet-fsa.go.jp/S100BBBB"}
+	if rawItemHash(a, content("臨時報告書", true)) == rawItemHash(b, content("臨時報告書", true)) {
+		t.Fatal("listings of different filings hashed the same")
+	}
+}
+
+func TestPartitionWatermark(t *testing.T) {
+	day := func(id string, d int) fetcher.Document {
+		return fetcher.Document{DocID: id, PublishedAt: time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)}
+	}
+	docs := []fetcher.Document{day("a", 1), day("b", 2), day("c", 3), day("d", 4)}
+
+	w, ok := partitionWatermark(docs, map[string]struct{}{"a": {}, "b": {}, "c": {}, "d": {}})
+	if !ok || w.DocID != "d" {
+		t.Fatalf("all kept = %+v %v, want d", w, ok)
+	}
+	// The merge dropped c, so the watermark must stay at b.
+	w, ok = partitionWatermark(docs, map[string]struct{}{"a": {}, "b": {}, "d": {}})
+	if !ok || w.DocID != "b" {
+		t.Fatalf("c dropped = %+v %v, want b", w, ok)
+	}
+	if w, ok := partitionWatermark(docs, map[string]struct{}{"c": {}, "d": {}}); ok {
+		t.Fatalf("a dropped = %+v, want no watermark", w)
+	}
+}
+
+func TestWatermarkKey(t *testing.T) {
+	cfg := fetcher.Phase1FetchConfig{
+		SECTickers:     []string{"AAPL"},
+		SECForms:       []string{"8-k", " 10-K"},
+		EDINETDocTypes: []string{"120"},
+		EDINETSecCodes: []string{"7203"},
+	}
+	sec := fetcher.Partitions("sec", cfg)[0]
+	if got := watermarkKey(sec); got != "ticker:AAPL|forms=10-K,8-K" {
+		t.Errorf("sec key = %q", got)
+	}
+	if got := watermarkKey(fetcher.Partitions("edinet", cfg)[0]); got != "*|doc_types=120;sec_codes=72030" {
+		t.Errorf("edinet key = %q", got)
+	}
+	cfg.SECForms = nil
+	if got := watermarkKey(fetcher.Partitions("sec", cfg)[0]); got != "ticker:AAPL" {
+		t.Errorf("unfiltered sec key = %q", got)
+	}
+}
+
+// slowDownloader serves every document's content after delay and tracks how
+// many downloads are in flight.
+type slowDownloader struct {
+	fakeFetcher
+}
+
+func (f slowDownloader) Download(ctx context.Context, d fetcher.Document) ([]byte, string, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		m := f.maxSeen.Load()
+		if n <= m || f.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case <-time.After(f.delay):
+	}
+	return []byte("Full text of " + d.DocID + "."), "text/plain", nil
+}
+
+func TestDownloadContents(t *testing.T) {
+	var inFlight, maxSeen atomic.Int32
+	f := slowDownloader{fakeFetcher{source: "sec", delay: 20 * time.Millisecond, inFlight: &inFlight, maxSeen: &maxSeen}}
+	var docs []fetcher.Document
+	for i := 0; i < 5; i++ {
+		docs = append(docs, fetcher.Document{DocID: fmt.Sprint("d", i), Summary: "listing"})
+	}
+
+	out := downloadContents(context.Background(), f, fetcher.Phase1FetchConfig{}, docs, 2)
+	if got := maxSeen.Load(); got > 2 {
+		t.Fatalf("max downloads in flight = %d, want <= 2", got)
+	}
+	for i, e := range out {
+		if e.err != nil || e.content.Listing || e.content.Text != "Full text of "+docs[i].DocID+"." {
+			t.Fatalf("content %d = %+v, %v", i, e.content, e.err)
+		}
+	}
+
+	// Past the source's deadline documents keep their listing's text.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	f.delay = time.Second
+	start := time.Now()
+	out = downloadContents(ctx, f, fetcher.Phase1FetchConfig{}, docs, 2)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("downloads outlasted the deadline: %s", elapsed)
+	}
+	for i, e := range out {
+		if e.err == nil || !e.content.Listing || e.content.Text != "listing" {
+			t.Fatalf("content %d = %+v, %v", i, e.content, e.err)
+		}
+	}
+}
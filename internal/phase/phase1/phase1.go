@@ -3,6 +3,8 @@ package phase1
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"investment_committee/internal/db/models"
@@ -10,24 +12,180 @@ import (
 	"investment_committee/internal/domain"
 )
 
+// ErrRunNotFetched is returned when finalizing a run whose sources are still
+// being fetched by the executor.
+var ErrRunNotFetched = errors.New("run still fetching")
+
+// RunOptions are the non-fetch settings read from a run's config.
+type RunOptions struct {
+	// AutoFinalize lets the executor finalize the run right after fetching.
+	// Set it to false to keep the run open for manual events until
+	// POST /phase1/runs/{id}/finalize. Defaults to true.
+	AutoFinalize *bool `json:"auto_finalize,omitempty"`
+	// TriggerRules overrides fields of domain.DefaultTriggerRules.
+	TriggerRules json.RawMessage `json:"trigger_rules,omitempty"`
+	// AnomalyRules overrides fields of domain.DefaultAnomalyRules.
+	AnomalyRules json.RawMessage `json:"anomaly_rules,omitempty"`
+	// SignalRules overrides fields of domain.DefaultSignalRules, including
+	// the signal detectors that run.
+	SignalRules json.RawMessage `json:"signal_rules,omitempty"`
+}
+
+func (o RunOptions) autoFinalize() bool {
+	return o.AutoFinalize == nil || *o.AutoFinalize
+}
+
+// FinalizeRun closes a run: it writes the anomaly summary, appends the
+// signal.detected events of the signal detectors, writes the trigger
+// decision, advances the fetch watermarks of a successful run, appends
+// run.finalized and sets the final status. A run can be finalized once, and
+// only after the executor has fetched its sources. All writes happen in one
+// transaction that claims the run, so a concurrent finalize gets
+// ErrRunFinalized and a failed one can be retried without duplicating
+// signals.
 func FinalizeRun(ctx context.Context, repo *queries.Repository, runID string) error {
-	emptySummary := json.RawMessage(`{}`)
-	if err := repo.CreateAnomalySummary(ctx, runID, emptySummary); err != nil {
+	run, err := repo.GetRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if run.FetchedAt == nil {
+		return ErrRunNotFetched
+	}
+	events, err := repo.ListAllPhase1RunEvents(ctx, runID)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if e.EventType == domain.Phase1EventRunFinalized {
+			return queries.ErrRunFinalized
+		}
+	}
+	if run.Status == "failed" {
+		// The executor failed the run before fetching it (invalid config,
+		// universe), so there is nothing to evaluate.
+		return finalize(ctx, repo, queries.RunFinalization{RunID: runID, Status: run.Status, Error: run.Error})
+	}
+	var opts RunOptions
+	if len(run.ConfigJSON) > 0 {
+		if err := json.Unmarshal(run.ConfigJSON, &opts); err != nil {
+			return err
+		}
+	}
+	anomalyRules, err := domain.ParseAnomalyRules(opts.AnomalyRules)
+	if err != nil {
+		return err
+	}
+	signalRules, err := domain.ParseSignalRules(opts.SignalRules)
+	if err != nil {
+		return err
+	}
+	rules, err := domain.ParseTriggerRules(opts.TriggerRules)
+	if err != nil {
+		return err
+	}
+
+	anomalies, err := detectAnomalies(ctx, repo, runID, events, anomalyRules)
+	if err != nil {
 		return err
 	}
-	decision := json.RawMessage(`{"should_handoff":false,"type":"none","reasons":[],"candidates":[]}`)
-	if err := repo.CreateTriggerDecision(ctx, runID, decision); err != nil {
+	summary, err := json.Marshal(anomalies)
+	if err != nil {
 		return err
 	}
-	finalEvent := models.Phase1RunEvent{
-		RunID:      runID,
+	signals, err := detectSignals(ctx, repo, runID, events, anomalies, anomalyRules, signalRules)
+	if err != nil {
+		return err
+	}
+	universe, err := repo.ListActiveUniverseItems(ctx)
+	if err != nil {
+		return err
+	}
+	in := triggerInput(append(events, signals...), anomalies.Activity, universe)
+	decision, err := json.Marshal(domain.EvaluateTrigger(in, rules))
+	if err != nil {
+		return err
+	}
+	status, errMsg := runOutcome(events)
+	f := queries.RunFinalization{
+		RunID:           runID,
+		AnomalySummary:  summary,
+		TriggerDecision: decision,
+		Signals:         signals,
+		Status:          status,
+		Error:           errMsg,
+	}
+	if status == "success" {
+		f.Watermarks = runWatermarks(runID, events)
+	}
+	return finalize(ctx, repo, f)
+}
+
+// runWatermarks collects the watermarks recorded in the run's doc.fetched
+// events.
+func runWatermarks(runID string, events []models.Phase1RunEvent) []models.FetchWatermark {
+	var out []models.FetchWatermark
+	for _, e := range events {
+		if e.EventType != domain.Phase1EventDocFetched {
+			continue
+		}
+		var p struct {
+			Source     string             `json:"source"`
+			Watermarks []watermarkPayload `json:"watermarks"`
+		}
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			continue
+		}
+		for _, w := range p.Watermarks {
+			out = append(out, models.FetchWatermark{
+				Source:          p.Source,
+				EntityKey:       w.EntityKey,
+				LastPublishedAt: w.PublishedAt,
+				LastDocID:       w.DocID,
+				RunID:           &runID,
+			})
+		}
+	}
+	return out
+}
+
+// finalize writes f with its run.finalized event.
+func finalize(ctx context.Context, repo *queries.Repository, f queries.RunFinalization) error {
+	payload, err := json.Marshal(map[string]any{"status": f.Status})
+	if err != nil {
+		return err
+	}
+	f.Finalized = models.Phase1RunEvent{
+		RunID:      f.RunID,
 		EventType:  domain.Phase1EventRunFinalized,
-		Source:     "system",
+		Source:     domain.Phase1EventSourceSystem,
 		OccurredAt: time.Now().UTC(),
-		Payload:    json.RawMessage(`{"status":"success"}`),
+		Payload:    payload,
 	}
-	if _, err := repo.CreatePhase1RunEvent(ctx, finalEvent); err != nil {
-		return err
+	return repo.FinalizePhase1Run(ctx, f)
+}
+
+// runOutcome fails a run when any source failed to fetch and joins the
+// recorded errors into runs.error.
+func runOutcome(events []models.Phase1RunEvent) (string, *string) {
+	var msgs []string
+	for _, e := range events {
+		if e.EventType != domain.Phase1EventDocFetchFailed {
+			continue
+		}
+		var p struct {
+			Source    string `json:"source"`
+			Partition string `json:"partition"`
+			Error     string `json:"error"`
+		}
+		_ = json.Unmarshal(e.Payload, &p)
+		if p.Partition != "" {
+			p.Source += " " + p.Partition
+		}
+		msgs = append(msgs, p.Source+": "+p.Error)
+	}
+	if len(msgs) == 0 {
+		return "success", nil
 	}
-	return repo.UpdateRunStatus(ctx, runID, "success", nil)
+	msg := strings.Join(msgs, "; ")
+	return "failed", &msg
 }
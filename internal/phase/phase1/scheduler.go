@@ -0,0 +1,80 @@
+package phase1
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"investment_committee/internal/cron"
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/db/queries"
+)
+
+const defaultSchedulerInterval = 30 * time.Second
+
+type runQueue interface {
+	Enqueue(runID string)
+}
+
+// Scheduler creates Phase1 runs with mode scheduled from phase1_schedules.
+// Every replica may run one; FireDueSchedules makes sure each due slot
+// produces a single run. A schedule that was due several times while no
+// scheduler was running fires once and then resumes from the current time.
+type Scheduler struct {
+	repo         *queries.Repository
+	runs         runQueue
+	PollInterval time.Duration
+	Now          func() time.Time
+}
+
+func NewScheduler(repo *queries.Repository, runs runQueue) *Scheduler {
+	return &Scheduler{
+		repo:         repo,
+		runs:         runs,
+		PollInterval: defaultSchedulerInterval,
+		Now:          time.Now,
+	}
+}
+
+// Run fires due schedules until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) Tick(ctx context.Context) {
+	now := s.Now().UTC()
+	ids, err := s.repo.FireDueSchedules(ctx, now, func(sc models.Phase1Schedule) *time.Time {
+		return NextScheduleRun(sc.Cron, sc.Timezone, now)
+	})
+	if err != nil {
+		log.Printf("phase1 scheduler: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.runs.Enqueue(id)
+	}
+}
+
+// NextScheduleRun returns the first activation of expr after t, or nil when
+// the expression is invalid or never fires.
+func NextScheduleRun(expr, tz string, t time.Time) *time.Time {
+	c, err := cron.Parse(expr, tz)
+	if err != nil {
+		return nil
+	}
+	next := c.Next(t)
+	if next.IsZero() {
+		return nil
+	}
+	next = next.UTC()
+	return &next
+}
@@ -0,0 +1,170 @@
+package phase1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/db/queries"
+	"investment_committee/internal/domain"
+	"investment_committee/internal/phase1/xbrl"
+)
+
+// priorEventLimit bounds the earlier earnings events loaded per entity for
+// the guidance change detector.
+const priorEventLimit = 50
+
+// detectSignals runs the detectors enabled by rules over the run's documents
+// and events and returns a signal.detected event for every hit, for
+// FinalizeRun to append with the run's finalization. Scored filings are
+// given the entity's previous filing of the same type for the tone shift
+// detector.
+func detectSignals(ctx context.Context, repo *queries.Repository, runID string, events []models.Phase1RunEvent, anomalies domain.AnomalySummary, anomalyRules domain.AnomalyRules, rules domain.SignalRules) ([]models.Phase1RunEvent, error) {
+	detectors := rules.Enabled()
+	if len(detectors) == 0 {
+		return nil, nil
+	}
+	runEvents, err := repo.ListAllEventsByRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	in := domain.SignalInput{
+		Documents:    signalDocuments(events),
+		Anomalies:    anomalies,
+		AnomalyRules: anomalyRules,
+	}
+	toneShift := enabled(detectors, domain.SignalDetectorToneShift)
+	guided := map[string]bool{}
+	for _, e := range runEvents {
+		se := signalEvent(e)
+		in.Events = append(in.Events, se)
+		if len(se.Guidance) > 0 {
+			guided[e.EntityID] = true
+		}
+	}
+	for i, d := range in.Documents {
+		if !toneShift || d.Tone == nil || d.DocType == "" || d.UniverseItemID == "" || d.RawItemID == "" {
+			continue
+		}
+		prev, err := repo.PreviousFiling(ctx, d.UniverseItemID, d.Source, d.DocType, d.RawItemID, d.PublishedAt)
+		if errors.Is(err, queries.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var tone domain.Tone
+		if prev.Published == nil || json.Unmarshal(prev.Sentiment, &tone) != nil {
+			continue
+		}
+		in.Documents[i].Previous = &domain.SignalFiling{RawItemID: prev.ID, PublishedAt: *prev.Published, Tone: tone}
+	}
+	for entity := range guided {
+		entity, category := entity, "earnings"
+		prior, _, err := repo.ListEvents(ctx, queries.EventFilter{EntityID: &entity, Category: &category, Limit: priorEventLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range prior {
+			if e.RunID != runID {
+				in.PriorEvents = append(in.PriorEvents, signalEvent(e))
+			}
+		}
+	}
+
+	var out []models.Phase1RunEvent
+	for _, s := range domain.DetectSignals(in, detectors) {
+		payload, err := json.Marshal(map[string]any{
+			"entity_id":     s.EntityID,
+			"severity":      s.Severity,
+			"detector":      s.Detector,
+			"message":       s.Message,
+			"evidence":      s.Evidence,
+			"rules_version": rules.Version,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, models.Phase1RunEvent{
+			RunID:      runID,
+			EventType:  domain.Phase1EventSignalDetected,
+			Source:     domain.Phase1EventSourceSystem,
+			OccurredAt: time.Now().UTC(),
+			Payload:    payload,
+		})
+	}
+	return out, nil
+}
+
+// signalDocuments collects the documents of the run's doc.fetched events.
+func signalDocuments(events []models.Phase1RunEvent) []domain.SignalDocument {
+	var out []domain.SignalDocument
+	for _, e := range events {
+		if e.EventType != domain.Phase1EventDocFetched {
+			continue
+		}
+		var p docFetchedPayload
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			continue
+		}
+		for _, d := range p.Documents {
+			doc := domain.SignalDocument{
+				RawItemID:      d.RawItemID,
+				DocID:          d.DocID,
+				ClusterID:      d.ClusterID,
+				Source:         p.Source,
+				DocType:        d.DocType,
+				Title:          d.Title,
+				Meta:           d.Meta,
+				PublishedAt:    d.PublishedAt,
+				UniverseItemID: d.UniverseItemID,
+				EntityID:       d.Ticker,
+				Tone:           d.Sentiment,
+			}
+			if doc.EntityID == "" {
+				doc.EntityID = d.EntityID
+			}
+			for _, l := range d.Entities {
+				doc.Entities = append(doc.Entities, domain.SignalEntity{EntityID: l.EntityID, Evidence: l.Evidence})
+			}
+			out = append(out, doc)
+		}
+	}
+	return out
+}
+
+func enabled(detectors []domain.SignalDetector, name string) bool {
+	for _, d := range detectors {
+		if d.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// signalEvent reads the guidance key facts of an earnings event.
+func signalEvent(e models.Event) domain.SignalEvent {
+	se := domain.SignalEvent{
+		EventID:    e.EventID,
+		EntityID:   e.EntityID,
+		Category:   e.Category,
+		ObservedAt: e.ObservedAt,
+	}
+	var facts struct {
+		Facts []struct {
+			Kind string `json:"kind"`
+			domain.GuidanceFact
+		} `json:"facts"`
+	}
+	if e.Category != "earnings" || json.Unmarshal(e.FactsJSON, &facts) != nil {
+		return se
+	}
+	for _, f := range facts.Facts {
+		if f.Kind == xbrl.KindGuidance {
+			se.Guidance = append(se.Guidance, f.GuidanceFact)
+		}
+	}
+	return se
+}
@@ -0,0 +1,71 @@
+package phase1
+
+import (
+	"encoding/json"
+	"time"
+
+	"investment_committee/internal/db/models"
+	"investment_committee/internal/domain"
+)
+
+type docFetchedPayload struct {
+	Source    string `json:"source"`
+	Documents []struct {
+		DocID          string            `json:"doc_id"`
+		Title          string            `json:"title"`
+		DocType        string            `json:"doc_type"`
+		Meta           map[string]string `json:"meta"`
+		PublishedAt    time.Time         `json:"published_at"`
+		Ticker         string            `json:"ticker"`
+		EntityID       string            `json:"entity_id"`
+		UniverseItemID string            `json:"universe_item_id"`
+		RawItemID      string            `json:"raw_item_id"`
+		ClusterID      string            `json:"cluster_id"`
+		Entities       []struct {
+			UniverseItemID string   `json:"universe_item_id"`
+			EntityID       string   `json:"entity_id"`
+			Evidence       []string `json:"evidence"`
+		} `json:"entities"`
+		Sentiment *domain.Tone `json:"sentiment"`
+	} `json:"documents"`
+}
+
+type signalPayload struct {
+	EntityID string `json:"entity_id"`
+	Ticker   string `json:"ticker"`
+	Severity string `json:"severity"`
+	Detector string `json:"detector"`
+}
+
+// triggerInput combines the run's document counts, its signal.detected events,
+// manual or from the detectors, and the active universe into the rule
+// engine's input.
+func triggerInput(events []models.Phase1RunEvent, activity domain.ActivityCounts, universe []models.UniverseItem) domain.TriggerInput {
+	in := domain.TriggerInput{DocCounts: activity.Entity}
+	for _, e := range events {
+		if e.EventType != domain.Phase1EventSignalDetected {
+			continue
+		}
+		var p signalPayload
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			continue
+		}
+		entity := p.EntityID
+		if entity == "" {
+			entity = p.Ticker
+		}
+		if entity == "" {
+			continue
+		}
+		in.Signals = append(in.Signals, domain.TriggerSignal{EntityID: entity, Severity: p.Severity, Detector: p.Detector})
+	}
+	for _, u := range universe {
+		in.Universe = append(in.Universe, domain.TriggerUniverseItem{
+			ID:         u.ID,
+			EntityType: u.EntityType,
+			EntityID:   u.EntityID,
+			Priority:   u.Priority,
+		})
+	}
+	return in
+}
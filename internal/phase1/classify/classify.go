@@ -0,0 +1,94 @@
+// Package classify assigns fetched documents to the event categories of the
+// events table with rule-based classifiers. Classifiers are pluggable: a
+// Chain runs several and keeps the most confident answer.
+package classify
+
+import "strings"
+
+// Event categories, as allowed by the events table.
+const (
+	CategoryEarnings     = "earnings"
+	CategoryIR           = "ir"
+	CategoryRegulation   = "regulation"
+	CategoryTechnology   = "technology"
+	CategoryMacro        = "macro"
+	CategorySupplyDemand = "supply_demand"
+	CategoryCompetition  = "competition"
+	CategoryPriceAction  = "price_action"
+	CategoryOther        = "other"
+)
+
+// Categories lists the allowed categories.
+var Categories = []string{
+	CategoryEarnings, CategoryIR, CategoryRegulation, CategoryTechnology, CategoryMacro,
+	CategorySupplyDemand, CategoryCompetition, CategoryPriceAction, CategoryOther,
+}
+
+// IsCategory reports whether c is an allowed category.
+func IsCategory(c string) bool {
+	for _, v := range Categories {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Document is what classifiers see of a fetched document.
+type Document struct {
+	Source   string
+	DocType  string
+	Title    string
+	Summary  string
+	Text     string
+	Language string
+	Meta     map[string]string
+}
+
+// Result is a classification. Rule names the rule that matched and Tags the
+// evidence (matched keywords or form types).
+type Result struct {
+	Category   string
+	Confidence float64
+	Rule       string
+	Tags       []string
+}
+
+// Classifier classifies a document, reporting false when it has no opinion.
+type Classifier interface {
+	Name() string
+	Classify(d Document) (Result, bool)
+}
+
+// Chain runs its classifiers in order and returns the most confident result;
+// on a tie the earlier classifier wins.
+type Chain []Classifier
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, cl := range c {
+		names[i] = cl.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (c Chain) Classify(d Document) (Result, bool) {
+	var best Result
+	found := false
+	for _, cl := range c {
+		r, ok := cl.Classify(d)
+		if !ok || !IsCategory(r.Category) {
+			continue
+		}
+		if !found || r.Confidence > best.Confidence {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// Default returns the built-in classifiers: filing types first, then
+// keyword rules.
+func Default() Chain {
+	return Chain{FormClassifier{}, NewKeywordClassifier(DefaultKeywordRules())}
+}
@@ -0,0 +1,53 @@
+package classify
+
+import "testing"
+
+func TestDefaultChain(t *testing.T) {
+	cases := []struct {
+		name     string
+		doc      Document
+		category string
+		rule     string
+	}{
+		{"10-Q", Document{Source: "sec", DocType: "10-Q", Title: "Example Corp 10-Q 2024-10-30"}, CategoryEarnings, "form"},
+		{"8-K results", Document{Source: "sec", DocType: "8-K", Meta: map[string]string{"items": "2.02,9.01"}}, CategoryEarnings, "form"},
+		{"8-K officer", Document{Source: "sec", DocType: "8-K", Meta: map[string]string{"items": "5.02"}}, CategoryIR, "form"},
+		{"EDINET buyback", Document{Source: "edinet", DocType: "share_buyback_report"}, CategoryIR, "form"},
+		{"IR dividend", Document{Source: "ir", DocType: "ir_news", Title: "Notice of Dividend Increase and Share Repurchase"}, CategoryIR, "keyword"},
+		{"IR ja forecast", Document{Source: "ir", DocType: "ir_news", Title: "業績予想の上方修正に関するお知らせ"}, CategoryEarnings, "keyword"},
+		{"IR recall", Document{Source: "ir", DocType: "ir_news", Title: "Voluntary recall of model X", Summary: "The FDA was notified."}, CategoryRegulation, "keyword"},
+		// An 8-K whose item is unmapped falls back to IR, but a clear
+		// headline wins over that weak form rule.
+		{"8-K launch", Document{Source: "sec", DocType: "8-K", Meta: map[string]string{"items": "7.01"}, Title: "Example unveils new product platform"}, CategoryTechnology, "keyword"},
+	}
+	chain := Default()
+	for _, tc := range cases {
+		r, ok := chain.Classify(tc.doc)
+		if !ok || r.Category != tc.category || r.Rule != tc.rule {
+			t.Errorf("%s: got %+v ok=%v, want %s by %s", tc.name, r, ok, tc.category, tc.rule)
+		}
+		if r.Confidence <= 0 || r.Confidence > 1 {
+			t.Errorf("%s: confidence %v", tc.name, r.Confidence)
+		}
+	}
+	if _, ok := chain.Classify(Document{Source: "ir", Title: "Website maintenance notice"}); ok {
+		t.Error("unrelated document classified")
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	for _, tc := range []struct {
+		text, kw string
+		want     bool
+	}{
+		{"new ai chip", "ai", true},
+		{"said the company", "ai", false},
+		{"eps of $1.20", "eps", true},
+		{"steps taken", "eps", false},
+		{"配当予想の修正", "配当", true},
+	} {
+		if got := containsWord(tc.text, tc.kw); got != tc.want {
+			t.Errorf("containsWord(%q, %q) = %v", tc.text, tc.kw, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package classify
+
+import "strings"
+
+// FormClassifier classifies regulatory filings by their form: periodic
+// reports are earnings, other disclosures are IR. 8-K filings are classified
+// by their items, so an 8-K announcing results (item 2.02) is earnings.
+type FormClassifier struct{}
+
+func (FormClassifier) Name() string { return "form" }
+
+type formRule struct {
+	category   string
+	confidence float64
+}
+
+var secForms = map[string]formRule{
+	"10-K":     {CategoryEarnings, 0.9},
+	"10-K/A":   {CategoryEarnings, 0.8},
+	"10-Q":     {CategoryEarnings, 0.9},
+	"10-Q/A":   {CategoryEarnings, 0.8},
+	"20-F":     {CategoryEarnings, 0.9},
+	"40-F":     {CategoryEarnings, 0.9},
+	"6-K":      {CategoryIR, 0.5},
+	"DEF 14A":  {CategoryIR, 0.8},
+	"SC 13D":   {CategoryIR, 0.8},
+	"SC 13G":   {CategoryIR, 0.7},
+	"S-1":      {CategoryIR, 0.8},
+	"S-3":      {CategoryIR, 0.7},
+	"424B2":    {CategoryIR, 0.6},
+	"424B5":    {CategoryIR, 0.6},
+	"4":        {CategoryIR, 0.6},
+	"SD":       {CategoryRegulation, 0.6},
+	"CORRESP":  {CategoryRegulation, 0.7},
+	"UPLOAD":   {CategoryRegulation, 0.7},
+	"11-K":     {CategoryIR, 0.5},
+	"8-K":      {CategoryIR, 0.5},
+	"8-K/A":    {CategoryIR, 0.5},
+	"ARS":      {CategoryEarnings, 0.7},
+	"NT 10-K":  {CategoryEarnings, 0.7},
+	"NT 10-Q":  {CategoryEarnings, 0.7},
+	"SC 13D/A": {CategoryIR, 0.7},
+	"SC 13G/A": {CategoryIR, 0.6},
+}
+
+// sec8KItems maps 8-K items to categories, most specific first.
+var sec8KItems = []struct {
+	item string
+	formRule
+}{
+	{"2.02", formRule{CategoryEarnings, 0.9}},
+	{"1.03", formRule{CategoryIR, 0.8}},         // bankruptcy
+	{"2.01", formRule{CategoryIR, 0.8}},         // acquisition or disposition
+	{"1.01", formRule{CategoryIR, 0.7}},         // material agreement
+	{"5.02", formRule{CategoryIR, 0.7}},         // officers and directors
+	{"3.01", formRule{CategoryRegulation, 0.8}}, // delisting
+	{"4.02", formRule{CategoryRegulation, 0.8}}, // non-reliance on financials
+}
+
+// edinetDocTypes maps the EDINET document labels of the fetcher.
+var edinetDocTypes = map[string]formRule{
+	"annual_securities_report":          {CategoryEarnings, 0.85},
+	"amended_annual_securities_report":  {CategoryEarnings, 0.75},
+	"quarterly_report":                  {CategoryEarnings, 0.85},
+	"amended_quarterly_report":          {CategoryEarnings, 0.75},
+	"semiannual_report":                 {CategoryEarnings, 0.85},
+	"amended_semiannual_report":         {CategoryEarnings, 0.75},
+	"securities_registration":           {CategoryIR, 0.7},
+	"extraordinary_report":              {CategoryIR, 0.6},
+	"amended_extraordinary_report":      {CategoryIR, 0.5},
+	"share_buyback_report":              {CategoryIR, 0.8},
+	"large_shareholding_report":         {CategoryIR, 0.8},
+	"amended_large_shareholding_report": {CategoryIR, 0.7},
+}
+
+func (c FormClassifier) Classify(d Document) (Result, bool) {
+	switch d.Source {
+	case "sec":
+		form := strings.ToUpper(strings.TrimSpace(d.DocType))
+		if form == "8-K" || form == "8-K/A" {
+			items := d.Meta["items"]
+			for _, it := range sec8KItems {
+				if strings.Contains(items, it.item) {
+					return c.result(it.formRule, form, "item "+it.item), true
+				}
+			}
+		}
+		if r, ok := secForms[form]; ok {
+			return c.result(r, form), true
+		}
+	case "edinet":
+		if r, ok := edinetDocTypes[d.DocType]; ok {
+			return c.result(r, d.DocType), true
+		}
+	}
+	return Result{}, false
+}
+
+func (c FormClassifier) result(r formRule, tags ...string) Result {
+	return Result{Category: r.category, Confidence: r.confidence, Rule: c.Name(), Tags: tags}
+}
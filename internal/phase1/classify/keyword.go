@@ -0,0 +1,157 @@
+package classify
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeywordRule assigns Category to documents that mention its keywords.
+// ASCII keywords match whole words, case-insensitively; others (Japanese)
+// match anywhere.
+type KeywordRule struct {
+	Category string   `json:"category"`
+	Keywords []string `json:"keywords"`
+}
+
+// KeywordClassifier scores each rule by its keyword hits, counting title
+// hits twice, and picks the best rule. Confidence grows with the score from
+// 0.5 to at most 0.8, below the form rules for filings of a known type.
+type KeywordClassifier struct {
+	rules []KeywordRule
+}
+
+func NewKeywordClassifier(rules []KeywordRule) *KeywordClassifier {
+	out := make([]KeywordRule, 0, len(rules))
+	for _, r := range rules {
+		kws := make([]string, 0, len(r.Keywords))
+		for _, k := range r.Keywords {
+			if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+				kws = append(kws, k)
+			}
+		}
+		out = append(out, KeywordRule{Category: r.Category, Keywords: kws})
+	}
+	return &KeywordClassifier{rules: out}
+}
+
+func (c *KeywordClassifier) Name() string { return "keyword" }
+
+func (c *KeywordClassifier) Classify(d Document) (Result, bool) {
+	title := strings.ToLower(d.Title)
+	body := strings.ToLower(d.Summary)
+	if body == "" {
+		body = strings.ToLower(d.Text[:min(len(d.Text), 2000)])
+	}
+	var (
+		best      Result
+		bestScore int
+	)
+	for _, r := range c.rules {
+		score := 0
+		var tags []string
+		for _, k := range r.Keywords {
+			hit := false
+			if containsWord(title, k) {
+				score += 2
+				hit = true
+			} else if containsWord(body, k) {
+				score++
+				hit = true
+			}
+			if hit {
+				tags = append(tags, k)
+			}
+		}
+		if score > bestScore {
+			sort.Strings(tags)
+			best = Result{Category: r.Category, Rule: c.Name(), Tags: tags}
+			bestScore = score
+		}
+	}
+	if bestScore == 0 {
+		return Result{}, false
+	}
+	best.Confidence = min(0.8, 0.4+0.1*float64(bestScore))
+	return best, true
+}
+
+// containsWord reports whether text contains kw; an ASCII keyword must not be
+// preceded or followed by a letter or digit.
+func containsWord(text, kw string) bool {
+	if !isASCII(kw) {
+		return strings.Contains(text, kw)
+	}
+	for i := 0; ; {
+		j := strings.Index(text[i:], kw)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(kw)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultKeywordRules covers English and Japanese headlines.
+func DefaultKeywordRules() []KeywordRule {
+	return []KeywordRule{
+		{CategoryEarnings, []string{
+			"earnings", "quarterly results", "financial results", "revenue", "net income", "operating income",
+			"guidance", "outlook", "eps", "profit", "fiscal year results",
+			"決算", "業績", "業績予想", "上方修正", "下方修正", "売上高", "営業利益", "純利益", "四半期",
+		}},
+		{CategoryIR, []string{
+			"dividend", "share repurchase", "buyback", "stock split", "annual general meeting", "shareholder",
+			"appoints", "chief executive", "ceo", "cfo", "acquisition", "acquire", "merger", "tender offer", "offering",
+			"配当", "自己株式", "株式分割", "株主総会", "代表取締役", "人事", "買収", "合併", "公開買付", "資本業務提携",
+		}},
+		{CategoryRegulation, []string{
+			"sec charges", "settlement", "lawsuit", "antitrust", "regulator", "regulatory", "investigation",
+			"fine", "penalty", "approval", "fda", "ftc", "doj", "recall", "sanction", "tariff",
+			"行政処分", "業務改善命令", "訴訟", "規制", "承認", "認可", "独占禁止法", "課徴金", "リコール",
+		}},
+		{CategoryTechnology, []string{
+			"launch", "unveils", "new product", "patent", "artificial intelligence", "ai", "semiconductor", "chip",
+			"software", "platform", "research and development", "r&d", "breakthrough",
+			"新製品", "発売", "特許", "研究開発", "技術", "生成ai", "半導体",
+		}},
+		{CategoryMacro, []string{
+			"interest rate", "inflation", "central bank", "federal reserve", "gdp", "recession", "currency",
+			"exchange rate", "yen", "bank of japan", "unemployment",
+			"金利", "インフレ", "日銀", "為替", "円安", "円高", "景気", "物価",
+		}},
+		{CategorySupplyDemand, []string{
+			"supply chain", "shortage", "capacity", "inventory", "production", "demand", "backlog", "orders",
+			"plant", "factory", "shipments",
+			"供給", "需要", "在庫", "生産", "受注", "工場", "増産", "減産", "出荷",
+		}},
+		{CategoryCompetition, []string{
+			"competitor", "competition", "market share", "rival", "price war", "partnership", "alliance",
+			"競合", "競争", "シェア", "提携", "参入",
+		}},
+		{CategoryPriceAction, []string{
+			"shares rose", "shares fell", "stock jumps", "stock falls", "52-week", "all-time high", "downgrade",
+			"upgrade", "price target", "short seller", "trading halt",
+			"株価", "急騰", "急落", "ストップ高", "ストップ安", "格下げ", "格上げ", "目標株価",
+		}},
+	}
+}
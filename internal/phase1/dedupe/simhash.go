@@ -0,0 +1,99 @@
+// Package dedupe fingerprints document text so that near-duplicates, such as
+// the same release fetched from an IR feed and an 8-K exhibit, can be
+// grouped.
+package dedupe
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxDistance is the largest Hamming distance between the simhashes of
+	// two near-duplicates. Split into eight 8-bit bands, two such hashes
+	// share at least one band.
+	MaxDistance = 7
+	// shingleSize is the number of tokens per shingle.
+	shingleSize = 3
+	// minShingles is the fewest shingles a text needs to be fingerprinted;
+	// shorter texts (titles, one-line listings) collide too easily.
+	minShingles = 24
+)
+
+// Simhash returns the 64-bit simhash of the shingles of text. Latin words
+// and individual CJK characters are tokens, compared case- and
+// width-insensitively. It reports false when the text is too short to be
+// fingerprinted reliably.
+func Simhash(text string) (uint64, bool) {
+	tokens := tokenize(text)
+	n := len(tokens) - shingleSize + 1
+	if n < minShingles {
+		return 0, false
+	}
+	var v [64]int
+	h := fnv.New64a()
+	for i := 0; i < n; i++ {
+		h.Reset()
+		for _, t := range tokens[i : i+shingleSize] {
+			h.Write([]byte(t))
+			h.Write([]byte{0})
+		}
+		x := h.Sum64()
+		for b := 0; b < 64; b++ {
+			if x&(1<<b) != 0 {
+				v[b]++
+			} else {
+				v[b]--
+			}
+		}
+	}
+	var out uint64
+	for b := 0; b < 64; b++ {
+		if v[b] > 0 {
+			out |= 1 << b
+		}
+	}
+	return out, true
+}
+
+// Distance is the number of bits in which two simhashes differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Near reports whether two simhashes belong to near-duplicate texts.
+func Near(a, b uint64) bool {
+	return Distance(a, b) <= MaxDistance
+}
+
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		if r >= '！' && r <= '～' {
+			r -= 0xfee0
+		}
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,40 @@
+package dedupe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSimhash(t *testing.T) {
+	hash := func(name string) uint64 {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, ok := Simhash(string(b))
+		if !ok {
+			t.Fatalf("%s: not fingerprinted", name)
+		}
+		return h
+	}
+	cases := []struct {
+		a, b string
+		near bool
+	}{
+		{"release_ir.txt", "release_8k.txt", true},
+		{"tanshin.txt", "tanshin_tdnet.txt", true},
+		{"release_ir.txt", "release_other.txt", false},
+		{"release_ir.txt", "tanshin.txt", false},
+	}
+	for _, tc := range cases {
+		a, b := hash(tc.a), hash(tc.b)
+		if got := Near(a, b); got != tc.near {
+			t.Errorf("%s vs %s: distance %d, near=%v", tc.a, tc.b, Distance(a, b), got)
+		}
+	}
+	if _, ok := Simhash("Example Corp 8-K 2024-10-30"); ok {
+		t.Error("title fingerprinted")
+	}
+}
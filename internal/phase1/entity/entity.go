@@ -0,0 +1,296 @@
+// Package entity resolves the companies, tickers, securities codes and
+// themes mentioned in a document to universe items.
+package entity
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"investment_committee/internal/phase1/extract"
+)
+
+// DefaultMinScore is the score a link needs to be returned.
+const DefaultMinScore = 0.5
+
+// scanLimit bounds the runes of body text that are scanned.
+const scanLimit = 20000
+
+// Match scores. A link combines the best match of each kind, and each
+// keyword, as independent evidence: 1 - Π(1 - score).
+const (
+	scoreQualifiedTicker = 0.95 // NASDAQ: AAPL, 東証: 7203
+	scoreCashtag         = 0.9  // $AAPL
+	scoreBareTicker      = 0.6  // AAPL
+	scoreCode            = 0.9  // 証券コード 7203, 7203.T
+	scoreParenCode       = 0.85 // トヨタ自動車(7203)
+	scoreFullName        = 0.9  // toyota motor corporation
+	scoreCoreName        = 0.8  // toyota motor
+	scoreBrandName       = 0.7  // sony, for sony group corporation
+	scoreKeyword         = 0.55
+	fuzzyFactor          = 0.8
+	fuzzyMinSimilarity   = 0.85
+	titleBonus           = 0.05
+)
+
+// Item is a universe item documents can be linked to.
+type Item struct {
+	ID         string
+	EntityType string
+	EntityID   string
+	Name       string
+	Keywords   []string
+}
+
+// Link ties a document to a universe item. Evidence lists the matches that
+// produced Score, such as "ticker:AAPL" or "name:トヨタ自動車".
+type Link struct {
+	UniverseItemID string   `json:"universe_item_id"`
+	EntityType     string   `json:"entity_type"`
+	EntityID       string   `json:"entity_id"`
+	Score          float64  `json:"score"`
+	Evidence       []string `json:"evidence"`
+}
+
+// Resolver links documents to a fixed set of universe items.
+type Resolver struct {
+	items []item
+	// MinScore is the score below which links are dropped; zero means
+	// DefaultMinScore.
+	MinScore float64
+}
+
+// item is an Item prepared for matching.
+type item struct {
+	Item
+	tickers  []string // upper case, matched in the original text
+	codes    []string // four-character securities codes
+	names    []variant
+	keywords []string // folded
+}
+
+// variant is a folded spelling of an item's name.
+type variant struct {
+	text   string
+	score  float64
+	tokens []string // set for Latin names, which are fuzzy matched
+}
+
+// NewResolver prepares items for matching. The name of an item may list
+// several spellings separated by "/" or "|", or in parentheses, such as
+// "Toyota Motor Corporation (トヨタ自動車株式会社)".
+func NewResolver(items []Item) *Resolver {
+	r := &Resolver{items: make([]item, 0, len(items))}
+	for _, it := range items {
+		p := item{Item: it}
+		if it.EntityType == "ticker" {
+			id := strings.ToUpper(strings.TrimSpace(it.EntityID))
+			if code, ok := secCode(id); ok {
+				p.codes = append(p.codes, code)
+			} else if id != "" {
+				p.tickers = append(p.tickers, id)
+			}
+		}
+		p.names = nameVariants(it.Name)
+		for _, kw := range it.Keywords {
+			if kw = fold(kw); kw != "" {
+				p.keywords = append(p.keywords, kw)
+			}
+		}
+		r.items = append(r.items, p)
+	}
+	return r
+}
+
+// Resolve returns the links of a document with the given title and text,
+// best first. Matches in the title raise the score of a link.
+func (r *Resolver) Resolve(title, text string) []Link {
+	if r == nil || len(r.items) == 0 {
+		return nil
+	}
+	if rs := []rune(text); len(rs) > scanLimit {
+		text = string(rs[:scanLimit])
+	}
+	title = extract.Normalize(title)
+	text = extract.Normalize(text)
+	titleDoc := newDocument(title)
+	bodyDoc := newDocument(text)
+	minScore := r.MinScore
+	if minScore <= 0 {
+		minScore = DefaultMinScore
+	}
+
+	var links []Link
+	for _, it := range r.items {
+		m := matches{}
+		inTitle := it.match(titleDoc, m)
+		it.match(bodyDoc, m)
+		if len(m) == 0 {
+			continue
+		}
+		score := m.score()
+		if inTitle {
+			score = min(1, score+titleBonus)
+		}
+		score = float64(int(score*100+0.5)) / 100
+		if score < minScore {
+			continue
+		}
+		links = append(links, Link{
+			UniverseItemID: it.ID,
+			EntityType:     it.EntityType,
+			EntityID:       it.EntityID,
+			Score:          score,
+			Evidence:       m.evidence(),
+		})
+	}
+	sort.SliceStable(links, func(i, j int) bool { return links[i].Score > links[j].Score })
+	return links
+}
+
+// matches holds the best score per kind of evidence ("ticker", "code",
+// "name", "keyword:<kw>") and the evidence for it.
+type matches map[string]scored
+
+type scored struct {
+	score    float64
+	evidence string
+}
+
+func (m matches) add(kind string, score float64, evidence string) {
+	if cur, ok := m[kind]; !ok || score > cur.score {
+		m[kind] = scored{score, evidence}
+	}
+}
+
+func (m matches) score() float64 {
+	miss := 1.0
+	for _, s := range m {
+		miss *= 1 - s.score
+	}
+	return 1 - miss
+}
+
+func (m matches) evidence() []string {
+	out := make([]string, 0, len(m))
+	for _, s := range m {
+		out = append(out, s.evidence)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// match adds the matches of it in d to m and reports whether there were any.
+func (it item) match(d document, m matches) bool {
+	found := false
+	add := func(kind string, score float64, evidence string) {
+		m.add(kind, score, evidence)
+		found = true
+	}
+	for _, t := range it.tickers {
+		switch {
+		case d.qualified[t]:
+			add("ticker", scoreQualifiedTicker, "ticker:"+t)
+		case d.cashtags[t]:
+			add("ticker", scoreCashtag, "ticker:$"+t)
+		case len(t) >= 3 && containsWord(d.text, t):
+			// One- and two-letter tickers are too often ordinary words.
+			add("ticker", scoreBareTicker, "ticker:"+t)
+		}
+	}
+	for _, c := range it.codes {
+		if s, ok := d.codes[c]; ok {
+			add("code", s, "code:"+c)
+		}
+	}
+	nameFound := false
+	for _, v := range it.names {
+		if containsWord(d.folded, v.text) {
+			add("name", v.score, "name:"+v.text)
+			nameFound = true
+		}
+	}
+	if !nameFound {
+		for _, v := range it.names {
+			if w, sim := d.fuzzy(v); sim > 0 {
+				add("name", v.score*sim*fuzzyFactor, "fuzzy:"+w)
+			}
+		}
+	}
+	for _, kw := range it.keywords {
+		if containsWord(d.folded, kw) {
+			add("keyword:"+kw, scoreKeyword, "keyword:"+kw)
+		}
+	}
+	return found
+}
+
+var (
+	qualifiedTickerRe = regexp.MustCompile(`(?i)(?:\b(?:nasdaq|nyse(?: american| arca)?|amex|otc(?:qx|qb)?|tsx|lse|tse|tyo|jpx)|東証(?:プライム|スタンダード|グロース)?)\s*:\s*([A-Za-z0-9][A-Za-z0-9.\-]{0,5})\b`)
+	cashtagRe         = regexp.MustCompile(`\$([A-Za-z][A-Za-z.]{0,5})\b`)
+	codeRe            = regexp.MustCompile(`(?i)(?:証券コード|コード番号|コード|\bcode)\s*[:：]?\s*(\d{3}[0-9A-Z])\b`)
+	suffixCodeRe      = regexp.MustCompile(`\b(\d{3}[0-9A-Z])(?:\.T| JP)\b`)
+	parenCodeRe       = regexp.MustCompile(`\((\d{3}[0-9A-Z])\)`)
+)
+
+// document is one part of a document prepared for matching.
+type document struct {
+	text      string // normalized, original case
+	folded    string
+	tokens    []string
+	qualified map[string]bool
+	cashtags  map[string]bool
+	codes     map[string]float64
+}
+
+func newDocument(text string) document {
+	d := document{
+		text:      text,
+		folded:    fold(text),
+		qualified: map[string]bool{},
+		cashtags:  map[string]bool{},
+		codes:     map[string]float64{},
+	}
+	d.tokens = latinTokens(d.folded)
+	for _, m := range qualifiedTickerRe.FindAllStringSubmatch(text, -1) {
+		id := strings.ToUpper(m[1])
+		if code, ok := secCode(id); ok {
+			d.codes[code] = max(d.codes[code], scoreQualifiedTicker)
+		} else {
+			d.qualified[id] = true
+		}
+	}
+	for _, m := range cashtagRe.FindAllStringSubmatch(text, -1) {
+		d.cashtags[strings.ToUpper(m[1])] = true
+	}
+	for _, re := range []*regexp.Regexp{codeRe, suffixCodeRe} {
+		for _, m := range re.FindAllStringSubmatch(text, -1) {
+			d.codes[m[1]] = max(d.codes[m[1]], scoreCode)
+		}
+	}
+	for _, m := range parenCodeRe.FindAllStringSubmatch(text, -1) {
+		d.codes[m[1]] = max(d.codes[m[1]], scoreParenCode)
+	}
+	return d
+}
+
+// secCode returns the four-character form of a Japanese securities code
+// such as 7203, 72030 (with check digit) or 130A.
+func secCode(id string) (string, bool) {
+	if len(id) != 4 && len(id) != 5 {
+		return "", false
+	}
+	for i, c := range id {
+		digit := c >= '0' && c <= '9'
+		if (i < 3 && !digit) || (!digit && (c < 'A' || c > 'Z')) {
+			return "", false
+		}
+	}
+	if len(id) == 5 {
+		if id[4] != '0' {
+			return "", false
+		}
+		id = id[:4]
+	}
+	return id, true
+}
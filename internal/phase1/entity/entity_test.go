@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	r := NewResolver([]Item{
+		{ID: "u-aapl", EntityType: "ticker", EntityID: "AAPL", Name: "Apple Inc."},
+		{ID: "u-msft", EntityType: "ticker", EntityID: "MSFT", Name: "Microsoft Corporation"},
+		{ID: "u-toyota", EntityType: "ticker", EntityID: "72030", Name: "Toyota Motor Corporation (トヨタ自動車株式会社)"},
+		{ID: "u-sony", EntityType: "ticker", EntityID: "6758", Name: "ソニーグループ株式会社 / Sony Group Corporation"},
+		{ID: "u-ai", EntityType: "theme", EntityID: "generative-ai", Name: "Generative AI", Keywords: []string{"large language model", "生成AI"}},
+		{ID: "u-f", EntityType: "ticker", EntityID: "F", Name: "Ford Motor Company"},
+	})
+	cases := []struct {
+		name        string
+		title, text string
+		want        string // universe_item_id:score, best first
+	}{
+		{"press release naming three companies",
+			"Apple, Microsoft and Toyota announce partnership",
+			"Apple Inc. (NASDAQ: AAPL), Microsoft Corp. (NASDAQ: MSFT) and Toyota Motor Corporation (TSE: 7203) today announced...",
+			"u-aapl:1,u-msft:1,u-toyota:1"},
+		{"japanese release with code",
+			"トヨタ自動車(株)、決算発表",
+			"トヨタ自動車株式会社(証券コード:7203)は本日...",
+			"u-toyota:1"},
+		{"name variants",
+			"ソニー・グループ、新製品を発表", "",
+			"u-sony:0.85"},
+		{"fuzzy name",
+			"", "Shares of Toyota Motors rose after the report.",
+			"u-toyota:0.59"},
+		{"theme keywords",
+			"", "A new large language model and 生成ＡＩ tools were shown.",
+			"u-ai:0.8"},
+		{"single-letter ticker needs a qualifier",
+			"", "F is for fun. Not a mention.",
+			""},
+		{"cashtag", "", "Traders bought $F calls.", "u-f:0.9"},
+		{"year is not a code", "", "In 2024 revenue grew; see note 7203 for details.", ""},
+	}
+	for _, tc := range cases {
+		var got []string
+		for _, l := range r.Resolve(tc.title, tc.text) {
+			got = append(got, l.UniverseItemID+":"+strconv.FormatFloat(l.Score, 'f', -1, 64))
+			if len(l.Evidence) == 0 {
+				t.Errorf("%s: %s has no evidence", tc.name, l.UniverseItemID)
+			}
+		}
+		if s := strings.Join(got, ","); s != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, s, tc.want)
+		}
+	}
+}
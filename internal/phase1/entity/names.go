@@ -0,0 +1,252 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"investment_committee/internal/phase1/extract"
+)
+
+const (
+	// fuzzyTokens bounds the Latin words of a document that are fuzzy
+	// matched against names.
+	fuzzyTokens = 3000
+	// fuzzyMinLength is the shortest name, in bytes, that is fuzzy matched.
+	fuzzyMinLength = 5
+)
+
+// legalSuffixes and legalPrefixes are the legal forms dropped from company
+// names to get the name a document is likely to use: "Toyota Motor
+// Corporation" is usually "Toyota Motor".
+var (
+	legalSuffixes = []string{
+		"incorporated", "inc", "corporation", "corp", "company", "co", "limited", "ltd", "llc",
+		"plc", "ag", "sa", "nv", "se", "kk", "株式会社", "有限会社", "合同会社",
+	}
+	legalPrefixes = []string{"the", "株式会社", "有限会社", "合同会社"}
+	// groupSuffixes are dropped last, for the brand of a holding company:
+	// "Sony Group" is also "Sony".
+	groupSuffixes = []string{"holdings", "holding", "hldgs", "hd", "group", "ホールディングス", "グループ"}
+)
+
+// nameVariants returns the folded spellings of a universe item's name: each
+// listed spelling in full, without its legal form and without a group or
+// holdings suffix.
+func nameVariants(name string) []variant {
+	name = extract.Normalize(name)
+	for _, s := range []string{"(株)", "㈱", "(有)", "㈲"} {
+		name = strings.ReplaceAll(name, s, " ")
+	}
+	var parts []string
+	for _, p := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '|' }) {
+		for {
+			open := strings.IndexByte(p, '(')
+			end := strings.IndexByte(p, ')')
+			if open < 0 || end < open {
+				break
+			}
+			parts = append(parts, p[open+1:end])
+			p = p[:open] + " " + p[end+1:]
+		}
+		parts = append(parts, p)
+	}
+
+	var out []variant
+	seen := map[string]bool{}
+	add := func(text string, score float64) {
+		if seen[text] || !longEnough(text) {
+			return
+		}
+		seen[text] = true
+		v := variant{text: text, score: score}
+		if isASCII(text) && len(text) >= fuzzyMinLength {
+			v.tokens = strings.Fields(text)
+		}
+		out = append(out, v)
+	}
+	for _, p := range parts {
+		full := fold(p)
+		add(full, scoreFullName)
+		core := trimAffixes(full, legalPrefixes, legalSuffixes)
+		add(core, scoreCoreName)
+		add(trimAffixes(core, nil, groupSuffixes), scoreBrandName)
+	}
+	return out
+}
+
+// longEnough reports whether a name is specific enough to be matched: three
+// Latin characters or two others.
+func longEnough(s string) bool {
+	if isASCII(s) {
+		return len(s) >= 3
+	}
+	return utf8.RuneCountInString(s) >= 2
+}
+
+// trimAffixes removes the prefixes and suffixes from a folded name, as whole
+// words for Latin ones, repeatedly ("co ltd").
+func trimAffixes(s string, prefixes, suffixes []string) string {
+	for changed := true; changed; {
+		changed = false
+		for _, p := range prefixes {
+			if rest, ok := cutAffix(s, p, true); ok {
+				s, changed = rest, true
+			}
+		}
+		for _, x := range suffixes {
+			if rest, ok := cutAffix(s, x, false); ok {
+				s, changed = rest, true
+			}
+		}
+	}
+	return s
+}
+
+func cutAffix(s, affix string, prefix bool) (string, bool) {
+	sep := ""
+	if isASCII(affix) {
+		sep = " "
+	}
+	if prefix {
+		rest, ok := strings.CutPrefix(s, affix+sep)
+		return strings.TrimSpace(rest), ok && strings.TrimSpace(rest) != ""
+	}
+	rest, ok := strings.CutSuffix(s, sep+affix)
+	return strings.TrimSpace(rest), ok && strings.TrimSpace(rest) != ""
+}
+
+// fold lower-cases s and reduces the spelling differences of names:
+// punctuation becomes a space, except in abbreviations ("Inc.", "U.S."),
+// "&" becomes "and", the katakana middle dot and spaces between Japanese
+// words are dropped and small ヶ and ヵ become ケ and カ. s is expected to be
+// normalized.
+func fold(s string) string {
+	s = strings.ToLower(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		switch {
+		case r == '.' || r == '\'' || r == '’' || r == '・' || r == '･':
+			continue
+		case r == 'ヶ':
+			r = 'ケ'
+		case r == 'ヵ':
+			r = 'カ'
+		case r == '&':
+			b.WriteString(" and")
+			space = true
+			continue
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 && !(r >= utf8.RuneSelf && lastRune(b.String()) >= utf8.RuneSelf) {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// latinTokens splits folded text into its Latin words.
+func latinTokens(folded string) []string {
+	tokens := strings.FieldsFunc(folded, func(r rune) bool {
+		return r >= utf8.RuneSelf || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	return tokens[:min(len(tokens), fuzzyTokens)]
+}
+
+// fuzzy finds the run of words in d most similar to a Latin name, such as
+// "toyota motors" for "toyota motor". It returns the run and its similarity,
+// or a zero similarity when no run is similar enough.
+func (d document) fuzzy(v variant) (string, float64) {
+	n := len(v.tokens)
+	if n == 0 {
+		return "", 0
+	}
+	var (
+		best    string
+		bestSim float64
+	)
+	for i := 0; i+n <= len(d.tokens); i++ {
+		if d.tokens[i][0] != v.text[0] {
+			continue
+		}
+		w := strings.Join(d.tokens[i:i+n], " ")
+		longest := max(len(w), len(v.text))
+		if diff := len(w) - len(v.text); diff*5 > longest || -diff*5 > longest {
+			continue
+		}
+		sim := 1 - float64(levenshtein(w, v.text))/float64(longest)
+		if sim >= fuzzyMinSimilarity && sim > bestSim {
+			best, bestSim = w, sim
+		}
+	}
+	return best, bestSim
+}
+
+// levenshtein is the edit distance of two ASCII strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// containsWord reports whether text contains w; an ASCII w must not be
+// preceded or followed by a letter or digit.
+func containsWord(text, w string) bool {
+	if w == "" {
+		return false
+	}
+	if !isASCII(w) {
+		return strings.Contains(text, w)
+	}
+	for i := 0; ; {
+		j := strings.Index(text[i:], w)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(w)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
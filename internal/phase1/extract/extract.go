@@ -0,0 +1,253 @@
+// Package extract turns downloaded filings and news pages into clean text, a
+// short extractive summary and a language code.
+package extract
+
+import (
+	"bytes"
+	"html"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// MaxTextBytes caps the stored text of one document.
+const MaxTextBytes = 1 << 20
+
+type Result struct {
+	Text     string
+	Summary  string
+	Language string
+}
+
+// Process extracts the text of body (HTML or plain text), normalizes it and
+// summarizes it.
+func Process(body []byte, contentType string) Result {
+	s := decode(body, contentType)
+	if isHTML(s, contentType) {
+		s = HTMLText(s)
+	}
+	text := Normalize(s)
+	if len(text) > MaxTextBytes {
+		cut := MaxTextBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	lang := DetectLanguage(text)
+	return Result{Text: text, Summary: Summarize(text, lang, defaultSummarySentences), Language: lang}
+}
+
+// decode returns body as UTF-8. It is decoded from the charset named by
+// contentType or, failing that, by the document's <meta charset> or XML
+// declaration (Japanese filings and IR pages often use Shift_JIS or EUC-JP).
+// Without a known charset, invalid UTF-8 is read as Latin-1, the declared
+// charset of most older EDGAR documents.
+func decode(body []byte, contentType string) string {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	if label := declaredCharset(body, contentType); label != "" {
+		if enc, err := htmlindex.Get(label); err == nil {
+			if name, _ := htmlindex.Name(enc); name != "utf-8" {
+				if b, err := enc.NewDecoder().Bytes(body); err == nil {
+					return string(b)
+				}
+			}
+		}
+	}
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	rs := make([]rune, len(body))
+	for i, b := range body {
+		rs[i] = rune(b)
+	}
+	return string(rs)
+}
+
+var (
+	metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w.:-]+)`)
+	xmlEncoding = regexp.MustCompile(`(?i)<\?xml[^>]+encoding\s*=\s*["']([\w.:-]+)`)
+)
+
+// declaredCharset is the charset parameter of contentType, or else the one
+// declared in the first kilobyte of body.
+func declaredCharset(body []byte, contentType string) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return params["charset"]
+	}
+	head := body[:min(len(body), 1024)]
+	for _, re := range []*regexp.Regexp{metaCharset, xmlEncoding} {
+		if m := re.FindSubmatch(head); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+func isHTML(s, contentType string) bool {
+	ct := strings.ToLower(contentType)
+	if strings.Contains(ct, "html") || strings.Contains(ct, "xml") {
+		return true
+	}
+	head := strings.ToLower(s[:min(len(s), 1024)])
+	for _, marker := range []string{"<!doctype html", "<html", "<body", "<p>", "<div"} {
+		if strings.Contains(head, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedElements are dropped with their content: scripts and styles, page
+// chrome (navigation, headers, footers, forms) and the hidden header of
+// inline XBRL documents.
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"head": true, "nav": true, "header": true, "footer": true, "aside": true,
+	"form": true, "iframe": true, "button": true, "ix:header": true,
+}
+
+// blockElements end a line of text.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "ul": true, "ol": true, "tr": true,
+	"table": true, "section": true, "article": true, "main": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"pre": true, "dd": true, "dt": true, "dl": true, "hr": true, "title": true,
+}
+
+// HTMLText returns the visible text of an HTML document, one line per block
+// element, with entities decoded.
+func HTMLText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) / 2)
+	text := func(t string) { b.WriteString(html.UnescapeString(t)) }
+	lower := asciiLower(s)
+
+	for i := 0; i < len(s); {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			text(s[i:])
+			break
+		}
+		text(s[i : i+lt])
+		i += lt
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+		end := tagEnd(s, i)
+		if end < 0 {
+			text(s[i:])
+			break
+		}
+		name, closing, selfClosing := tagName(s[i+1 : end])
+		i = end + 1
+		switch {
+		case name == "":
+		case skippedElements[name] && !closing && !selfClosing:
+			i = skipElement(s, lower, i, name)
+		case blockElements[name]:
+			b.WriteByte('\n')
+		case name == "td" || name == "th":
+			b.WriteByte('\t')
+		}
+	}
+	return b.String()
+}
+
+// tagEnd returns the index of the '>' closing the tag that starts at i,
+// ignoring '>' inside quoted attribute values.
+func tagEnd(s string, i int) int {
+	var quote byte
+	for j := i + 1; j < len(s); j++ {
+		c := s[j]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return j
+		}
+	}
+	return -1
+}
+
+func tagName(tag string) (name string, closing, selfClosing bool) {
+	if strings.HasPrefix(tag, "/") {
+		closing = true
+		tag = tag[1:]
+	}
+	selfClosing = strings.HasSuffix(tag, "/")
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end < 0 {
+		end = len(tag)
+	}
+	name = strings.ToLower(tag[:end])
+	if name != "" && (name[0] == '!' || name[0] == '?') {
+		name = ""
+	}
+	return name, closing, selfClosing
+}
+
+// rawTextElements hold text that is not markup, so only their end tag is
+// looked for.
+var rawTextElements = map[string]bool{"script": true, "style": true, "noscript": true, "template": true}
+
+// skipElement returns the index after the end tag of the element name whose
+// content starts at i, counting nested elements of the same name. lower is s
+// with ASCII letters lowercased.
+func skipElement(s, lower string, i int, name string) int {
+	if rawTextElements[name] {
+		end := strings.Index(lower[i:], "</"+name)
+		if end < 0 {
+			return len(s)
+		}
+		if gt := tagEnd(s, i+end); gt >= 0 {
+			return gt + 1
+		}
+		return len(s)
+	}
+	depth := 1
+	for depth > 0 {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			return len(s)
+		}
+		i += lt
+		end := tagEnd(s, i)
+		if end < 0 {
+			return len(s)
+		}
+		n, closing, selfClosing := tagName(s[i+1 : end])
+		if n == name && !selfClosing {
+			if closing {
+				depth--
+			} else {
+				depth++
+			}
+		}
+		i = end + 1
+	}
+	return i
+}
+
+// asciiLower lowercases A-Z only, keeping byte offsets equal to s.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,96 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestProcessHTML(t *testing.T) {
+	page := `<!DOCTYPE html><html><head><title>Example</title><style>p{color:red}</style></head>
+<body><nav><a href="/">Home</a> | <a href="/ir">IR</a></nav>
+<script>if (a < b) { document.write("<p>x</p>"); }</script>
+<div style="display:none"><ix:header><ix:hidden>dei:EntityName</ix:hidden></ix:header></div>
+<h1>Example Corp Reports Record Quarter</h1>
+<!-- generated -->
+<p>Example Corp today reported revenue of $1.2 billion for the fourth quarter, up 12% from a year ago, driven by data center demand.</p>
+<p>Operating margin expanded to 31% as data center demand offset weaker consumer sales in Europe.</p>
+<table><tr><td>Revenue</td><td>1,200</td></tr></table>
+<p>The company expects first quarter revenue between $1.25 billion and $1.3 billion on continued data center demand.</p>
+<footer>&copy; Example Corp &amp; subsidiaries</footer></body></html>`
+
+	res := Process([]byte(page), "text/html; charset=utf-8")
+	for _, gone := range []string{"Home", "color:red", "document.write", "EntityName", "generated", "subsidiaries"} {
+		if strings.Contains(res.Text, gone) {
+			t.Errorf("text contains %q:\n%s", gone, res.Text)
+		}
+	}
+	if !strings.HasPrefix(res.Text, "Example Corp Reports Record Quarter\n") {
+		t.Errorf("text starts %q", res.Text[:min(len(res.Text), 60)])
+	}
+	if !strings.Contains(res.Text, "Revenue 1,200") {
+		t.Errorf("table row not kept on one line:\n%s", res.Text)
+	}
+	if res.Language != "en" {
+		t.Errorf("language = %q", res.Language)
+	}
+	if !strings.HasPrefix(res.Summary, "Example Corp today reported revenue") || strings.Contains(res.Summary, "1,200") {
+		t.Errorf("summary = %q", res.Summary)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"ＡＢＣ　１２３％", "ABC 123%"},
+		{"ｶﾞｿﾘﾝ ﾊﾟﾝ ｳﾞｧ", "ガソリン パン ヴァ"},
+		{"  a \t b\u200b  \n\n\n\nc\r\n", "a b\n\nc"},
+	}
+	for _, tc := range cases {
+		if got := Normalize(tc.in); got != tc.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestDetectLanguageAndSummary(t *testing.T) {
+	ja := "トヨタ自動車は本日、２０２５年３月期の連結業績予想を上方修正しました。円安の進行により営業利益は前回予想を上回る見込みです。\n配当予想に変更はありません。"
+	res := Process([]byte(ja), "text/plain")
+	if res.Language != "ja" {
+		t.Fatalf("language = %q", res.Language)
+	}
+	if !strings.Contains(res.Text, "2025年3月期") {
+		t.Errorf("full-width digits not folded: %q", res.Text)
+	}
+	if !strings.HasPrefix(res.Summary, "トヨタ自動車は本日") {
+		t.Errorf("summary = %q", res.Summary)
+	}
+	for in, want := range map[string]string{
+		"公司今天发布了年度财务报告": "zh",
+		"12,345 67,890": "und",
+	} {
+		if got := DetectLanguage(in); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProcessJapaneseCharsets(t *testing.T) {
+	const text = "当社は本日、通期業績予想を上方修正いたしました。"
+	page := `<html><head><meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS"></head><body><p>` + text + `</p></body></html>`
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := Process([]byte(sjis), "text/html"); !strings.Contains(res.Text, text) || res.Language != "ja" {
+		t.Errorf("meta charset: text %q, language %q", res.Text, res.Language)
+	}
+
+	eucjp, err := japanese.EUCJP.NewEncoder().String("<p>" + text + "</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := Process([]byte(eucjp), "text/html; charset=EUC-JP"); !strings.Contains(res.Text, text) {
+		t.Errorf("content type charset: text %q", res.Text)
+	}
+}
@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize folds character widths the way NFKC does for Japanese text
+// (full-width ASCII and the ideographic space to ASCII, half-width katakana
+// to full-width), drops zero-width characters, collapses runs of spaces and
+// keeps at most one blank line between paragraphs.
+func Normalize(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	line := make([]rune, 0, 256)
+	blank := 0
+	flush := func() {
+		l := strings.TrimSpace(string(line))
+		line = line[:0]
+		if l == "" {
+			if b.Len() > 0 {
+				blank++
+			}
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+			if blank > 0 {
+				b.WriteByte('\n')
+			}
+		}
+		blank = 0
+		b.WriteString(l)
+	}
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\n' || r == '\r' || r == '\f' || r == '\v' || r == '\u2028' || r == '\u2029':
+			flush()
+			continue
+		case r == '\u200b' || r == '\u200c' || r == '\u200d' || r == '\u2060' || r == '\ufeff' || r == '\u00ad':
+			continue
+		case r == '\u3000' || r == '\u00a0':
+			r = ' '
+		case r >= '\uff01' && r <= '\uff5e':
+			r -= 0xfee0
+		case r >= '\uff61' && r <= '\uff9f':
+			r = halfwidthKana(rs, &i)
+		case unicode.IsSpace(r):
+			r = ' '
+		case unicode.IsControl(r):
+			continue
+		}
+		if r == ' ' && (len(line) == 0 || line[len(line)-1] == ' ') {
+			continue
+		}
+		line = append(line, r)
+	}
+	flush()
+	return b.String()
+}
+
+// halfwidthKatakana maps U+FF61..U+FF9F to their full-width forms.
+var halfwidthKatakana = []rune("。「」、・ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン゛゜")
+
+// halfwidthKana converts the half-width katakana at rs[*i], combining it
+// with a following voiced or semi-voiced sound mark.
+func halfwidthKana(rs []rune, i *int) rune {
+	r := halfwidthKatakana[rs[*i]-'\uff61']
+	if *i+1 >= len(rs) {
+		return r
+	}
+	switch rs[*i+1] {
+	case '\uff9e': // dakuten
+		if r == 'ウ' {
+			*i++
+			return 'ヴ'
+		}
+		if strings.ContainsRune("カキクケコサシスセソタチツテトハヒフヘホ", r) {
+			*i++
+			return r + 1
+		}
+	case '\uff9f': // handakuten
+		if strings.ContainsRune("ハヒフヘホ", r) {
+			*i++
+			return r + 2
+		}
+	}
+	return r
+}
@@ -0,0 +1,235 @@
+package extract
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultSummarySentences = 3
+	maxSummaryRunes         = 600
+)
+
+// DetectLanguage returns "ja" for text with kana, "zh" for Han text without
+// kana, "en" for Latin text with English function words and "und"
+// otherwise.
+func DetectLanguage(text string) string {
+	var kana, han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < utf8.RuneSelf && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	switch {
+	case kana > 0 && (kana+han)*4 >= latin:
+		return "ja"
+	case han > 0 && han*4 >= latin:
+		return "zh"
+	case latin == 0:
+		return "und"
+	}
+	words := latinWords(text)
+	hits := 0
+	for _, w := range words {
+		if englishStopwords[w] {
+			hits++
+		}
+	}
+	if len(words) > 0 && hits*20 >= len(words) {
+		return "en"
+	}
+	return "und"
+}
+
+// Summarize picks up to n sentences of text that best cover its frequent
+// terms, favouring early sentences, and returns them in document order.
+// Table rows, navigation remnants and very short or long sentences are not
+// candidates.
+func Summarize(text, lang string, n int) string {
+	sentences := splitSentences(text, lang)
+	if len(sentences) == 0 || n <= 0 {
+		return ""
+	}
+
+	freq := map[string]int{}
+	terms := make([][]string, len(sentences))
+	for i, s := range sentences {
+		terms[i] = sentenceTerms(s)
+		for _, t := range terms[i] {
+			freq[t]++
+		}
+	}
+
+	type scored struct {
+		idx   int
+		score float64
+	}
+	var cands []scored
+	for i, s := range sentences {
+		if !summaryCandidate(s, lang) || len(terms[i]) == 0 {
+			continue
+		}
+		seen := map[string]bool{}
+		var score float64
+		for _, t := range terms[i] {
+			if !seen[t] {
+				seen[t] = true
+				score += math.Log1p(float64(freq[t]))
+			}
+		}
+		score /= math.Sqrt(float64(len(terms[i])))
+		// Filings and press releases state the point first.
+		score *= 1 + 1/float64(2+i)
+		cands = append(cands, scored{i, score})
+	}
+	if len(cands) == 0 {
+		return truncateRunes(sentences[0], maxSummaryRunes)
+	}
+	sort.SliceStable(cands, func(a, b int) bool { return cands[a].score > cands[b].score })
+	if len(cands) > n {
+		cands = cands[:n]
+	}
+	sort.Slice(cands, func(a, b int) bool { return cands[a].idx < cands[b].idx })
+
+	sep := " "
+	if lang == "ja" || lang == "zh" {
+		sep = ""
+	}
+	parts := make([]string, len(cands))
+	for i, c := range cands {
+		parts[i] = sentences[c.idx]
+	}
+	return truncateRunes(strings.Join(parts, sep), maxSummaryRunes)
+}
+
+// splitSentences splits on line breaks, on 。！？ and on . ! ? followed by a
+// space (not after a single capital, as in initials, or common
+// abbreviations).
+func splitSentences(text, lang string) []string {
+	var out []string
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		rs := []rune(line)
+		start := 0
+		for i, r := range rs {
+			end := false
+			switch r {
+			case '。', '！', '？':
+				end = true
+			case '.', '!', '?':
+				end = i+1 < len(rs) && rs[i+1] == ' ' && !abbreviation(rs[start:i])
+			}
+			if end {
+				add(string(rs[start : i+1]))
+				start = i + 1
+			}
+		}
+		add(string(rs[start:]))
+	}
+	return out
+}
+
+var abbreviations = map[string]bool{
+	"inc": true, "corp": true, "co": true, "ltd": true, "no": true, "vs": true,
+	"mr": true, "ms": true, "dr": true, "u.s": true, "e.g": true, "i.e": true, "approx": true,
+}
+
+func abbreviation(before []rune) bool {
+	i := len(before)
+	for i > 0 && !unicode.IsSpace(before[i-1]) {
+		i--
+	}
+	w := strings.ToLower(string(before[i:]))
+	if utf8.RuneCountInString(w) == 1 {
+		return true
+	}
+	return abbreviations[w]
+}
+
+// summaryCandidate rejects fragments: too short or long sentences, and lines
+// that are mostly digits or separators such as table rows and menus.
+func summaryCandidate(s, lang string) bool {
+	n := utf8.RuneCountInString(s)
+	minRunes := 40
+	if lang == "ja" || lang == "zh" {
+		minRunes = 15
+	}
+	if n < minRunes || n > 400 {
+		return false
+	}
+	letters := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters*10 >= n*6 && strings.Count(s, "|") < 2
+}
+
+// sentenceTerms returns the lowercased content words of Latin text and the
+// character bigrams of Han and katakana runs, which stand in for words in
+// Japanese and Chinese.
+func sentenceTerms(s string) []string {
+	var out []string
+	for _, w := range latinWords(s) {
+		if len(w) >= 3 && !englishStopwords[w] {
+			out = append(out, w)
+		}
+	}
+	var run []rune
+	flush := func() {
+		for i := 0; i+1 < len(run); i++ {
+			out = append(out, string(run[i:i+2]))
+		}
+		run = run[:0]
+	}
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Katakana, r) {
+			run = append(run, r)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return out
+}
+
+func latinWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r >= utf8.RuneSelf || !(unicode.IsLetter(r) || r == '\'')
+	})
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	rs := []rune(s)
+	return strings.TrimSpace(string(rs[:n-1])) + "…"
+}
+
+var englishStopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all also am an and any are as at be
+		because been before being below between both but by can could did do does doing down during each
+		few for from further had has have having he her here hers him his how i if in into is it its itself
+		just may me might more most must my no nor not now of off on once only or other our ours out over
+		own same shall she should so some such than that the their theirs them then there these they this
+		those through to too under until up very was we were what when where which while who whom why will
+		with would you your`) {
+		englishStopwords[w] = true
+	}
+}
@@ -0,0 +1,280 @@
+package fetcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultEDINETBaseURL = "https://api.edinet-fsa.go.jp"
+
+// edinetMaxLookbackDays caps how many dates are listed when resuming from a
+// watermark that is older than edinet_days.
+const edinetMaxLookbackDays = 31
+
+// jst is fixed rather than loaded so the fetcher works without tzdata.
+var jst = time.FixedZone("JST", 9*60*60)
+
+// edinetDocTypes maps docTypeCode to the labels stored in Document.DocType.
+var edinetDocTypes = map[string]string{
+	"030": "securities_registration",
+	"120": "annual_securities_report",
+	"130": "amended_annual_securities_report",
+	"140": "quarterly_report",
+	"150": "amended_quarterly_report",
+	"160": "semiannual_report",
+	"170": "amended_semiannual_report",
+	"180": "extraordinary_report",
+	"190": "amended_extraordinary_report",
+	"220": "share_buyback_report",
+	"350": "large_shareholding_report",
+	"360": "amended_large_shareholding_report",
+}
+
+func EDINETDocTypeLabel(code string) string {
+	if label, ok := edinetDocTypes[code]; ok {
+		return label
+	}
+	return "other"
+}
+
+type EDINETConfig struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+	Now     func() time.Time
+}
+
+type EDINETFetcher struct {
+	cfg    EDINETConfig
+	client *http.Client
+}
+
+func NewEDINETFetcher(cfg EDINETConfig) *EDINETFetcher {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultEDINETBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &EDINETFetcher{cfg: cfg, client: defaultClient(cfg.Client)}
+}
+
+func (f *EDINETFetcher) Source() string { return "edinet" }
+
+type edinetListResponse struct {
+	Metadata struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"metadata"`
+	Results []edinetDocument `json:"results"`
+}
+
+type edinetDocument struct {
+	SeqNumber        int     `json:"seqNumber"`
+	DocID            string  `json:"docID"`
+	EdinetCode       *string `json:"edinetCode"`
+	SecCode          *string `json:"secCode"`
+	FilerName        *string `json:"filerName"`
+	OrdinanceCode    *string `json:"ordinanceCode"`
+	FormCode         *string `json:"formCode"`
+	DocTypeCode      *string `json:"docTypeCode"`
+	PeriodStart      *string `json:"periodStart"`
+	PeriodEnd        *string `json:"periodEnd"`
+	SubmitDateTime   *string `json:"submitDateTime"`
+	DocDescription   *string `json:"docDescription"`
+	ParentDocID      *string `json:"parentDocID"`
+	WithdrawalStatus string  `json:"withdrawalStatus"`
+	XbrlFlag         string  `json:"xbrlFlag"`
+}
+
+func (f *EDINETFetcher) Fetch(ctx context.Context, cfg Phase1FetchConfig) ([]Document, error) {
+	max := cfg.MaxItemsPerSource
+	if max <= 0 {
+		max = defaultMaxItemsPerSource
+	}
+	days := cfg.EDINETDays
+	if days <= 0 {
+		days = 1
+	}
+	codes := map[string]struct{}{}
+	for _, c := range cfg.EDINETSecCodes {
+		if n := normalizeSecCode(c); n != "" {
+			codes[n] = struct{}{}
+		}
+	}
+	docTypes := map[string]struct{}{}
+	for _, t := range cfg.EDINETDocTypes {
+		docTypes[strings.TrimSpace(t)] = struct{}{}
+	}
+
+	// Dates are listed newest first, from the last edinet_days days, the
+	// backfill range, or back to the watermark's date, even when that is
+	// earlier than edinet_days allows (up to edinetMaxLookbackDays).
+	end := f.cfg.Now().In(jst)
+	start := end.AddDate(0, 0, -(days - 1))
+	if b := cfg.Backfill; b != nil {
+		start, end = b.From, b.To
+	} else if w := cfg.Watermark; w != nil {
+		start = w.PublishedAt.In(jst)
+		if limit := end.AddDate(0, 0, -(edinetMaxLookbackDays - 1)); start.Before(limit) {
+			start = limit
+		}
+	}
+	var out []Document
+	for day := end; day.Format(dateLayout) >= start.Format(dateLayout); day = day.AddDate(0, 0, -1) {
+		docs, err := f.fetchDate(ctx, day.Format(dateLayout))
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if cfg.Watermark != nil && !cfg.wanted(doc) {
+				continue
+			}
+			if len(codes) > 0 {
+				if _, ok := codes[normalizeSecCode(doc.Meta["sec_code"])]; !ok {
+					continue
+				}
+			}
+			if len(docTypes) > 0 {
+				if _, ok := docTypes[doc.Meta["doc_type_code"]]; !ok {
+					continue
+				}
+			}
+			out = append(out, doc)
+		}
+	}
+	return cfg.limit(out, max), nil
+}
+
+func (f *EDINETFetcher) fetchDate(ctx context.Context, date string) ([]Document, error) {
+	q := url.Values{}
+	q.Set("date", date)
+	q.Set("type", "2")
+	if f.cfg.APIKey != "" {
+		q.Set("Subscription-Key", f.cfg.APIKey)
+	}
+	var resp edinetListResponse
+	if err := getJSON(ctx, f.client, f.cfg.BaseURL+"/api/v2/documents.json?"+q.Encode(), http.Header{"Accept": {"application/json"}}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Metadata.Status != "200" {
+		return nil, fmt.Errorf("edinet: documents.json %s: status %s %s", date, resp.Metadata.Status, resp.Metadata.Message)
+	}
+
+	var out []Document
+	for _, r := range resp.Results {
+		if r.WithdrawalStatus != "" && r.WithdrawalStatus != "0" {
+			continue
+		}
+		secCode := str(r.SecCode)
+		if secCode == "" {
+			continue
+		}
+		published, err := time.ParseInLocation("2006-01-02 15:04", str(r.SubmitDateTime), jst)
+		if err != nil {
+			continue
+		}
+		code := str(r.DocTypeCode)
+		meta := map[string]string{
+			"doc_type_code": code,
+			"sec_code":      secCode,
+			"edinet_code":   str(r.EdinetCode),
+			"filer_name":    str(r.FilerName),
+			"submitted_jst": published.Format("2006-01-02T15:04:05-07:00"),
+		}
+		for k, v := range map[string]*string{
+			"ordinance_code": r.OrdinanceCode,
+			"form_code":      r.FormCode,
+			"period_start":   r.PeriodStart,
+			"period_end":     r.PeriodEnd,
+			"parent_doc_id":  r.ParentDocID,
+		} {
+			if s := str(v); s != "" {
+				meta[k] = s
+			}
+		}
+		if r.XbrlFlag == "1" {
+			meta["xbrl"] = "1"
+		}
+		out = append(out, Document{
+			DocID:       r.DocID,
+			Title:       strings.TrimSpace(str(r.FilerName) + " " + str(r.DocDescription)),
+			URL:         f.cfg.BaseURL + "/api/v2/documents/" + url.PathEscape(r.DocID) + "?type=2",
+			PublishedAt: published.UTC(),
+			Ticker:      tickerFromSecCode(secCode),
+			Summary:     str(r.DocDescription),
+			DocType:     EDINETDocTypeLabel(code),
+			Meta:        meta,
+		})
+	}
+	return out, nil
+}
+
+// Download returns the XBRL instance of a document, read from the archive of
+// its submission (type=1). Documents without XBRL cannot be downloaded.
+func (f *EDINETFetcher) Download(ctx context.Context, d Document) ([]byte, string, error) {
+	if d.Meta["xbrl"] != "1" {
+		return nil, "", fmt.Errorf("edinet: %s: %w", d.DocID, ErrNoContent)
+	}
+	q := url.Values{}
+	q.Set("type", "1")
+	if f.cfg.APIKey != "" {
+		q.Set("Subscription-Key", f.cfg.APIKey)
+	}
+	b, _, err := getContent(ctx, f.client, f.cfg.BaseURL+"/api/v2/documents/"+url.PathEscape(d.DocID)+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, "", fmt.Errorf("edinet: %s: %w", d.DocID, err)
+	}
+	for _, zf := range zr.File {
+		if !strings.HasPrefix(zf.Name, "XBRL/PublicDoc/") || !strings.HasSuffix(zf.Name, ".xbrl") {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("edinet: %s: %w", d.DocID, err)
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxResponseBytes))
+		if err != nil {
+			return nil, "", fmt.Errorf("edinet: %s: %w", d.DocID, err)
+		}
+		return b, "application/xbrl+xml", nil
+	}
+	return nil, "", fmt.Errorf("edinet: %s: no xbrl instance in archive", d.DocID)
+}
+
+// normalizeSecCode accepts both the 4-character listing code and EDINET's
+// 5-character securities code with the trailing check digit.
+func normalizeSecCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 4 {
+		return code + "0"
+	}
+	return code
+}
+
+func tickerFromSecCode(code string) string {
+	if len(code) == 5 && strings.HasSuffix(code, "0") {
+		return code[:4]
+	}
+	return code
+}
+
+func str(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return strings.TrimSpace(*p)
+}
@@ -3,6 +3,7 @@ package fetcher
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -13,11 +14,54 @@ type Document struct {
 	PublishedAt time.Time
 	Ticker      string
 	Summary     string
+	DocType     string
+	Meta        map[string]string
+	// UniverseItemID is the universe item the document was fetched for.
+	UniverseItemID string
 }
 
 type Phase1FetchConfig struct {
 	Sources           []string `json:"sources"`
 	MaxItemsPerSource int      `json:"max_items_per_source"`
+	SECCIKs           []string `json:"sec_ciks,omitempty"`
+	SECTickers        []string `json:"sec_tickers,omitempty"`
+	SECForms          []string `json:"sec_forms,omitempty"`
+	EDINETSecCodes    []string `json:"edinet_sec_codes,omitempty"`
+	EDINETDocTypes    []string `json:"edinet_doc_types,omitempty"`
+	EDINETDays        int      `json:"edinet_days,omitempty"`
+	IRFeeds           []IRFeed `json:"ir_feeds,omitempty"`
+	IRWindowDays      int      `json:"ir_window_days,omitempty"`
+	// IRKeywords keeps only the items of feeds without a ticker that mention
+	// one of the keywords.
+	IRKeywords []string `json:"ir_keywords,omitempty"`
+
+	// Universe makes the run fetch for the active universe items it selects;
+	// the executor fills Targets and the identifiers from them.
+	Universe *UniverseSelection `json:"universe,omitempty"`
+	Targets  []UniverseTarget   `json:"-"`
+
+	// Backfill fetches the documents published in the range and ignores
+	// watermarks.
+	Backfill *DateRange `json:"backfill,omitempty"`
+	// Watermark is set per partition by the executor from earlier runs.
+	Watermark *Watermark `json:"-"`
+
+	// Concurrency bounds the partitions fetched at once across all sources.
+	Concurrency int `json:"concurrency,omitempty"`
+	// SourceTimeoutSeconds bounds each source from its first request.
+	SourceTimeoutSeconds int `json:"source_timeout_seconds,omitempty"`
+	// RunTimeoutSeconds bounds the whole fetch stage of a run.
+	RunTimeoutSeconds int `json:"run_timeout_seconds,omitempty"`
+
+	// ExtractContent downloads each document's content and stores its text,
+	// summary and language. It defaults to true.
+	ExtractContent *bool `json:"extract_content,omitempty"`
+}
+
+// ExtractEnabled reports whether document content should be downloaded and
+// extracted.
+func (c Phase1FetchConfig) ExtractEnabled() bool {
+	return c.ExtractContent == nil || *c.ExtractContent
 }
 
 type DocumentFetcher interface {
@@ -25,6 +69,29 @@ type DocumentFetcher interface {
 	Fetch(ctx context.Context, cfg Phase1FetchConfig) ([]Document, error)
 }
 
+// ContentDownloader is implemented by fetchers that can download the primary
+// content of the documents they return, for text extraction.
+type ContentDownloader interface {
+	Download(ctx context.Context, d Document) (body []byte, contentType string, err error)
+}
+
+// ErrNoContent is returned by Download for documents that have no content to
+// download, such as EDINET documents submitted without XBRL.
+var ErrNoContent = errors.New("no downloadable content")
+
+// AsDownloader returns f as a ContentDownloader when it, or the fetcher it
+// wraps, can download document content.
+func AsDownloader(f DocumentFetcher) (ContentDownloader, bool) {
+	if r, ok := f.(*retryingFetcher); ok {
+		if _, ok := r.inner.(ContentDownloader); !ok {
+			return nil, false
+		}
+		return r, true
+	}
+	dl, ok := f.(ContentDownloader)
+	return dl, ok
+}
+
 type Registry struct {
 	fetchers map[string]DocumentFetcher
 }
@@ -33,6 +100,41 @@ func NewRegistry() *Registry {
 	return &Registry{fetchers: map[string]DocumentFetcher{}}
 }
 
+// Settings selects the real fetchers to register in place of the stubs.
+// Policies override DefaultPolicies per source.
+type Settings struct {
+	SEC      *SECConfig
+	EDINET   *EDINETConfig
+	IR       *IRFeedConfig
+	Policies map[string]Policy
+}
+
+func NewDefaultRegistry(s Settings) *Registry {
+	reg := NewRegistry()
+	reg.Register(StubIRFetcher{})
+	reg.Register(StubSECFetcher{})
+	reg.Register(StubEDINETFetcher{})
+	if s.SEC != nil {
+		reg.Register(NewSECFetcher(*s.SEC))
+	}
+	if s.EDINET != nil {
+		reg.Register(NewEDINETFetcher(*s.EDINET))
+	}
+	if s.IR != nil {
+		reg.Register(NewIRFeedFetcher(*s.IR))
+	}
+	policies := DefaultPolicies()
+	for name, p := range s.Policies {
+		policies[name] = p
+	}
+	for name, f := range reg.fetchers {
+		if p, ok := policies[name]; ok {
+			reg.fetchers[name] = NewRetryingFetcher(f, p)
+		}
+	}
+	return reg
+}
+
 func (r *Registry) Register(f DocumentFetcher) {
 	if f == nil {
 		return
@@ -0,0 +1,108 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxResponseBytes = 32 << 20
+
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the server's Retry-After, if any.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("GET %s: status %d", e.URL, e.StatusCode)
+}
+
+func defaultClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+func getBytes(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	b, _, err := getContent(ctx, client, url, header)
+	return b, err
+}
+
+// getContent is getBytes that also returns the response's Content-Type. The
+// request waits for the source's rate limiter and is retried under its
+// policy (see retryRequest).
+func getContent(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, string, error) {
+	var (
+		body        []byte
+		contentType string
+	)
+	err := retryRequest(ctx, url, func() error {
+		var err error
+		body, contentType, err = get(ctx, client, url, header)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, contentType, nil
+}
+
+func get(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, string, error) {
+	if err := waitLimiter(ctx); err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return nil, "", &HTTPStatusError{URL: url, StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	return b, resp.Header.Get("Content-Type"), err
+}
+
+// retryAfter accepts both Retry-After forms: delay seconds and an HTTP date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, dst any) error {
+	b, err := getBytes(ctx, client, url, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("GET %s: decode: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const defaultIRWindowDays = 7
+
+type IRFeed struct {
+	URL    string `json:"url"`
+	Ticker string `json:"ticker,omitempty"`
+}
+
+type IRFeedConfig struct {
+	UserAgent string
+	Client    *http.Client
+	Now       func() time.Time
+}
+
+// IRFeedFetcher reads RSS 2.0, RSS 1.0 (RDF) and Atom investor-relations
+// feeds listed in Phase1FetchConfig.IRFeeds.
+type IRFeedFetcher struct {
+	cfg    IRFeedConfig
+	client *http.Client
+}
+
+func NewIRFeedFetcher(cfg IRFeedConfig) *IRFeedFetcher {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &IRFeedFetcher{cfg: cfg, client: defaultClient(cfg.Client)}
+}
+
+func (f *IRFeedFetcher) Source() string { return "ir" }
+
+// Fetch returns the recent items of cfg.IRFeeds. A feed that cannot be
+// fetched or parsed is logged and skipped; Fetch fails only when every feed
+// does.
+func (f *IRFeedFetcher) Fetch(ctx context.Context, cfg Phase1FetchConfig) ([]Document, error) {
+	max := cfg.MaxItemsPerSource
+	if max <= 0 {
+		max = defaultMaxItemsPerSource
+	}
+	window := cfg.IRWindowDays
+	if window <= 0 {
+		window = defaultIRWindowDays
+	}
+	cutoff := f.cfg.Now().UTC().AddDate(0, 0, -window)
+
+	seen := map[string]struct{}{}
+	var out []Document
+	var errs []error
+	for _, feed := range cfg.IRFeeds {
+		items, err := f.fetchFeed(ctx, feed)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			// One broken company feed does not cost the others.
+			log.Printf("ir: skipping feed %s: %v", feed.URL, err)
+			errs = append(errs, err)
+			continue
+		}
+		for _, d := range items {
+			if (cfg.Backfill == nil && d.PublishedAt.Before(cutoff)) || !cfg.wanted(d) {
+				continue
+			}
+			if feed.Ticker == "" && len(cfg.IRKeywords) > 0 && !containsKeyword(strings.ToLower(d.Title+"\n"+d.Summary), cfg.IRKeywords) {
+				continue
+			}
+			if _, ok := seen[d.DocID]; ok {
+				continue
+			}
+			seen[d.DocID] = struct{}{}
+			out = append(out, d)
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(cfg.IRFeeds) {
+		return nil, errors.Join(errs...)
+	}
+	return cfg.limit(out, max), nil
+}
+
+func (f *IRFeedFetcher) fetchFeed(ctx context.Context, feed IRFeed) ([]Document, error) {
+	h := http.Header{}
+	h.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+	if f.cfg.UserAgent != "" {
+		h.Set("User-Agent", f.cfg.UserAgent)
+	}
+	b, err := getBytes(ctx, f.client, feed.URL, h)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseFeed(b)
+	if err != nil {
+		return nil, fmt.Errorf("ir: %s: %w", feed.URL, err)
+	}
+	out := make([]Document, 0, len(entries))
+	for _, e := range entries {
+		published, ok := parseFeedTime(e.published)
+		if !ok {
+			continue
+		}
+		link := resolveLink(feed.URL, e.link)
+		key := e.id
+		if key == "" {
+			key = link
+		}
+		if key == "" {
+			continue
+		}
+		out = append(out, Document{
+			DocID:       feedDocID(feed.URL, key),
+			Title:       strings.TrimSpace(e.title),
+			URL:         link,
+			PublishedAt: published.UTC(),
+			Ticker:      feed.Ticker,
+			Summary:     strings.TrimSpace(e.summary),
+			DocType:     "ir_news",
+			Meta: map[string]string{
+				"feed_url":    feed.URL,
+				"feed_format": e.format,
+				"guid":        strings.TrimSpace(e.id),
+			},
+		})
+	}
+	return out, nil
+}
+
+// Download returns the page an item links to.
+func (f *IRFeedFetcher) Download(ctx context.Context, d Document) ([]byte, string, error) {
+	if d.URL == "" {
+		return nil, "", fmt.Errorf("ir: %s: no document url", d.DocID)
+	}
+	h := http.Header{}
+	h.Set("Accept", "text/html, text/plain, */*")
+	if f.cfg.UserAgent != "" {
+		h.Set("User-Agent", f.cfg.UserAgent)
+	}
+	return getContent(ctx, f.client, d.URL, h)
+}
+
+type feedEntry struct {
+	format    string
+	id        string
+	title     string
+	link      string
+	published string
+	summary   string
+}
+
+type rssDoc struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 puts items next to the channel element.
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	PubDate     string `xml:"pubDate"`
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string `xml:"description"`
+}
+
+type atomDoc struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Links     []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+func parseFeed(b []byte) ([]feedEntry, error) {
+	root, err := feedRoot(b)
+	if err != nil {
+		return nil, err
+	}
+	switch root {
+	case "rss", "RDF":
+		var doc rssDoc
+		if err := decodeFeed(b, &doc); err != nil {
+			return nil, err
+		}
+		format := "rss2"
+		items := doc.Channel.Items
+		if root == "RDF" {
+			format = "rss1"
+			items = doc.Items
+		}
+		out := make([]feedEntry, 0, len(items))
+		for _, it := range items {
+			id := it.GUID
+			if id == "" {
+				id = it.About
+			}
+			published := it.PubDate
+			if published == "" {
+				published = it.DCDate
+			}
+			out = append(out, feedEntry{format: format, id: id, title: it.Title, link: strings.TrimSpace(it.Link), published: published, summary: it.Description})
+		}
+		return out, nil
+	case "feed":
+		var doc atomDoc
+		if err := decodeFeed(b, &doc); err != nil {
+			return nil, err
+		}
+		out := make([]feedEntry, 0, len(doc.Entries))
+		for _, e := range doc.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			if link == "" && len(e.Links) > 0 {
+				link = e.Links[0].Href
+			}
+			published := e.Published
+			if published == "" {
+				published = e.Updated
+			}
+			summary := e.Summary
+			if summary == "" {
+				summary = e.Content
+			}
+			out = append(out, feedEntry{format: "atom", id: e.ID, title: e.Title, link: strings.TrimSpace(link), published: published, summary: summary})
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported feed root <%s>", root)
+	}
+}
+
+func feedRoot(b []byte) (string, error) {
+	dec := newFeedDecoder(b)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local, nil
+		}
+	}
+}
+
+func decodeFeed(b []byte, dst any) error {
+	return newFeedDecoder(b).Decode(dst)
+}
+
+// newFeedDecoder decodes feeds in the charset their XML declaration names.
+// Japanese IR feeds are often Shift_JIS or EUC-JP; any label of the WHATWG
+// encoding standard is accepted.
+func newFeedDecoder(b []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	dec.Strict = false
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "", "utf-8", "utf8", "us-ascii":
+			return input, nil
+		}
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported charset %q", charset)
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+	return dec
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseFeedTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func resolveLink(feedURL, link string) string {
+	if link == "" {
+		return ""
+	}
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// feedDocID derives a stable DocID from the feed host and the item GUID so
+// items with the same GUID on different sites do not collide.
+func feedDocID(feedURL, guid string) string {
+	host := feedURL
+	if u, err := url.Parse(feedURL); err == nil && u.Host != "" {
+		host = strings.ToLower(u.Host)
+	}
+	sum := sha256.Sum256([]byte(host + "|" + strings.TrimSpace(guid)))
+	return "ir-" + hex.EncodeToString(sum[:10])
+}
@@ -0,0 +1,156 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"investment_committee/internal/phase1/fetcher/vcr"
+)
+
+func TestIRFeedFetcherFetch(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/ir")))
+	t.Cleanup(srv.Close)
+	now := func() time.Time { return time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC) }
+	f := NewIRFeedFetcher(IRFeedConfig{Now: now})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{
+		IRFeeds: []IRFeed{
+			{URL: srv.URL + "/rss2.xml", Ticker: "EXMP"},
+			{URL: srv.URL + "/atom.xml", Ticker: "9999"},
+		},
+		IRWindowDays: 7,
+	})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 4 {
+		t.Fatalf("len(docs)=%d", len(docs))
+	}
+	first := docs[0]
+	if first.Title != "Example Corp Reports Fourth Quarter Results" || first.Ticker != "EXMP" {
+		t.Fatalf("unexpected first doc: %+v", first)
+	}
+	if first.URL != srv.URL+"/news/2024/q4-results" {
+		t.Fatalf("relative link not resolved: %s", first.URL)
+	}
+	if first.Meta["guid"] != "example-news-1001" || first.Meta["feed_format"] != "rss2" {
+		t.Fatalf("unexpected meta: %+v", first.Meta)
+	}
+	atom := docs[1]
+	if atom.Ticker != "9999" || !atom.PublishedAt.Equal(time.Date(2025, 1, 30, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected atom doc: %+v", atom)
+	}
+	updatedOnly := docs[2]
+	if updatedOnly.Summary != "通期業績予想を上方修正します。" {
+		t.Fatalf("atom content fallback: %q", updatedOnly.Summary)
+	}
+	noGUID := docs[3]
+	if noGUID.DocID != feedDocID(srv.URL+"/rss2.xml", "https://ir.example.com/news/2025/dividend") {
+		t.Fatalf("link-derived doc id: %s", noGUID.DocID)
+	}
+}
+
+func TestIRFeedFetcherStableDocID(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/ir")))
+	t.Cleanup(srv.Close)
+	now := func() time.Time { return time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC) }
+	f := NewIRFeedFetcher(IRFeedConfig{Now: now})
+	cfg := Phase1FetchConfig{IRFeeds: []IRFeed{{URL: srv.URL + "/rss2.xml"}}}
+
+	a, err := f.Fetch(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	b, err := f.Fetch(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(a) != 2 || a[0].DocID != b[0].DocID {
+		t.Fatalf("doc ids not stable: %+v %+v", a, b)
+	}
+}
+
+func TestIRFeedFetcherShiftJIS(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/ir")))
+	t.Cleanup(srv.Close)
+	now := func() time.Time { return time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC) }
+	f := NewIRFeedFetcher(IRFeedConfig{Now: now})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{IRFeeds: []IRFeed{{URL: srv.URL + "/rss2_sjis.xml", Ticker: "9999"}}})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Title != "業績予想の修正に関するお知らせ" || docs[0].Summary != "通期業績予想を下方修正します。" {
+		t.Fatalf("unexpected docs: %+v", docs)
+	}
+}
+
+func TestIRFeedFetcherReplay(t *testing.T) {
+	rec, err := vcr.New("testdata/cassettes/ir_2025-01-31.json", vcr.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewIRFeedFetcher(IRFeedConfig{Client: rec.Client(), Now: rec.Now})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{
+		IRFeeds: []IRFeed{
+			{URL: "https://ir.example.com/news/rss2.xml", Ticker: "EXMP"},
+			{URL: "https://ir.example.jp/atom.xml", Ticker: "9999"},
+			{URL: "https://ir.example.jp/rss_sjis.xml", Ticker: "9999"},
+		},
+		IRWindowDays: 7,
+	})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	var titles []string
+	for _, d := range docs {
+		titles = append(titles, d.Title)
+	}
+	if len(docs) != 5 {
+		t.Fatalf("titles = %q", titles)
+	}
+	if docs[0].URL != "https://ir.example.com/news/2024/q4-results" {
+		t.Fatalf("relative link not resolved: %s", docs[0].URL)
+	}
+	found := false
+	for _, d := range docs {
+		found = found || d.Title == "業績予想の修正に関するお知らせ"
+	}
+	if !found {
+		t.Fatalf("Shift_JIS feed not decoded: %q", titles)
+	}
+	if _, err := f.Fetch(context.Background(), Phase1FetchConfig{IRFeeds: []IRFeed{{URL: "https://ir.example.com/other.xml"}}}); err == nil {
+		t.Fatal("expected an error for a feed missing from the cassette")
+	}
+}
+
+func TestIRFeedFetcherSkipsBrokenFeed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/rss2.xml", http.FileServer(http.Dir("testdata/ir")))
+	mux.HandleFunc("/broken.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<rss><channel><item>"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	now := func() time.Time { return time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC) }
+	f := NewIRFeedFetcher(IRFeedConfig{Now: now})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{
+		IRFeeds:      []IRFeed{{URL: srv.URL + "/broken.xml"}, {URL: srv.URL + "/rss2.xml", Ticker: "EXMP"}},
+		IRWindowDays: 7,
+	})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Ticker != "EXMP" {
+		t.Fatalf("docs of the working feed = %+v", docs)
+	}
+
+	if _, err := f.Fetch(context.Background(), Phase1FetchConfig{IRFeeds: []IRFeed{{URL: srv.URL + "/broken.xml"}, {URL: srv.URL + "/missing.xml"}}}); err == nil {
+		t.Fatal("expected an error when every feed fails")
+	}
+}
@@ -0,0 +1,104 @@
+package fetcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// Partition is an independent slice of one source's work, e.g. a single SEC
+// ticker or IR feed, that can be fetched concurrently with the others.
+type Partition struct {
+	Source string
+	// Key identifies the partition in events and watermarks, e.g.
+	// "ticker:AAPL"; empty when the source is fetched as a whole.
+	Key    string
+	Config Phase1FetchConfig
+}
+
+// Partitions splits the work of src by entity. SEC is split per CIK and per
+// ticker and IR per feed; EDINET lists documents by date for all companies at
+// once, so it stays a single partition.
+func Partitions(src string, cfg Phase1FetchConfig) []Partition {
+	var out []Partition
+	switch src {
+	case "sec":
+		for _, cik := range cfg.SECCIKs {
+			c := cfg
+			c.SECCIKs, c.SECTickers = []string{cik}, nil
+			out = append(out, Partition{Source: src, Key: "cik:" + cik, Config: c})
+		}
+		for _, t := range cfg.SECTickers {
+			c := cfg
+			c.SECCIKs, c.SECTickers = nil, []string{t}
+			out = append(out, Partition{Source: src, Key: "ticker:" + t, Config: c})
+		}
+	case "ir":
+		for _, feed := range cfg.IRFeeds {
+			c := cfg
+			c.IRFeeds = []IRFeed{feed}
+			out = append(out, Partition{Source: src, Key: "feed:" + feed.URL, Config: c})
+		}
+	}
+	if len(out) == 0 {
+		return []Partition{{Source: src, Config: cfg}}
+	}
+	return out
+}
+
+// Filter describes the settings that narrow what the partition's fetcher
+// returns, e.g. "forms=10-K,8-K", or is empty when nothing is filtered out.
+// Values are normalized and sorted so that equal filters compare equal.
+func (p Partition) Filter() string {
+	var parts []string
+	add := func(name string, values []string, norm func(string) string) {
+		var vs []string
+		for _, v := range values {
+			if v = norm(v); v != "" {
+				vs = append(vs, v)
+			}
+		}
+		if len(vs) > 0 {
+			sort.Strings(vs)
+			parts = append(parts, name+"="+strings.Join(vs, ","))
+		}
+	}
+	c := p.Config
+	switch p.Source {
+	case "sec":
+		add("forms", c.SECForms, func(v string) string { return strings.ToUpper(strings.TrimSpace(v)) })
+	case "edinet":
+		add("doc_types", c.EDINETDocTypes, strings.TrimSpace)
+		add("sec_codes", c.EDINETSecCodes, normalizeSecCode)
+	case "ir":
+		// Keywords only filter feeds that are not tied to a ticker.
+		if len(c.IRFeeds) == 1 && c.IRFeeds[0].Ticker == "" {
+			add("keywords", c.IRKeywords, func(v string) string { return strings.ToLower(strings.TrimSpace(v)) })
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// MergeDocuments combines the results of a source's partitions the way the
+// source would have returned them in one call: unique by DocID, newest
+// first, and at most max documents (the source default when max <= 0).
+func MergeDocuments(max int, parts ...[]Document) []Document {
+	if max <= 0 {
+		max = defaultMaxItemsPerSource
+	}
+	seen := map[string]struct{}{}
+	var out []Document
+	for _, docs := range parts {
+		for _, d := range docs {
+			if _, ok := seen[d.DocID]; ok {
+				continue
+			}
+			seen[d.DocID] = struct{}{}
+			out = append(out, d)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].PublishedAt.After(out[j].PublishedAt) })
+	if len(out) > max {
+		out = out[:max]
+	}
+	return out
+}
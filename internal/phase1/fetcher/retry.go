@@ -0,0 +1,228 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Policy is the retry and rate limit policy of one source.
+type Policy struct {
+	// RequestsPerSecond limits HTTP requests across all runs; 0 disables it.
+	RequestsPerSecond float64
+	Burst             int
+	// MaxAttempts counts the first call; 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultPolicy retries up to 4 times without a rate limit.
+func DefaultPolicy() Policy {
+	return Policy{Burst: 1, MaxAttempts: 4, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+}
+
+// DefaultPolicies follow the published limits where there is one: SEC allows
+// 10 requests per second, EDINET and IR sites get a conservative rate.
+func DefaultPolicies() map[string]Policy {
+	sec, edinet, ir := DefaultPolicy(), DefaultPolicy(), DefaultPolicy()
+	sec.RequestsPerSecond = 10
+	edinet.RequestsPerSecond = 2
+	ir.RequestsPerSecond = 5
+	return map[string]Policy{"sec": sec, "edinet": edinet, "ir": ir}
+}
+
+// Attempt describes a failed try of one HTTP request made by a wrapped
+// fetcher's Fetch or, with an empty URL and no Number, a Fetch that
+// succeeded.
+type Attempt struct {
+	Source string
+	// URL is the request that failed; empty for a successful Fetch.
+	URL         string
+	Number      int
+	MaxAttempts int
+	Duration    time.Duration
+	Documents   int
+	Err         error
+	Retryable   bool
+	// NextDelay is the backoff before the request is tried again; zero
+	// when the fetcher gives up.
+	NextDelay time.Duration
+}
+
+type attemptObserverKey struct{}
+
+// WithAttemptObserver makes wrapped fetchers report every attempt made with
+// ctx, e.g. to record it as a run event.
+func WithAttemptObserver(ctx context.Context, fn func(Attempt)) context.Context {
+	return context.WithValue(ctx, attemptObserverKey{}, fn)
+}
+
+func observeAttempt(ctx context.Context, a Attempt) {
+	if fn, ok := ctx.Value(attemptObserverKey{}).(func(Attempt)); ok && fn != nil {
+		fn(a)
+	}
+}
+
+// retryingFetcher wraps a DocumentFetcher with the source's Policy. The
+// policy applies to each HTTP request the fetcher makes, so a flaky request
+// is retried on its own instead of repeating every request of the Fetch.
+type retryingFetcher struct {
+	inner   DocumentFetcher
+	policy  Policy
+	limiter *rateLimiter
+	sleep   func(context.Context, time.Duration) error
+}
+
+func NewRetryingFetcher(f DocumentFetcher, p Policy) DocumentFetcher {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return &retryingFetcher{
+		inner:   f,
+		policy:  p,
+		limiter: newRateLimiter(p.RequestsPerSecond, p.Burst),
+		sleep:   sleepCtx,
+	}
+}
+
+func (r *retryingFetcher) Source() string { return r.inner.Source() }
+
+func (r *retryingFetcher) Fetch(ctx context.Context, cfg Phase1FetchConfig) ([]Document, error) {
+	start := time.Now()
+	docs, err := r.inner.Fetch(r.requestContext(ctx, true), cfg)
+	if err != nil {
+		return nil, err
+	}
+	observeAttempt(ctx, Attempt{Source: r.Source(), Duration: time.Since(start), Documents: len(docs)})
+	return docs, nil
+}
+
+// Download fetches a document's content through the same limiter and retry
+// policy as Fetch. Its attempts are not reported to the attempt observer.
+func (r *retryingFetcher) Download(ctx context.Context, d Document) ([]byte, string, error) {
+	dl, ok := r.inner.(ContentDownloader)
+	if !ok {
+		return nil, "", fmt.Errorf("%s: content download not supported", r.Source())
+	}
+	return dl.Download(r.requestContext(ctx, false), d)
+}
+
+type requestRetryKey struct{}
+
+type requestRetry struct {
+	*retryingFetcher
+	observe bool
+}
+
+// requestContext makes the requests made with ctx wait for the limiter and
+// retry under the policy.
+func (r *retryingFetcher) requestContext(ctx context.Context, observe bool) context.Context {
+	ctx = withLimiter(ctx, r.limiter)
+	return context.WithValue(ctx, requestRetryKey{}, requestRetry{r, observe})
+}
+
+// retryRequest calls fn, the request to url, until it succeeds, fails
+// permanently or runs out of the attempts of the policy in ctx, waiting out
+// the backoff in between. Without a policy fn is called once.
+func retryRequest(ctx context.Context, url string, fn func() error) error {
+	rr, ok := ctx.Value(requestRetryKey{}).(requestRetry)
+	if !ok {
+		return fn()
+	}
+	for n := 1; ; n++ {
+		start := time.Now()
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		a := Attempt{
+			Source:      rr.Source(),
+			URL:         url,
+			Number:      n,
+			MaxAttempts: rr.policy.MaxAttempts,
+			Duration:    time.Since(start),
+			Err:         err,
+			Retryable:   ctx.Err() == nil && IsTransient(err),
+		}
+		if a.Retryable && n < rr.policy.MaxAttempts {
+			a.NextDelay = rr.backoff(n, err)
+			// Do not sleep past the caller's deadline only to be cancelled.
+			if dl, ok := ctx.Deadline(); ok && time.Until(dl) < a.NextDelay {
+				a.NextDelay = 0
+			}
+		}
+		if rr.observe {
+			observeAttempt(ctx, a)
+		}
+		if a.NextDelay == 0 {
+			return err
+		}
+		if serr := rr.sleep(ctx, a.NextDelay); serr != nil {
+			return err
+		}
+	}
+}
+
+// backoff is exponential with equal jitter, raised to the server's
+// Retry-After when it asks for longer.
+func (r *retryingFetcher) backoff(n int, err error) time.Duration {
+	d := r.policy.BaseDelay << (n - 1)
+	if d > r.policy.MaxDelay || d <= 0 {
+		d = r.policy.MaxDelay
+	}
+	d = d/2 + rand.N(d/2+1)
+	var se *HTTPStatusError
+	if errors.As(err, &se) && se.RetryAfter > d {
+		d = se.RetryAfter
+	}
+	return d
+}
+
+// IsTransient reports whether err is worth retrying: throttling, server
+// errors, timeouts and dropped connections.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var se *HTTPStatusError
+	if errors.As(err, &se) {
+		switch se.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
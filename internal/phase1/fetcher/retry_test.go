@@ -0,0 +1,153 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pagesFetcher fetches every page and returns a document per page.
+type pagesFetcher struct {
+	client *http.Client
+	pages  []string
+}
+
+func (f pagesFetcher) Source() string { return "pages" }
+
+func (f pagesFetcher) Fetch(ctx context.Context, cfg Phase1FetchConfig) ([]Document, error) {
+	var docs []Document
+	for _, p := range f.pages {
+		if _, err := getBytes(ctx, f.client, p, nil); err != nil {
+			return nil, err
+		}
+		docs = append(docs, Document{DocID: p})
+	}
+	return docs, nil
+}
+
+// flakyServer answers /flaky with the given statuses before succeeding and
+// counts the requests per path.
+func flakyServer(t *testing.T, statuses []int, retryAfter string) (*httptest.Server, map[string]int) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+		if r.URL.Path == "/flaky" && n <= len(statuses) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statuses[n-1])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, calls
+}
+
+func TestRetryingFetcher(t *testing.T) {
+	cases := []struct {
+		name       string
+		statuses   []int
+		retryAfter string
+		attempts   int
+		wantCalls  int
+		wantErr    bool
+		minDelay   time.Duration
+	}{
+		{name: "success", attempts: 3, wantCalls: 1},
+		{name: "transient then success", statuses: []int{503, 503}, attempts: 3, wantCalls: 3},
+		{name: "gives up", statuses: []int{503, 503, 503}, attempts: 3, wantCalls: 3, wantErr: true},
+		{name: "permanent error", statuses: []int{404}, attempts: 3, wantCalls: 1, wantErr: true},
+		{name: "retry-after", statuses: []int{429}, retryAfter: "2", attempts: 2, wantCalls: 2, minDelay: 2 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, calls := flakyServer(t, tc.statuses, tc.retryAfter)
+			inner := pagesFetcher{client: srv.Client(), pages: []string{srv.URL + "/first", srv.URL + "/flaky", srv.URL + "/last"}}
+			f := NewRetryingFetcher(inner, Policy{MaxAttempts: tc.attempts, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}).(*retryingFetcher)
+			var slept []time.Duration
+			f.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+			var seen []Attempt
+			ctx := WithAttemptObserver(context.Background(), func(a Attempt) { seen = append(seen, a) })
+
+			docs, err := f.Fetch(ctx, Phase1FetchConfig{})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v", err)
+			}
+			if !tc.wantErr && len(docs) != 3 {
+				t.Fatalf("docs=%v", docs)
+			}
+			if calls["/first"] != 1 || calls["/flaky"] != tc.wantCalls {
+				t.Fatalf("calls=%v, want /flaky %d and other pages once", calls, tc.wantCalls)
+			}
+			if len(slept) != tc.wantCalls-1 {
+				t.Fatalf("slept=%v", slept)
+			}
+			for _, d := range slept {
+				if tc.minDelay == 0 && d > 4*time.Millisecond {
+					t.Fatalf("delay %v above max", d)
+				}
+				if d < tc.minDelay {
+					t.Fatalf("delay %v below retry-after %v", d, tc.minDelay)
+				}
+			}
+			failed := len(tc.statuses)
+			if failed > tc.wantCalls {
+				failed = tc.wantCalls
+			}
+			if tc.wantErr && len(seen) != failed || !tc.wantErr && len(seen) != failed+1 {
+				t.Fatalf("attempts=%+v", seen)
+			}
+			for i, a := range seen[:failed] {
+				if a.URL != srv.URL+"/flaky" || a.Number != i+1 || a.Err == nil {
+					t.Fatalf("attempt %d=%+v", i, a)
+				}
+			}
+			if last := seen[len(seen)-1]; tc.wantErr && last.NextDelay != 0 || !tc.wantErr && (last.Err != nil || last.Documents != 3) {
+				t.Fatalf("last attempt=%+v", last)
+			}
+		})
+	}
+}
+
+func TestRetryingFetcherStopsAtDeadline(t *testing.T) {
+	srv, calls := flakyServer(t, []int{502}, "")
+	inner := pagesFetcher{client: srv.Client(), pages: []string{srv.URL + "/flaky"}}
+	f := NewRetryingFetcher(inner, Policy{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := f.Fetch(ctx, Phase1FetchConfig{}); err == nil || calls["/flaky"] != 1 {
+		t.Fatalf("err=%v calls=%v", err, calls)
+	}
+}
+
+func TestRateLimitAppliesToRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	ctx := withLimiter(context.Background(), newRateLimiter(20, 1))
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := getBytes(ctx, srv.Client(), srv.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request uses the burst token, the other three wait 50ms each.
+	if d := time.Since(start); d < 140*time.Millisecond {
+		t.Fatalf("4 requests at 20 rps took %v", d)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := getBytes(cancelled, srv.Client(), srv.URL, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v", err)
+	}
+}
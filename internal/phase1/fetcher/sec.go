@@ -0,0 +1,306 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSECDataBaseURL     = "https://data.sec.gov"
+	defaultSECArchivesBaseURL = "https://www.sec.gov"
+	defaultMaxItemsPerSource  = 20
+)
+
+// SECConfig configures the EDGAR fetcher. DataBaseURL serves submissions JSON
+// and ArchivesBaseURL serves filing indexes and the ticker map; both can point
+// at the same local server in tests.
+type SECConfig struct {
+	DataBaseURL     string
+	ArchivesBaseURL string
+	UserAgent       string
+	Client          *http.Client
+}
+
+type SECFetcher struct {
+	cfg    SECConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	tickerCIKs map[string]string
+}
+
+func NewSECFetcher(cfg SECConfig) *SECFetcher {
+	if cfg.DataBaseURL == "" {
+		cfg.DataBaseURL = defaultSECDataBaseURL
+	}
+	if cfg.ArchivesBaseURL == "" {
+		cfg.ArchivesBaseURL = defaultSECArchivesBaseURL
+	}
+	cfg.DataBaseURL = strings.TrimRight(cfg.DataBaseURL, "/")
+	cfg.ArchivesBaseURL = strings.TrimRight(cfg.ArchivesBaseURL, "/")
+	return &SECFetcher{cfg: cfg, client: defaultClient(cfg.Client)}
+}
+
+func (f *SECFetcher) Source() string { return "sec" }
+
+type secSubmissions struct {
+	CIK     string   `json:"cik"`
+	Name    string   `json:"name"`
+	Tickers []string `json:"tickers"`
+	Filings struct {
+		Recent secRecentFilings `json:"recent"`
+	} `json:"filings"`
+}
+
+type secRecentFilings struct {
+	AccessionNumber       []string `json:"accessionNumber"`
+	FilingDate            []string `json:"filingDate"`
+	ReportDate            []string `json:"reportDate"`
+	AcceptanceDateTime    []string `json:"acceptanceDateTime"`
+	Form                  []string `json:"form"`
+	Items                 []string `json:"items"`
+	PrimaryDocument       []string `json:"primaryDocument"`
+	PrimaryDocDescription []string `json:"primaryDocDescription"`
+}
+
+type secFilingIndex struct {
+	Directory struct {
+		Item []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"item"`
+	} `json:"directory"`
+}
+
+type secTickerEntry struct {
+	CIK    int    `json:"cik_str"`
+	Ticker string `json:"ticker"`
+	Title  string `json:"title"`
+}
+
+func (f *SECFetcher) Fetch(ctx context.Context, cfg Phase1FetchConfig) ([]Document, error) {
+	max := cfg.MaxItemsPerSource
+	if max <= 0 {
+		max = defaultMaxItemsPerSource
+	}
+	ciks, err := f.resolveCIKs(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	forms := map[string]struct{}{}
+	for _, form := range cfg.SECForms {
+		forms[strings.ToUpper(strings.TrimSpace(form))] = struct{}{}
+	}
+
+	// Resuming from a watermark, every filing after it is listed so that
+	// limit can keep the oldest.
+	perCIK := max
+	if cfg.Backfill == nil && cfg.Watermark != nil {
+		perCIK = 0
+	}
+	var out []Document
+	for _, cik := range ciks {
+		docs, err := f.fetchCIK(ctx, cik, forms, perCIK, cfg.wanted)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, docs...)
+	}
+	return cfg.limit(out, max), nil
+}
+
+func (f *SECFetcher) header() http.Header {
+	h := http.Header{}
+	if f.cfg.UserAgent != "" {
+		h.Set("User-Agent", f.cfg.UserAgent)
+	}
+	h.Set("Accept", "application/json")
+	return h
+}
+
+func (f *SECFetcher) resolveCIKs(ctx context.Context, cfg Phase1FetchConfig) ([]string, error) {
+	seen := map[string]struct{}{}
+	var out []string
+	add := func(cik string) {
+		if _, ok := seen[cik]; ok {
+			return
+		}
+		seen[cik] = struct{}{}
+		out = append(out, cik)
+	}
+	for _, raw := range cfg.SECCIKs {
+		cik, err := padCIK(raw)
+		if err != nil {
+			return nil, err
+		}
+		add(cik)
+	}
+	for _, ticker := range cfg.SECTickers {
+		cik, err := f.lookupTicker(ctx, ticker)
+		if err != nil {
+			return nil, err
+		}
+		add(cik)
+	}
+	return out, nil
+}
+
+func (f *SECFetcher) lookupTicker(ctx context.Context, ticker string) (string, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tickerCIKs == nil {
+		var raw map[string]secTickerEntry
+		if err := getJSON(ctx, f.client, f.cfg.ArchivesBaseURL+"/files/company_tickers.json", f.header(), &raw); err != nil {
+			return "", err
+		}
+		m := make(map[string]string, len(raw))
+		for _, e := range raw {
+			m[strings.ToUpper(e.Ticker)] = fmt.Sprintf("%010d", e.CIK)
+		}
+		f.tickerCIKs = m
+	}
+	cik, ok := f.tickerCIKs[ticker]
+	if !ok {
+		return "", fmt.Errorf("sec: unknown ticker %q", ticker)
+	}
+	return cik, nil
+}
+
+// fetchCIK lists the CIK's recent filings that wanted accepts, newest first
+// and at most max of them unless max is 0.
+func (f *SECFetcher) fetchCIK(ctx context.Context, cik string, forms map[string]struct{}, max int, wanted func(Document) bool) ([]Document, error) {
+	var sub secSubmissions
+	url := f.cfg.DataBaseURL + "/submissions/CIK" + cik + ".json"
+	if err := getJSON(ctx, f.client, url, f.header(), &sub); err != nil {
+		return nil, err
+	}
+	recent := sub.Filings.Recent
+	ticker := ""
+	if len(sub.Tickers) > 0 {
+		ticker = sub.Tickers[0]
+	}
+
+	var out []Document
+	for i, acc := range recent.AccessionNumber {
+		if max > 0 && len(out) >= max {
+			break
+		}
+		form := at(recent.Form, i)
+		if len(forms) > 0 {
+			if _, ok := forms[strings.ToUpper(form)]; !ok {
+				continue
+			}
+		}
+		published, ok := secPublishedAt(at(recent.AcceptanceDateTime, i), at(recent.FilingDate, i))
+		if !ok || !wanted(Document{DocID: acc, PublishedAt: published}) {
+			continue
+		}
+		primary := at(recent.PrimaryDocument, i)
+		if primary == "" {
+			name, err := f.primaryFromIndex(ctx, cik, acc)
+			if err != nil {
+				return nil, err
+			}
+			primary = name
+		}
+		desc := at(recent.PrimaryDocDescription, i)
+		summary := desc
+		if summary == "" {
+			summary = form
+		}
+		meta := map[string]string{
+			"cik":              cik,
+			"accession_number": acc,
+			"filing_date":      at(recent.FilingDate, i),
+		}
+		if v := at(recent.ReportDate, i); v != "" {
+			meta["report_date"] = v
+		}
+		if v := at(recent.Items, i); v != "" {
+			meta["items"] = v
+		}
+		out = append(out, Document{
+			DocID:       acc,
+			Title:       strings.TrimSpace(fmt.Sprintf("%s %s %s", sub.Name, form, at(recent.FilingDate, i))),
+			URL:         f.filingURL(cik, acc, primary),
+			PublishedAt: published,
+			Ticker:      ticker,
+			Summary:     summary,
+			DocType:     form,
+			Meta:        meta,
+		})
+	}
+	return out, nil
+}
+
+// Download returns the filing's primary document. EDGAR requires the same
+// declared User-Agent as for the JSON endpoints.
+func (f *SECFetcher) Download(ctx context.Context, d Document) ([]byte, string, error) {
+	if d.URL == "" {
+		return nil, "", fmt.Errorf("sec: %s: no document url", d.DocID)
+	}
+	h := http.Header{}
+	if f.cfg.UserAgent != "" {
+		h.Set("User-Agent", f.cfg.UserAgent)
+	}
+	h.Set("Accept", "text/html, text/plain, */*")
+	return getContent(ctx, f.client, d.URL, h)
+}
+
+func (f *SECFetcher) primaryFromIndex(ctx context.Context, cik, acc string) (string, error) {
+	var idx secFilingIndex
+	if err := getJSON(ctx, f.client, f.filingURL(cik, acc, "index.json"), f.header(), &idx); err != nil {
+		return "", err
+	}
+	for _, it := range idx.Directory.Item {
+		name := strings.ToLower(it.Name)
+		if strings.Contains(name, "-index") {
+			continue
+		}
+		if strings.HasSuffix(name, ".htm") || strings.HasSuffix(name, ".html") {
+			return it.Name, nil
+		}
+	}
+	return acc + "-index.htm", nil
+}
+
+func (f *SECFetcher) filingURL(cik, acc, name string) string {
+	n, _ := strconv.ParseInt(cik, 10, 64)
+	return fmt.Sprintf("%s/Archives/edgar/data/%d/%s/%s", f.cfg.ArchivesBaseURL, n, strings.ReplaceAll(acc, "-", ""), name)
+}
+
+func padCIK(raw string) (string, error) {
+	raw = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(raw)), "CIK")
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("sec: invalid cik %q", raw)
+	}
+	return fmt.Sprintf("%010d", n), nil
+}
+
+func secPublishedAt(acceptance, filingDate string) (time.Time, bool) {
+	if acceptance != "" {
+		if t, err := time.Parse(time.RFC3339, acceptance); err == nil {
+			return t.UTC(), true
+		}
+	}
+	if filingDate != "" {
+		if t, err := time.Parse("2006-01-02", filingDate); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func at(s []string, i int) string {
+	if i < len(s) {
+		return s[i]
+	}
+	return ""
+}
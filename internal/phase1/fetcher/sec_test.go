@@ -0,0 +1,120 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"investment_committee/internal/phase1/fetcher/vcr"
+)
+
+func newSECFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/company_tickers.json", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/sec/company_tickers.json")
+	})
+	mux.HandleFunc("/submissions/CIK0000320193.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "test admin@example.com" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		http.ServeFile(w, r, "testdata/sec/CIK0000320193.json")
+	})
+	mux.HandleFunc("/Archives/edgar/data/320193/000032019324000069/index.json", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/sec/index_000032019324000069.json")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSECFetcherFetch(t *testing.T) {
+	srv := newSECFixtureServer(t)
+	f := NewSECFetcher(SECConfig{DataBaseURL: srv.URL, ArchivesBaseURL: srv.URL, UserAgent: "test admin@example.com"})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{SECTickers: []string{"aapl"}, MaxItemsPerSource: 3})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("len(docs)=%d", len(docs))
+	}
+	d := docs[0]
+	if d.DocID != "0000320193-24-000123" || d.DocType != "10-K" || d.Ticker != "AAPL" {
+		t.Fatalf("unexpected first doc: %+v", d)
+	}
+	if d.Meta["cik"] != "0000320193" {
+		t.Fatalf("cik=%q", d.Meta["cik"])
+	}
+	if !d.PublishedAt.Equal(time.Date(2024, 11, 1, 6, 1, 36, 0, time.UTC)) {
+		t.Fatalf("published_at=%s", d.PublishedAt)
+	}
+	if want := srv.URL + "/Archives/edgar/data/320193/000032019324000123/aapl-20240928.htm"; d.URL != want {
+		t.Fatalf("url=%s", d.URL)
+	}
+	last := docs[2]
+	if want := srv.URL + "/Archives/edgar/data/320193/000032019324000069/aapl-20240502.htm"; last.URL != want {
+		t.Fatalf("index fallback url=%s", last.URL)
+	}
+	if last.Meta["items"] != "2.02,9.01" {
+		t.Fatalf("items=%q", last.Meta["items"])
+	}
+}
+
+func TestSECFetcherMaxItemsAndForms(t *testing.T) {
+	srv := newSECFixtureServer(t)
+	f := NewSECFetcher(SECConfig{DataBaseURL: srv.URL, ArchivesBaseURL: srv.URL, UserAgent: "test admin@example.com"})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{SECCIKs: []string{"320193"}, MaxItemsPerSource: 1})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("len(docs)=%d", len(docs))
+	}
+
+	docs, err = f.Fetch(context.Background(), Phase1FetchConfig{SECCIKs: []string{"320193"}, SECForms: []string{"8-k"}})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 1 || docs[0].DocType != "8-K" {
+		t.Fatalf("unexpected docs: %+v", docs)
+	}
+}
+
+func TestSECFetcherUnknownTicker(t *testing.T) {
+	srv := newSECFixtureServer(t)
+	f := NewSECFetcher(SECConfig{DataBaseURL: srv.URL, ArchivesBaseURL: srv.URL, UserAgent: "test admin@example.com"})
+	if _, err := f.Fetch(context.Background(), Phase1FetchConfig{SECTickers: []string{"ZZZZ"}}); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestSECFetcherReplay(t *testing.T) {
+	rec, err := vcr.New("testdata/cassettes/sec_aapl_2024-11-01.json", vcr.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewSECFetcher(SECConfig{UserAgent: "test admin@example.com", Client: rec.Client()})
+
+	docs, err := f.Fetch(context.Background(), Phase1FetchConfig{SECTickers: []string{"AAPL"}, MaxItemsPerSource: 3})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	var ids []string
+	for _, d := range docs {
+		ids = append(ids, d.DocID)
+	}
+	if len(docs) != 3 || docs[0].DocID != "0000320193-24-000123" {
+		t.Fatalf("doc ids = %v", ids)
+	}
+	if want := "https://www.sec.gov/Archives/edgar/data/320193/000032019324000069/aapl-20240502.htm"; docs[2].URL != want {
+		t.Fatalf("index fallback url=%s", docs[2].URL)
+	}
+	if _, err := f.Fetch(context.Background(), Phase1FetchConfig{SECCIKs: []string{"789019"}}); err == nil {
+		t.Fatal("expected an error for a CIK missing from the cassette")
+	}
+}
@@ -0,0 +1,142 @@
+package fetcher
+
+import (
+	"strings"
+)
+
+// UniverseSelection picks the active universe items a run fetches for.
+type UniverseSelection struct {
+	// PriorityMin drops items below this priority.
+	PriorityMin *int `json:"priority_min,omitempty"`
+	// EntityTypes limits the items to these entity types; empty means all.
+	EntityTypes []string `json:"entity_types,omitempty"`
+}
+
+// UniverseTarget is a universe item a run fetches for.
+type UniverseTarget struct {
+	ID         string
+	EntityType string
+	EntityID   string
+	Name       string
+	Keywords   []string
+	Priority   int
+}
+
+// Select returns the targets that pass the selection, in input order.
+func (s UniverseSelection) Select(items []UniverseTarget) []UniverseTarget {
+	types := map[string]struct{}{}
+	for _, t := range s.EntityTypes {
+		types[strings.TrimSpace(t)] = struct{}{}
+	}
+	var out []UniverseTarget
+	for _, it := range items {
+		if s.PriorityMin != nil && it.Priority < *s.PriorityMin {
+			continue
+		}
+		if _, ok := types[it.EntityType]; len(types) > 0 && !ok {
+			continue
+		}
+		out = append(out, it)
+	}
+	return out
+}
+
+// WithTargets adds the identifiers of the targets to cfg: ticker items go to
+// sec_tickers, or to edinet_sec_codes when they are Japanese securities
+// codes, and the keywords of every item go to ir_keywords. Identifiers
+// already in cfg are kept.
+func (cfg Phase1FetchConfig) WithTargets(targets []UniverseTarget) Phase1FetchConfig {
+	cfg.Targets = targets
+	add := func(list []string, v string) []string {
+		for _, x := range list {
+			if strings.EqualFold(x, v) {
+				return list
+			}
+		}
+		return append(list, v)
+	}
+	for _, t := range targets {
+		if t.EntityType == "ticker" {
+			id := strings.ToUpper(strings.TrimSpace(t.EntityID))
+			if isSecCode(id) {
+				cfg.EDINETSecCodes = add(cfg.EDINETSecCodes, id)
+			} else if id != "" {
+				cfg.SECTickers = add(cfg.SECTickers, id)
+			}
+		}
+		for _, kw := range t.Keywords {
+			if kw = strings.TrimSpace(kw); kw != "" {
+				cfg.IRKeywords = add(cfg.IRKeywords, kw)
+			}
+		}
+	}
+	return cfg
+}
+
+// isSecCode reports whether a ticker is a Japanese securities code such as
+// 7203, 72030 or 130A.
+func isSecCode(id string) bool {
+	if len(id) != 4 && len(id) != 5 {
+		return false
+	}
+	if id[0] < '0' || id[0] > '9' {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// TagDocuments sets UniverseItemID on documents that do not have one: a
+// ticker item matches the document's ticker (securities codes with or
+// without the check digit), otherwise the first target, by priority, with a
+// keyword in the title or summary matches.
+func TagDocuments(docs []Document, targets []UniverseTarget) {
+	if len(targets) == 0 {
+		return
+	}
+	for i := range docs {
+		if docs[i].UniverseItemID != "" {
+			continue
+		}
+		if t, ok := MatchTarget(docs[i], targets); ok {
+			docs[i].UniverseItemID = t.ID
+		}
+	}
+}
+
+// MatchTarget returns the target a document was fetched for.
+func MatchTarget(d Document, targets []UniverseTarget) (UniverseTarget, bool) {
+	ticker := tickerFromSecCode(strings.ToUpper(strings.TrimSpace(d.Ticker)))
+	if ticker != "" {
+		for _, t := range targets {
+			if t.EntityType == "ticker" && tickerFromSecCode(strings.ToUpper(strings.TrimSpace(t.EntityID))) == ticker {
+				return t, true
+			}
+		}
+	}
+	text := strings.ToLower(d.Title + "\n" + d.Summary)
+	var best UniverseTarget
+	found := false
+	for _, t := range targets {
+		if found && t.Priority <= best.Priority {
+			continue
+		}
+		if containsKeyword(text, t.Keywords) {
+			best, found = t, true
+		}
+	}
+	return best, found
+}
+
+func containsKeyword(lowerText string, keywords []string) bool {
+	for _, kw := range keywords {
+		if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" && strings.Contains(lowerText, kw) {
+			return true
+		}
+	}
+	return false
+}
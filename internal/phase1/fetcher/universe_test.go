@@ -0,0 +1,48 @@
+package fetcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUniverseTargets(t *testing.T) {
+	items := []UniverseTarget{
+		{ID: "u1", EntityType: "ticker", EntityID: "aapl", Priority: 90},
+		{ID: "u2", EntityType: "ticker", EntityID: "72030", Priority: 80},
+		{ID: "u3", EntityType: "theme", EntityID: "ai", Keywords: []string{"GPU", " "}, Priority: 70},
+		{ID: "u4", EntityType: "ticker", EntityID: "MSFT", Priority: 10},
+		{ID: "u5", EntityType: "industry", EntityID: "chips", Keywords: []string{"gpu"}, Priority: 75},
+	}
+	min := 50
+	sel := UniverseSelection{PriorityMin: &min, EntityTypes: []string{"ticker", "theme"}}
+	targets := sel.Select(items)
+	if len(targets) != 3 {
+		t.Fatalf("selected %d targets, want 3: %+v", len(targets), targets)
+	}
+
+	cfg := Phase1FetchConfig{SECTickers: []string{"AAPL"}}.WithTargets(targets)
+	if !reflect.DeepEqual(cfg.SECTickers, []string{"AAPL"}) {
+		t.Fatalf("sec tickers = %v", cfg.SECTickers)
+	}
+	if !reflect.DeepEqual(cfg.EDINETSecCodes, []string{"72030"}) {
+		t.Fatalf("edinet codes = %v", cfg.EDINETSecCodes)
+	}
+	if !reflect.DeepEqual(cfg.IRKeywords, []string{"GPU"}) {
+		t.Fatalf("ir keywords = %v", cfg.IRKeywords)
+	}
+
+	docs := []Document{
+		{DocID: "a", Ticker: "AAPL"},
+		{DocID: "b", Ticker: "7203"},
+		{DocID: "c", Title: "New gpu cluster"},
+		{DocID: "d", Title: "Unrelated"},
+		{DocID: "e", Ticker: "AAPL", UniverseItemID: "kept"},
+	}
+	TagDocuments(docs, items)
+	want := []string{"u1", "u2", "u5", "", "kept"}
+	for i, d := range docs {
+		if d.UniverseItemID != want[i] {
+			t.Errorf("doc %s tagged %q, want %q", d.DocID, d.UniverseItemID, want[i])
+		}
+	}
+}
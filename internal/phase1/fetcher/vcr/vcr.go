@@ -0,0 +1,237 @@
+// Package vcr records the HTTP traffic of the fetchers to cassette files and
+// replays it offline, so fetchers can be tested, and whole Phase1 runs
+// executed, against a recorded day of filings.
+package vcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Mode string
+
+const (
+	// ModeRecord sends requests upstream and appends every exchange to the
+	// cassette, replacing the one on disk.
+	ModeRecord Mode = "record"
+	// ModeReplay serves responses from the cassette and fails requests it
+	// has no recording for.
+	ModeReplay Mode = "replay"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeRecord, ModeReplay:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("vcr mode %q: must be record or replay", s)
+}
+
+// RedactedParams are query parameters that carry credentials. They are
+// dropped from recorded URLs and ignored when matching.
+var RedactedParams = []string{"Subscription-Key", "api_key", "apikey", "token"}
+
+// recordedHeaders are the response headers kept in cassettes.
+var recordedHeaders = []string{"Content-Type", "Retry-After", "Last-Modified", "ETag"}
+
+type Cassette struct {
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response holds the body as text, or base64 when it is not valid UTF-8.
+type Response struct {
+	Status     int               `json:"status"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	BodyBase64 string            `json:"body_base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper backed by one cassette file.
+type Recorder struct {
+	path  string
+	mode  Mode
+	inner http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	served   map[string]int
+}
+
+// New opens the cassette at path. Replay requires the file to exist; record
+// starts an empty cassette and sends requests through inner
+// (http.DefaultTransport when nil).
+func New(path string, mode Mode, inner http.RoundTripper) (*Recorder, error) {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, inner: inner, served: map[string]int{}}
+	switch mode {
+	case ModeRecord:
+		r.cassette.RecordedAt = time.Now().UTC()
+	case ModeReplay:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("vcr: %w", err)
+		}
+		if err := json.Unmarshal(b, &r.cassette); err != nil {
+			return nil, fmt.Errorf("vcr: %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("vcr mode %q: must be record or replay", mode)
+	}
+	return r, nil
+}
+
+// Client returns an HTTP client using the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r, Timeout: 30 * time.Second}
+}
+
+// Now is the time the cassette was recorded when replaying, so fetchers that
+// look back from today ask for the recorded dates.
+func (r *Recorder) Now() time.Time {
+	if r.mode == ModeReplay {
+		return r.cassette.RecordedAt
+	}
+	return time.Now()
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+// replay serves the recordings of a request in the order they were made and
+// repeats the last one once they are used up.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	key := requestKey(req.Method, req.URL)
+	r.mu.Lock()
+	var matches []Interaction
+	for _, it := range r.cassette.Interactions {
+		if u, err := url.Parse(it.Request.URL); err == nil && requestKey(it.Request.Method, u) == key {
+			matches = append(matches, it)
+		}
+	}
+	n := r.served[key]
+	r.served[key]++
+	r.mu.Unlock()
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("vcr: no recorded response for %s %s", req.Method, redact(req.URL))
+	}
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	return matches[n].Response.toHTTP(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	resp, err := r.inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rec := Response{Status: resp.StatusCode, Header: map[string]string{}}
+	for _, h := range recordedHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			rec.Header[h] = v
+		}
+	}
+	if utf8.Valid(body) {
+		rec.Body = string(body)
+	} else {
+		rec.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: redact(req.URL)},
+		Response: rec,
+	})
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+func (rec Response) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(rec.Body)
+	if rec.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(rec.BodyBase64); err != nil {
+			return nil, fmt.Errorf("vcr: %s: %w", redact(req.URL), err)
+		}
+	}
+	h := http.Header{}
+	for k, v := range rec.Header {
+		h.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func requestKey(method string, u *url.URL) string {
+	return method + " " + redact(u)
+}
+
+// redact drops RedactedParams and sorts the query so that parameter order
+// does not affect matching.
+func redact(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	for _, p := range RedactedParams {
+		q.Del(p)
+	}
+	c.RawQuery = q.Encode()
+	c.Fragment = ""
+	return c.String()
+}
@@ -0,0 +1,104 @@
+package vcr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, c *http.Client, url string) (int, string, error) {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(b), nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/feed":
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = io.WriteString(w, "<rss>"+r.URL.Query().Get("n")+"</rss>")
+		case "/bin":
+			_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+		default:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	rec, err := New(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls := []string{
+		srv.URL + "/feed?n=1&Subscription-Key=secret",
+		srv.URL + "/feed?n=1&Subscription-Key=secret",
+		srv.URL + "/bin",
+		srv.URL + "/busy",
+	}
+	var recorded []string
+	for _, u := range urls {
+		status, body, err := get(t, rec.Client(), u)
+		if err != nil {
+			t.Fatalf("record %s: %v", u, err)
+		}
+		recorded = append(recorded, body)
+		if u == urls[3] && status != http.StatusTooManyRequests {
+			t.Fatalf("status=%d", status)
+		}
+	}
+	srv.Close()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret") {
+		t.Fatal("cassette contains the subscription key")
+	}
+
+	rep, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Parameter order and credentials do not affect matching.
+	urls[0] = srv.URL + "/feed?Subscription-Key=other&n=1"
+	// The third request for the feed repeats the last recording.
+	for i, u := range append(urls, urls[0]) {
+		_, body, err := get(t, rep.Client(), u)
+		if err != nil {
+			t.Fatalf("replay %s: %v", u, err)
+		}
+		want := recorded[1]
+		if i < len(recorded) {
+			want = recorded[i]
+		}
+		if body != want {
+			t.Fatalf("replay %s = %q, want %q", u, body, want)
+		}
+	}
+	if hits != len(urls) {
+		t.Fatalf("replay reached the server: hits=%d", hits)
+	}
+	if _, _, err := get(t, rep.Client(), srv.URL+"/feed?n=2"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("unmatched request: err=%v", err)
+	}
+	if !rep.Now().Equal(rep.cassette.RecordedAt) {
+		t.Fatalf("replay Now=%s", rep.Now())
+	}
+}
@@ -0,0 +1,97 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Watermark is the newest document stored for a (source, entity) by an
+// earlier run. Fetchers given one skip older documents and the watermark
+// document itself; documents published at the same time are fetched again
+// and deduplicated as raw items.
+type Watermark struct {
+	PublishedAt time.Time
+	DocID       string
+}
+
+// DateRange is an inclusive range of calendar dates, "2006-01-02" in JSON.
+// To defaults to today.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+const dateLayout = "2006-01-02"
+
+func (r *DateRange) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	from, err := time.Parse(dateLayout, raw.From)
+	if err != nil {
+		return fmt.Errorf("backfill: from must be YYYY-MM-DD")
+	}
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if raw.To != "" {
+		if to, err = time.Parse(dateLayout, raw.To); err != nil {
+			return fmt.Errorf("backfill: to must be YYYY-MM-DD")
+		}
+	}
+	if to.Before(from) {
+		return fmt.Errorf("backfill: to is before from")
+	}
+	r.From, r.To = from, to
+	return nil
+}
+
+func (r DateRange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"from": r.From.Format(dateLayout), "to": r.To.Format(dateLayout)})
+}
+
+// Contains reports whether t falls on one of the range's dates (UTC).
+func (r DateRange) Contains(t time.Time) bool {
+	return !t.Before(r.From) && t.Before(r.To.AddDate(0, 0, 1))
+}
+
+// wanted reports whether a fetcher should return d: inside the backfill
+// range when there is one, otherwise newer than the watermark.
+func (cfg Phase1FetchConfig) wanted(d Document) bool {
+	if cfg.Backfill != nil {
+		return cfg.Backfill.Contains(d.PublishedAt)
+	}
+	if w := cfg.Watermark; w != nil {
+		return !d.PublishedAt.Before(w.PublishedAt) && d.DocID != w.DocID
+	}
+	return true
+}
+
+// limit sorts docs newest first and cuts them to max. Resuming from a
+// watermark it keeps the oldest documents, so that the watermark does not
+// pass the ones cut and the next run picks them up.
+func (cfg Phase1FetchConfig) limit(docs []Document, max int) []Document {
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].PublishedAt.After(docs[j].PublishedAt) })
+	if len(docs) <= max {
+		return docs
+	}
+	if cfg.Backfill == nil && cfg.Watermark != nil {
+		return docs[len(docs)-max:]
+	}
+	return docs[:max]
+}
+
+// NewWatermark returns the watermark of the newest document in docs.
+func NewWatermark(docs []Document) (Watermark, bool) {
+	var w Watermark
+	for _, d := range docs {
+		if d.PublishedAt.After(w.PublishedAt) {
+			w = Watermark{PublishedAt: d.PublishedAt, DocID: d.DocID}
+		}
+	}
+	return w, !w.PublishedAt.IsZero()
+}
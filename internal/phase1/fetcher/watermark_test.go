@@ -0,0 +1,74 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWanted(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	var backfill DateRange
+	if err := json.Unmarshal([]byte(`{"from":"2024-01-01","to":"2024-01-31"}`), &backfill); err != nil {
+		t.Fatal(err)
+	}
+	mark := &Watermark{PublishedAt: at("2024-02-01T10:00:00Z"), DocID: "last"}
+
+	cases := []struct {
+		name string
+		cfg  Phase1FetchConfig
+		doc  Document
+		want bool
+	}{
+		{"no watermark", Phase1FetchConfig{}, Document{DocID: "a", PublishedAt: at("2020-01-01T00:00:00Z")}, true},
+		{"older than watermark", Phase1FetchConfig{Watermark: mark}, Document{DocID: "a", PublishedAt: at("2024-02-01T09:59:59Z")}, false},
+		{"watermark document", Phase1FetchConfig{Watermark: mark}, Document{DocID: "last", PublishedAt: at("2024-02-01T10:00:00Z")}, false},
+		{"same time, other document", Phase1FetchConfig{Watermark: mark}, Document{DocID: "b", PublishedAt: at("2024-02-01T10:00:00Z")}, true},
+		{"newer", Phase1FetchConfig{Watermark: mark}, Document{DocID: "c", PublishedAt: at("2024-02-02T00:00:00Z")}, true},
+		{"backfill ignores watermark", Phase1FetchConfig{Watermark: mark, Backfill: &backfill}, Document{DocID: "d", PublishedAt: at("2024-01-31T23:00:00Z")}, true},
+		{"after backfill", Phase1FetchConfig{Backfill: &backfill}, Document{DocID: "e", PublishedAt: at("2024-02-01T00:00:00Z")}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.cfg.wanted(tc.doc); got != tc.want {
+			t.Errorf("%s: wanted = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	var bad DateRange
+	if err := json.Unmarshal([]byte(`{"from":"2024-02-01","to":"2024-01-01"}`), &bad); err == nil {
+		t.Error("expected error for to before from")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	day := func(d int) Document {
+		return Document{DocID: fmt.Sprint(d), PublishedAt: time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)}
+	}
+	ids := func(docs []Document) string {
+		var out []string
+		for _, d := range docs {
+			out = append(out, d.DocID)
+		}
+		return strings.Join(out, ",")
+	}
+	docs := func() []Document { return []Document{day(2), day(4), day(1), day(3)} }
+
+	if got := ids(Phase1FetchConfig{}.limit(docs(), 2)); got != "4,3" {
+		t.Errorf("without watermark = %s, want the newest 4,3", got)
+	}
+	resume := Phase1FetchConfig{Watermark: &Watermark{PublishedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}}
+	if got := ids(resume.limit(docs(), 2)); got != "2,1" {
+		t.Errorf("with watermark = %s, want the oldest 2,1", got)
+	}
+	if got := ids(resume.limit(docs(), 5)); got != "4,3,2,1" {
+		t.Errorf("under max = %s", got)
+	}
+}
@@ -0,0 +1,74 @@
+package sentiment
+
+// English is a small custom lexicon of about 230 hand-picked words common in
+// earnings releases and filings, sorted into the Loughran-McDonald
+// categories. It is not the Loughran-McDonald dictionary and its scores are
+// not comparable with it; for Loughran-McDonald scoring, load the dictionary
+// with LoadLexicon (SENTIMENT_LEXICON_EN in the API).
+var English = Lexicon{
+	Name:     "custom-en/v1",
+	Language: "en",
+	Positive: []string{
+		"achieve", "achievement", "advance", "advantage", "attractive", "benefit", "beneficial", "best",
+		"better", "boost", "breakthrough", "delight", "efficiency", "efficient", "enhance", "enhancement",
+		"enjoy", "excellent", "exceed", "exceptional", "favorable", "gain", "great", "greater", "highest",
+		"improve", "improvement", "impressive", "innovative", "leadership", "opportunity", "optimistic",
+		"outperform", "pleased", "positive", "profitable", "profitability", "progress", "rebound",
+		"record", "resolve", "robust", "strength", "strengthen", "strong", "stronger", "strongest",
+		"succeed", "success", "successful", "surpass", "upturn",
+	},
+	Negative: []string{
+		"adverse", "against", "bankruptcy", "breach", "challenge", "challenging", "closure", "concern",
+		"critical", "crisis", "curtail", "decline", "decrease", "default", "deficit", "delay", "delinquent",
+		"deteriorate", "deterioration", "difficult", "difficulty", "disappoint", "discontinue", "disruption",
+		"downgrade", "downturn", "drop", "fail", "failure", "fraud", "impair", "impairment", "inability",
+		"insolvency", "investigation", "layoff", "litigation", "lose", "loss", "lost", "misstatement",
+		"negative", "penalty", "poor", "problem", "recall", "restate", "restatement", "restructure",
+		"restructuring", "setback", "shortage", "shortfall", "slowdown", "slump", "terminate",
+		"termination", "unable", "unfavorable", "violation", "volatile", "weak", "weaken", "weaker",
+		"weakness", "worse", "worsen", "worst", "writedown", "write-down", "write-off",
+	},
+	Uncertainty: []string{
+		"almost", "anticipate", "apparent", "appear", "approximate", "approximately", "assume",
+		"assumption", "believe", "contingency", "contingent", "could", "depend", "dependent", "doubt",
+		"estimate", "fluctuate", "fluctuation", "imprecise", "indefinite", "likelihood", "may", "maybe",
+		"might", "nearly", "pending", "perhaps", "possible", "possibility", "possibly", "precaution",
+		"predict", "preliminary", "presume", "probable", "probably", "risk", "risky", "roughly",
+		"seldom", "sometimes", "speculative", "suggest", "tentative", "uncertain", "uncertainty",
+		"unclear", "unforeseen", "unknown", "unpredictable", "unproven", "unusual", "variability",
+		"variable", "vary", "volatility",
+	},
+	Litigious: []string{
+		"adjudicate", "allegation", "allege", "amend", "appeal", "arbitration", "attorney", "breach",
+		"claimant", "complaint", "counsel", "court", "defendant", "deposition", "enforceable",
+		"hereby", "herein", "hereunder", "indemnification", "indemnify", "infringe", "infringement",
+		"injunction", "judgment", "judicial", "jurisdiction", "jury", "lawsuit", "legal", "legally",
+		"legislation", "litigant", "litigate", "litigation", "plaintiff", "prosecute", "prosecution",
+		"regulator", "settlement", "statute", "statutory", "subpoena", "testimony", "thereof",
+		"tribunal", "verdict", "violate", "violation", "whereas",
+	},
+}
+
+// Japanese is a finance lexicon for Japanese disclosures (決算短信, 有価証券
+// 報告書, 適時開示). Replace it with LoadLexicon to tune it.
+var Japanese = Lexicon{
+	Name:     "ja-fin/v1",
+	Language: "ja",
+	Positive: []string{
+		"増収", "増益", "最高益", "過去最高", "上方修正", "増配", "復配", "黒字化", "黒字転換", "好調", "堅調",
+		"順調", "改善", "回復", "拡大", "伸長", "向上", "好転", "達成", "上回", "成長", "強化", "貢献", "好評",
+	},
+	Negative: []string{
+		"減収", "減益", "下方修正", "減配", "無配", "赤字", "損失", "減損", "特別損失", "悪化", "低迷", "減少",
+		"不振", "縮小", "下落", "下回", "停滞", "苦戦", "懸念", "債務超過", "不祥事", "不正", "延期", "中止",
+		"撤退", "毀損", "リコール", "遅延",
+	},
+	Uncertainty: []string{
+		"不確実", "不透明", "不確定", "見通し", "可能性", "見込み", "想定", "予想", "予測", "リスク", "変動",
+		"未定", "流動的", "見極め", "懸念", "おそれ", "恐れ",
+	},
+	Litigious: []string{
+		"訴訟", "提訴", "係争", "和解", "賠償", "損害賠償", "裁判", "判決", "控訴", "上告", "仲裁", "調停",
+		"差止", "告発", "行政処分", "課徴金", "法令違反", "違反", "訴え",
+	},
+}
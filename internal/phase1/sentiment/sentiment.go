@@ -0,0 +1,364 @@
+// Package sentiment scores the tone of document text with word lists: how
+// positive or negative it is, how uncertain and how litigious, using the
+// categories of the Loughran-McDonald finance dictionary.
+package sentiment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"investment_committee/internal/phase1/extract"
+)
+
+// Lexicon lists the words of each tone category for one language. A word
+// may be in several categories. English words are matched as whole words,
+// with their plural, past and -ing forms and -ly adverbs; Japanese terms as
+// substrings, longest first.
+type Lexicon struct {
+	Name        string   `json:"name"`
+	Language    string   `json:"language"`
+	Positive    []string `json:"positive"`
+	Negative    []string `json:"negative"`
+	Uncertainty []string `json:"uncertainty"`
+	Litigious   []string `json:"litigious"`
+}
+
+// LoadLexicon reads a lexicon from a JSON file with the fields of Lexicon.
+// Without a name, the lexicon is named after the file.
+func LoadLexicon(path string) (Lexicon, error) {
+	var l Lexicon
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return l, err
+	}
+	if err := json.Unmarshal(b, &l); err != nil {
+		return l, fmt.Errorf("%s: %w", path, err)
+	}
+	if l.Name == "" {
+		l.Name = "file:" + path
+	}
+	return l, nil
+}
+
+// Scores is the tone of a text. Polarity is (positive - negative) /
+// (positive + negative), from -1 to 1 and 0 without either; Uncertainty and
+// Litigiousness are the shares of the text's words in those categories.
+type Scores struct {
+	Lexicon        string  `json:"lexicon"`
+	Words          int     `json:"words"`
+	PositiveWords  int     `json:"positive_words"`
+	NegativeWords  int     `json:"negative_words"`
+	UncertainWords int     `json:"uncertain_words"`
+	LitigiousWords int     `json:"litigious_words"`
+	Polarity       float64 `json:"polarity"`
+	Uncertainty    float64 `json:"uncertainty"`
+	Litigiousness  float64 `json:"litigiousness"`
+}
+
+// Categories of a lexicon word, as a bit set.
+const (
+	positive uint8 = 1 << iota
+	negative
+	uncertain
+	litigious
+)
+
+// scanLimit bounds the runes of text that are scored.
+const scanLimit = 200000
+
+// Scorer scores text with a lexicon per language.
+type Scorer struct {
+	lexicons map[string]*compiled
+}
+
+type compiled struct {
+	name  string
+	words map[string]uint8
+	// terms are the Japanese terms by first rune, longest first.
+	terms map[rune][]string
+}
+
+// New prepares the lexicons; a later lexicon for the same language replaces
+// an earlier one.
+func New(lexicons ...Lexicon) (*Scorer, error) {
+	s := &Scorer{lexicons: map[string]*compiled{}}
+	for _, l := range lexicons {
+		if l.Language == "" {
+			return nil, errors.New("sentiment: lexicon " + l.Name + " has no language")
+		}
+		c := &compiled{name: l.Name, words: map[string]uint8{}}
+		for _, list := range []struct {
+			words []string
+			cat   uint8
+		}{{l.Positive, positive}, {l.Negative, negative}, {l.Uncertainty, uncertain}, {l.Litigious, litigious}} {
+			for _, w := range list.words {
+				if w = normalizeWord(w, l.Language); w != "" {
+					c.words[w] |= list.cat
+				}
+			}
+		}
+		if len(c.words) == 0 {
+			return nil, errors.New("sentiment: lexicon " + l.Name + " is empty")
+		}
+		if l.Language == "ja" {
+			c.terms = map[rune][]string{}
+			for w := range c.words {
+				r, _ := utf8.DecodeRuneInString(w)
+				c.terms[r] = append(c.terms[r], w)
+			}
+			for _, ts := range c.terms {
+				sort.Slice(ts, func(i, j int) bool {
+					if len(ts[i]) != len(ts[j]) {
+						return len(ts[i]) > len(ts[j])
+					}
+					return ts[i] < ts[j]
+				})
+			}
+		}
+		s.lexicons[l.Language] = c
+	}
+	return s, nil
+}
+
+// Default returns a scorer with the built-in English and Japanese lexicons.
+func Default() *Scorer {
+	s, err := New(English, Japanese)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Score returns the tone of text in language ("en", "ja"), as detected by
+// extract.DetectLanguage. It reports false when there is no lexicon for the
+// language or the text has no words.
+func (s *Scorer) Score(text, language string) (Scores, bool) {
+	if s == nil {
+		return Scores{}, false
+	}
+	c, ok := s.lexicons[language]
+	if !ok {
+		return Scores{}, false
+	}
+	if rs := []rune(text); len(rs) > scanLimit {
+		text = string(rs[:scanLimit])
+	}
+	var out Scores
+	if c.terms != nil {
+		out = c.scoreJapanese(extract.Normalize(text))
+	} else {
+		out = c.scoreWords(text)
+	}
+	if out.Words == 0 {
+		return Scores{}, false
+	}
+	out.Lexicon = c.name
+	if n := out.PositiveWords + out.NegativeWords; n > 0 {
+		out.Polarity = round(float64(out.PositiveWords-out.NegativeWords) / float64(n))
+	}
+	out.Uncertainty = round(float64(out.UncertainWords) / float64(out.Words))
+	out.Litigiousness = round(float64(out.LitigiousWords) / float64(out.Words))
+	return out, true
+}
+
+func (s *Scores) add(cat uint8, negated bool) {
+	// As in Loughran-McDonald, a negated positive word counts as neither
+	// positive nor negative.
+	if cat&positive != 0 && !negated {
+		s.PositiveWords++
+	}
+	if cat&negative != 0 {
+		s.NegativeWords++
+	}
+	if cat&uncertain != 0 {
+		s.UncertainWords++
+	}
+	if cat&litigious != 0 {
+		s.LitigiousWords++
+	}
+}
+
+// negationWindow is the number of words before a positive English word in
+// which a negation cancels it.
+const negationWindow = 3
+
+var negations = map[string]bool{
+	"no": true, "not": true, "none": true, "neither": true, "never": true, "nobody": true, "cannot": true, "without": true,
+}
+
+func (c *compiled) scoreWords(text string) Scores {
+	var out Scores
+	lastNegation := -negationWindow - 1
+	for i, w := range englishWords(text) {
+		out.Words++
+		if negations[w] || strings.HasSuffix(w, "n't") {
+			lastNegation = i
+			continue
+		}
+		if cat := c.lookup(w); cat != 0 {
+			out.add(cat, i-lastNegation <= negationWindow)
+		}
+	}
+	return out
+}
+
+// lookup finds w or, failing that, the stem of an inflected form of it.
+func (c *compiled) lookup(w string) uint8 {
+	if cat, ok := c.words[w]; ok {
+		return cat
+	}
+	w = strings.TrimSuffix(w, "'s")
+	var stems []string
+	switch {
+	case strings.HasSuffix(w, "ies"):
+		stems = append(stems, w[:len(w)-3]+"y")
+	case strings.HasSuffix(w, "es"):
+		stems = append(stems, w[:len(w)-2], w[:len(w)-1])
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+		stems = append(stems, w[:len(w)-1])
+	case strings.HasSuffix(w, "ied"):
+		stems = append(stems, w[:len(w)-3]+"y")
+	case strings.HasSuffix(w, "ed"):
+		stems = append(stems, w[:len(w)-2], w[:len(w)-1], undouble(w[:len(w)-2]))
+	case strings.HasSuffix(w, "ing"):
+		stems = append(stems, w[:len(w)-3], w[:len(w)-3]+"e", undouble(w[:len(w)-3]))
+	case strings.HasSuffix(w, "ly"):
+		stems = append(stems, w[:len(w)-2], w[:len(w)-2]+"e")
+	}
+	for _, s := range stems {
+		if len(s) > 2 {
+			if cat, ok := c.words[s]; ok {
+				return cat
+			}
+		}
+	}
+	return 0
+}
+
+// undouble drops the doubled final consonant of "stopp" (stopped).
+func undouble(s string) string {
+	if n := len(s); n > 2 && s[n-1] == s[n-2] {
+		return s[:n-1]
+	}
+	return s
+}
+
+// englishWords splits text into lower-case words of letters, keeping inner
+// apostrophes ("isn't") and hyphens ("write-off").
+func englishWords(text string) []string {
+	var (
+		words []string
+		b     strings.Builder
+	)
+	flush := func() {
+		w := strings.Trim(b.String(), "'-")
+		if w != "" {
+			words = append(words, w)
+		}
+		b.Reset()
+	}
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLetter(r):
+			b.WriteRune(unicode.ToLower(r))
+		case (r == '\'' || r == '’' || r == '-') && b.Len() > 0:
+			if r == '’' {
+				r = '\''
+			}
+			b.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// japaneseNegations follow a term to negate it, as in 改善しない or
+// 回復が見られず.
+var japaneseNegations = []string{"ない", "なかった", "ず", "ません"}
+
+// negationRunes is how far after a Japanese term a negation is looked for,
+// within the clause.
+const negationRunes = 6
+
+func (c *compiled) scoreJapanese(text string) Scores {
+	var out Scores
+	rs := []rune(text)
+	cjk, latin := 0, 0
+	inLatin := false
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			cjk++
+			inLatin = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inLatin {
+				latin++
+			}
+			inLatin = true
+		default:
+			inLatin = false
+		}
+		matched := ""
+		for _, t := range c.terms[r] {
+			if strings.HasPrefix(string(rs[i:min(len(rs), i+utf8.RuneCountInString(t))]), t) {
+				matched = t
+				break
+			}
+		}
+		if matched == "" {
+			i++
+			continue
+		}
+		n := utf8.RuneCountInString(matched)
+		out.add(c.words[matched], negatedAfter(rs[i+n:]))
+		// The rest of the term still counts towards the words.
+		for _, r := range rs[i+1 : i+n] {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+				cjk++
+			}
+		}
+		i += n
+	}
+	// Japanese words average about two characters.
+	out.Words = (cjk+1)/2 + latin
+	return out
+}
+
+func negatedAfter(rs []rune) bool {
+	var b strings.Builder
+	for i, r := range rs {
+		if i == negationRunes || strings.ContainsRune("。、，．,.!?！？\n", r) {
+			break
+		}
+		b.WriteRune(r)
+	}
+	clause := b.String()
+	for _, n := range japaneseNegations {
+		if strings.Contains(clause, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeWord(w, language string) string {
+	w = strings.TrimSpace(w)
+	if language == "ja" {
+		return extract.Normalize(w)
+	}
+	return strings.ToLower(w)
+}
+
+func round(x float64) float64 {
+	return math.Round(x*10000) / 10000
+}
@@ -0,0 +1,40 @@
+package sentiment
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	s := Default()
+	cases := []struct {
+		name, text, lang string
+		want             Scores
+	}{
+		{"english inflections",
+			"Revenue improved and margins strengthened, but impairments and losses weakened results.", "en",
+			Scores{Words: 11, PositiveWords: 2, NegativeWords: 3, Polarity: -0.2}},
+		{"negated positive",
+			"We did not achieve our targets, and results may not be favorable.", "en",
+			Scores{Words: 12, UncertainWords: 1, Uncertainty: 0.0833}},
+		{"litigious",
+			"The plaintiff filed a lawsuit; the court granted an injunction.", "en",
+			Scores{Words: 10, LitigiousWords: 4, Litigiousness: 0.4}},
+		{"japanese longest match",
+			"当期は増収増益となり、特別損失を計上した。今後の見通しは不透明です。", "ja",
+			Scores{Words: 16, PositiveWords: 2, NegativeWords: 1, UncertainWords: 2, Polarity: 0.3333, Uncertainty: 0.125}},
+		{"japanese negation",
+			"業績の改善が見られず、訴訟リスクが残る。", "ja",
+			Scores{Words: 9, UncertainWords: 1, LitigiousWords: 1, Uncertainty: 0.1111, Litigiousness: 0.1111}},
+	}
+	for _, tc := range cases {
+		got, ok := s.Score(tc.text, tc.lang)
+		if !ok {
+			t.Fatalf("%s: not scored", tc.name)
+		}
+		got.Lexicon = ""
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+	if _, ok := s.Score("text", "und"); ok {
+		t.Error("scored a language without a lexicon")
+	}
+}
@@ -0,0 +1,265 @@
+package xbrl
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Metrics reported as key facts, in the order of metricConcepts.
+const (
+	MetricRevenue         = "revenue"
+	MetricOperatingIncome = "operating_income"
+	MetricNetIncome       = "net_income"
+	MetricEPSBasic        = "eps_basic"
+	MetricEPSDiluted      = "eps_diluted"
+)
+
+// metricConcepts lists, per metric, the concepts that report it in order of
+// preference: US-GAAP, IFRS, the EDINET taxonomies (financial statements,
+// the summary of business results, IFRS) and the TDnet earnings release
+// (tanshin) taxonomy.
+var metricConcepts = []struct {
+	metric   string
+	concepts []string
+}{
+	{MetricRevenue, []string{
+		"us-gaap:Revenues",
+		"us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax",
+		"us-gaap:RevenueFromContractWithCustomerIncludingAssessedTax",
+		"us-gaap:SalesRevenueNet",
+		"ifrs-full:Revenue",
+		"jppfs_cor:NetSales",
+		"jppfs_cor:OperatingRevenue1",
+		"jpcrp_cor:NetSalesSummaryOfBusinessResults",
+		"jpcrp_cor:RevenueIFRSSummaryOfBusinessResults",
+		"jpcrp_cor:RevenuesUSGAAPSummaryOfBusinessResults",
+		"jpigp_cor:RevenueIFRS",
+		"tse-ed-t:NetSales",
+		"tse-ed-t:OperatingRevenues",
+		"tse-ed-t:SalesIFRS",
+	}},
+	{MetricOperatingIncome, []string{
+		"us-gaap:OperatingIncomeLoss",
+		"ifrs-full:ProfitLossFromOperatingActivities",
+		"jppfs_cor:OperatingIncome",
+		"jpigp_cor:OperatingProfitLossIFRS",
+		"tse-ed-t:OperatingIncome",
+		"tse-ed-t:OperatingIncomeIFRS",
+	}},
+	{MetricNetIncome, []string{
+		"us-gaap:NetIncomeLoss",
+		"ifrs-full:ProfitLossAttributableToOwnersOfParent",
+		"jppfs_cor:ProfitLossAttributableToOwnersOfParent",
+		"jpcrp_cor:ProfitLossAttributableToOwnersOfParentSummaryOfBusinessResults",
+		"jpigp_cor:ProfitLossAttributableToOwnersOfParentIFRS",
+		"tse-ed-t:ProfitAttributableToOwnersOfParent",
+		"tse-ed-t:ProfitAttributableToOwnersOfParentIFRS",
+	}},
+	{MetricEPSBasic, []string{
+		"us-gaap:EarningsPerShareBasic",
+		"us-gaap:EarningsPerShareBasicAndDiluted",
+		"ifrs-full:BasicEarningsLossPerShare",
+		"jppfs_cor:BasicEarningsLossPerShare",
+		"jpcrp_cor:BasicEarningsLossPerShareSummaryOfBusinessResults",
+		"jpigp_cor:BasicEarningsLossPerShareIFRS",
+		"tse-ed-t:NetIncomePerShare",
+		"tse-ed-t:BasicEarningsPerShareIFRS",
+	}},
+	{MetricEPSDiluted, []string{
+		"us-gaap:EarningsPerShareDiluted",
+		"ifrs-full:DilutedEarningsLossPerShare",
+		"jppfs_cor:DilutedEarningsPerShare",
+		"jpcrp_cor:DilutedEarningsPerShareSummaryOfBusinessResults",
+		"jpigp_cor:DilutedEarningsLossPerShareIFRS",
+		"tse-ed-t:DilutedNetIncomePerShare",
+		"tse-ed-t:DilutedEarningsPerShareIFRS",
+	}},
+}
+
+// coreMetrics are the metrics that decide a report's confidence.
+var coreMetrics = []string{MetricRevenue, MetricOperatingIncome, MetricEPSBasic}
+
+// Kinds of key facts.
+const (
+	KindActual   = "actual"
+	KindGuidance = "guidance"
+)
+
+// forecastMembers mark guidance contexts in the TDnet taxonomy; the upper
+// and lower members bound a range forecast. Only TDnet tanshin documents
+// tag forecasts this way: EDINET reports and US-GAAP filings have no
+// forecast contexts, so guidance is never read from them.
+var forecastMembers = map[string]string{
+	"ForecastMember": "",
+	"UpperMember":    "upper",
+	"LowerMember":    "lower",
+}
+
+// neutralMembers do not narrow a fact to part of the entity.
+var neutralMembers = map[string]bool{
+	"ConsolidatedMember": true,
+	"ResultMember":       true,
+	"CurrentMember":      true,
+}
+
+// KeyFact is one metric of an earnings report.
+type KeyFact struct {
+	Metric      string  `json:"metric"`
+	Kind        string  `json:"kind"`
+	Bound       string  `json:"bound,omitempty"`
+	Concept     string  `json:"concept"`
+	Value       float64 `json:"value"`
+	Unit        string  `json:"unit"`
+	Decimals    string  `json:"decimals,omitempty"`
+	PeriodStart string  `json:"period_start,omitempty"`
+	PeriodEnd   string  `json:"period_end"`
+}
+
+// Report holds the key facts of the latest reported period and any guidance
+// in a document.
+type Report struct {
+	// PeriodEnd is the end of the latest period with actual results.
+	PeriodEnd  time.Time
+	Facts      []KeyFact
+	Confidence float64
+}
+
+// HasGuidance reports whether the report includes forecast facts.
+func (r *Report) HasGuidance() bool {
+	for _, f := range r.Facts {
+		if f.Kind == KindGuidance {
+			return true
+		}
+	}
+	return false
+}
+
+// Earnings selects the key facts of inst: for each metric, the facts about
+// the whole entity for the latest period end (a quarterly report may give
+// both the quarter and the year to date), plus forecasts. Forecasts are only
+// found in TDnet tanshin documents (see forecastMembers); guidance in EDINET
+// and US-GAAP filings is not extracted. It returns nil when the document
+// reports none of the metrics.
+//
+// Confidence is 0.6 plus 0.1 for each of revenue, operating income and basic
+// EPS among the actual facts, so a full set of results scores 0.9 and a
+// document with guidance only scores 0.6.
+func Earnings(inst *Instance) *Report {
+	rank := map[string]int{}
+	metricOf := map[string]string{}
+	for _, mc := range metricConcepts {
+		for i, c := range mc.concepts {
+			rank[c] = i
+			metricOf[c] = mc.metric
+		}
+	}
+
+	type key struct {
+		metric, kind, bound string
+		start, end          time.Time
+	}
+	best := map[key]Fact{}
+	var latest time.Time
+	for _, f := range inst.Facts {
+		metric, ok := metricOf[f.Concept]
+		if !ok || f.Context.End.IsZero() {
+			continue
+		}
+		kind, bound, ok := classify(f.Context)
+		if !ok {
+			continue
+		}
+		k := key{metric, kind, bound, f.Context.Start, f.Context.End}
+		if cur, ok := best[k]; ok && rank[cur.Concept] <= rank[f.Concept] {
+			continue
+		}
+		best[k] = f
+		if kind == KindActual && f.Context.End.After(latest) {
+			latest = f.Context.End
+		}
+	}
+
+	r := &Report{PeriodEnd: latest}
+	found := map[string]bool{}
+	for k, f := range best {
+		if k.kind == KindActual && !k.end.Equal(latest) {
+			continue
+		}
+		if k.kind == KindGuidance && !latest.IsZero() && !k.end.After(latest) {
+			continue
+		}
+		if k.kind == KindActual {
+			found[k.metric] = true
+		}
+		kf := KeyFact{
+			Metric:    k.metric,
+			Kind:      k.kind,
+			Bound:     k.bound,
+			Concept:   f.Concept,
+			Value:     f.Value,
+			Unit:      f.Unit,
+			Decimals:  f.Decimals,
+			PeriodEnd: k.end.Format(dateLayout),
+		}
+		if !k.start.IsZero() {
+			kf.PeriodStart = k.start.Format(dateLayout)
+		}
+		r.Facts = append(r.Facts, kf)
+	}
+	if len(r.Facts) == 0 {
+		return nil
+	}
+
+	order := map[string]int{}
+	for i, mc := range metricConcepts {
+		order[mc.metric] = i
+	}
+	sort.Slice(r.Facts, func(i, j int) bool {
+		a, b := r.Facts[i], r.Facts[j]
+		if a.Kind != b.Kind {
+			return a.Kind == KindActual
+		}
+		if a.Metric != b.Metric {
+			return order[a.Metric] < order[b.Metric]
+		}
+		if a.PeriodEnd != b.PeriodEnd {
+			return a.PeriodEnd < b.PeriodEnd
+		}
+		// Shorter periods (the quarter) before the year to date.
+		if a.PeriodStart != b.PeriodStart {
+			return a.PeriodStart > b.PeriodStart
+		}
+		return a.Bound < b.Bound
+	})
+
+	n := 0
+	for _, m := range coreMetrics {
+		if found[m] {
+			n++
+		}
+	}
+	r.Confidence = float64(6+n) / 10
+	return r
+}
+
+// classify returns the kind of a duration context: actual results for the
+// whole entity, guidance for forecast members, and !ok for segments,
+// non-consolidated figures and other breakdowns.
+func classify(c Context) (kind, bound string, ok bool) {
+	kind = KindActual
+	for axis, member := range c.Dimensions {
+		if b, isForecast := forecastMembers[member]; isForecast {
+			kind = KindGuidance
+			if b != "" {
+				bound = b
+			}
+			continue
+		}
+		if neutralMembers[member] || strings.HasSuffix(axis, "ResultForecastAxis") {
+			continue
+		}
+		return "", "", false
+	}
+	return kind, bound, true
+}
@@ -0,0 +1,433 @@
+// Package xbrl reads numeric facts from XBRL instance documents and inline
+// XBRL (iXBRL) filings, as published on EDGAR and EDINET.
+package xbrl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Context is an xbrli:context: a period and, for facts that are not about
+// the reporting entity as a whole, dimension members.
+type Context struct {
+	ID string
+	// Start and End bound a duration; Instant is set instead for point in
+	// time facts.
+	Start, End time.Time
+	Instant    time.Time
+	// Dimensions maps an axis to its member, both as local names.
+	Dimensions map[string]string
+}
+
+// PeriodEnd returns the end of a duration or the instant.
+func (c Context) PeriodEnd() time.Time {
+	if !c.Instant.IsZero() {
+		return c.Instant
+	}
+	return c.End
+}
+
+// Fact is a numeric fact with its scale and sign applied.
+type Fact struct {
+	// Concept is the prefixed element name, with the prefix normalized to
+	// the taxonomy (us-gaap, ifrs-full, jppfs_cor, ...).
+	Concept  string
+	Value    float64
+	Decimals string
+	Unit     string
+	Context  Context
+}
+
+// Instance holds the numeric facts of one document.
+type Instance struct {
+	Facts []Fact
+}
+
+// ErrNotXBRL is returned for documents without XBRL contexts.
+var ErrNotXBRL = errors.New("xbrl: no contexts in document")
+
+// IsInstance reports whether b looks like a standalone XBRL instance (not an
+// inline XBRL page).
+func IsInstance(b []byte) bool {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	dec.Strict = false
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return false
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local == "xbrl"
+		}
+	}
+}
+
+// Parse reads an XBRL instance or an inline XBRL document. Facts that are
+// nil, non-numeric or in a format it cannot read are skipped.
+func Parse(b []byte) (*Instance, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	p := parser{
+		dec:      dec,
+		prefixes: map[string]string{},
+		contexts: map[string]Context{},
+		units:    map[string]string{},
+		inline:   !IsInstance(b),
+	}
+	if err := p.run(); err != nil {
+		return nil, err
+	}
+	if len(p.contexts) == 0 {
+		return nil, ErrNotXBRL
+	}
+	inst := &Instance{}
+	for _, rf := range p.raw {
+		ctx, ok := p.contexts[rf.contextRef]
+		if !ok {
+			continue
+		}
+		inst.Facts = append(inst.Facts, Fact{
+			Concept:  rf.concept,
+			Value:    rf.value,
+			Decimals: rf.decimals,
+			Unit:     p.units[rf.unitRef],
+			Context:  ctx,
+		})
+	}
+	return inst, nil
+}
+
+type rawFact struct {
+	concept    string
+	value      float64
+	decimals   string
+	contextRef string
+	unitRef    string
+}
+
+type parser struct {
+	dec      *xml.Decoder
+	prefixes map[string]string // prefix -> namespace URI
+	contexts map[string]Context
+	units    map[string]string
+	raw      []rawFact
+	inline   bool
+}
+
+func (p *parser) run() error {
+	for {
+		tok, err := p.dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("xbrl: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		p.declare(se)
+		switch {
+		case se.Name.Local == "context" && isXBRLI(se.Name.Space):
+			c, err := p.context(se)
+			if err != nil {
+				return err
+			}
+			p.contexts[c.ID] = c
+		case se.Name.Local == "unit" && isXBRLI(se.Name.Space):
+			id, u, err := p.unit(se)
+			if err != nil {
+				return err
+			}
+			p.units[id] = u
+		case p.inline && se.Name.Local == "nonFraction":
+			if err := p.nonFraction(se); err != nil {
+				return err
+			}
+		case !p.inline && attr(se, "contextRef") != "" && attr(se, "unitRef") != "":
+			if err := p.instanceFact(se); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// declare records the namespace prefixes declared on an element, which
+// inline XBRL needs to resolve the prefixes in name attributes.
+func (p *parser) declare(se xml.StartElement) {
+	for _, a := range se.Attr {
+		if a.Name.Space == "xmlns" {
+			p.prefixes[a.Name.Local] = a.Value
+		}
+	}
+}
+
+func (p *parser) context(se xml.StartElement) (Context, error) {
+	c := Context{ID: attr(se, "id"), Dimensions: map[string]string{}}
+	var (
+		field *time.Time
+		axis  string
+		text  strings.Builder
+	)
+	for {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return c, fmt.Errorf("xbrl: context %s: %w", c.ID, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			switch t.Name.Local {
+			case "startDate":
+				field = &c.Start
+			case "endDate":
+				field = &c.End
+			case "instant":
+				field = &c.Instant
+			case "explicitMember", "typedMember":
+				axis = localName(attr(t, "dimension"))
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			v := strings.TrimSpace(text.String())
+			switch {
+			case field != nil:
+				// Dates may carry a time; only the date part is used.
+				if d, err := time.Parse(dateLayout, v[:min(len(v), len(dateLayout))]); err == nil {
+					*field = d
+				}
+			case axis != "":
+				c.Dimensions[axis] = localName(v)
+			}
+			field, axis = nil, ""
+			text.Reset()
+			if t.Name.Local == "context" {
+				return c, nil
+			}
+		}
+	}
+}
+
+// unit returns a unit's id and its measures as "USD", "shares" or
+// "USD/shares".
+func (p *parser) unit(se xml.StartElement) (string, string, error) {
+	id := attr(se, "id")
+	var (
+		num, den []string
+		inDen    bool
+		text     strings.Builder
+	)
+	for {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return id, "", fmt.Errorf("xbrl: unit %s: %w", id, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			if t.Name.Local == "unitDenominator" {
+				inDen = true
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "measure":
+				m := localName(strings.TrimSpace(text.String()))
+				if inDen {
+					den = append(den, m)
+				} else {
+					num = append(num, m)
+				}
+			case "unit":
+				u := strings.Join(num, "*")
+				if len(den) > 0 {
+					u += "/" + strings.Join(den, "*")
+				}
+				return id, u, nil
+			}
+			text.Reset()
+		}
+	}
+}
+
+// nonFraction reads an ix:nonFraction element, whose text is the displayed
+// number: format says how to read it, scale is the power of ten it was
+// divided by and sign="-" negates it.
+func (p *parser) nonFraction(se xml.StartElement) error {
+	text, err := p.innerText("nonFraction")
+	if err != nil {
+		return err
+	}
+	if attr(se, "nil") == "true" {
+		return nil
+	}
+	v, ok := parseDisplayed(text, attr(se, "format"))
+	if !ok {
+		return nil
+	}
+	if s := attr(se, "scale"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		v *= math.Pow10(n)
+	}
+	if attr(se, "sign") == "-" {
+		v = -v
+	}
+	p.raw = append(p.raw, rawFact{
+		concept:    p.concept(attr(se, "name")),
+		value:      v,
+		decimals:   attr(se, "decimals"),
+		contextRef: attr(se, "contextRef"),
+		unitRef:    attr(se, "unitRef"),
+	})
+	return nil
+}
+
+// instanceFact reads a numeric fact element of an XBRL instance.
+func (p *parser) instanceFact(se xml.StartElement) error {
+	text, err := p.innerText(se.Name.Local)
+	if err != nil {
+		return err
+	}
+	if attr(se, "nil") == "true" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return nil
+	}
+	p.raw = append(p.raw, rawFact{
+		concept:    family(se.Name.Space, "") + ":" + se.Name.Local,
+		value:      v,
+		decimals:   attr(se, "decimals"),
+		contextRef: attr(se, "contextRef"),
+		unitRef:    attr(se, "unitRef"),
+	})
+	return nil
+}
+
+// innerText returns the text up to the end of the current element named
+// local, including the text of nested elements.
+func (p *parser) innerText(local string) (string, error) {
+	var b strings.Builder
+	depth := 1
+	for depth > 0 {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("xbrl: %s: %w", local, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			b.Write(t)
+		}
+	}
+	return b.String(), nil
+}
+
+// concept normalizes the prefix of an inline XBRL name attribute.
+func (p *parser) concept(name string) string {
+	prefix, local, ok := strings.Cut(name, ":")
+	if !ok {
+		return name
+	}
+	return family(p.prefixes[prefix], prefix) + ":" + local
+}
+
+// families maps a namespace URI fragment to the taxonomy prefix used in
+// Concept, so that facts match whatever prefix a filer declared.
+var families = []struct{ fragment, prefix string }{
+	{"fasb.org/us-gaap", "us-gaap"},
+	{"xbrl.sec.gov/dei", "dei"},
+	{"xbrl.ifrs.org", "ifrs-full"},
+	{"/jppfs_cor", "jppfs_cor"},
+	{"/jpcrp_cor", "jpcrp_cor"},
+	{"/jpigp_cor", "jpigp_cor"},
+	{"/jpdei_cor", "jpdei_cor"},
+	{"/tse-ed-t", "tse-ed-t"},
+}
+
+func family(namespace, prefix string) string {
+	for _, f := range families {
+		if strings.Contains(namespace, f.fragment) {
+			return f.prefix
+		}
+	}
+	if prefix != "" {
+		return prefix
+	}
+	return namespace
+}
+
+func isXBRLI(namespace string) bool {
+	return namespace == "" || strings.Contains(namespace, "xbrl.org/2003/instance")
+}
+
+func attr(se xml.StartElement, local string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func localName(qname string) string {
+	if i := strings.LastIndexByte(qname, ':'); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+// parseDisplayed reads a number as displayed in inline XBRL. The comma
+// decimal formats use "." or spaces as group separators; the zero dash
+// formats display zero as a dash.
+func parseDisplayed(text, format string) (float64, bool) {
+	s := strings.TrimSpace(text)
+	f := strings.ToLower(localName(format))
+	switch f {
+	case "fixed-zero", "zerodash", "fixedzero":
+		return 0, true
+	}
+	if s == "-" || s == "—" || s == "–" || s == "－" {
+		return 0, true
+	}
+	comma := strings.Contains(f, "comma")
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r >= '０' && r <= '９':
+			b.WriteRune(r - '０' + '0')
+		case r == '.' && !comma, r == ',' && comma:
+			b.WriteByte('.')
+		}
+	}
+	if b.Len() == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(b.String(), 64)
+	return v, err == nil
+}
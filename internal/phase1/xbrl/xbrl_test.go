@@ -0,0 +1,76 @@
+package xbrl
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func parseFile(t *testing.T, name string) *Instance {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inst
+}
+
+func TestEarningsInline(t *testing.T) {
+	r := Earnings(parseFile(t, "10q_inline.htm"))
+	if r == nil {
+		t.Fatal("no report")
+	}
+	want := []KeyFact{
+		{Metric: MetricRevenue, Kind: KindActual, Concept: "us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax",
+			Value: 1234.5e6, Unit: "USD", Decimals: "-6", PeriodStart: "2024-07-01", PeriodEnd: "2024-09-28"},
+		{Metric: MetricRevenue, Kind: KindActual, Concept: "us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax",
+			Value: 3600e6, Unit: "USD", Decimals: "-6", PeriodStart: "2023-12-31", PeriodEnd: "2024-09-28"},
+		{Metric: MetricOperatingIncome, Kind: KindActual, Concept: "us-gaap:OperatingIncomeLoss",
+			Value: -12.3e6, Unit: "USD", Decimals: "-6", PeriodStart: "2024-07-01", PeriodEnd: "2024-09-28"},
+		{Metric: MetricOperatingIncome, Kind: KindActual, Concept: "us-gaap:OperatingIncomeLoss",
+			Value: 0, Unit: "USD", Decimals: "-6", PeriodStart: "2023-12-31", PeriodEnd: "2024-09-28"},
+		{Metric: MetricEPSDiluted, Kind: KindActual, Concept: "us-gaap:EarningsPerShareDiluted",
+			Value: 0.42, Unit: "USD/shares", Decimals: "2", PeriodStart: "2024-07-01", PeriodEnd: "2024-09-28"},
+	}
+	if !reflect.DeepEqual(r.Facts, want) {
+		t.Fatalf("facts:\n got %+v\nwant %+v", r.Facts, want)
+	}
+	// Basic EPS is nil in the filing.
+	if r.Confidence != 0.8 {
+		t.Errorf("confidence = %v", r.Confidence)
+	}
+}
+
+func TestEarningsInstanceWithGuidance(t *testing.T) {
+	r := Earnings(parseFile(t, "tanshin.xbrl"))
+	if r == nil {
+		t.Fatal("no report")
+	}
+	got := map[string]float64{}
+	for _, f := range r.Facts {
+		got[f.Kind+" "+f.Metric+" "+f.PeriodEnd] = f.Value
+	}
+	want := map[string]float64{
+		"actual revenue 2025-03-31":            48036704000000,
+		"actual operating_income 2025-03-31":   4795586000000,
+		"actual eps_basic 2025-03-31":          359.56,
+		"guidance revenue 2026-03-31":          48500000000000,
+		"guidance operating_income 2026-03-31": 3800000000000,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("facts = %v", got)
+	}
+	if !r.HasGuidance() || r.Confidence != 0.9 || r.Facts[0].Concept != "tse-ed-t:NetSales" {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestParseNotXBRL(t *testing.T) {
+	if _, err := Parse([]byte("<html><body><p>Press release</p></body></html>")); err != ErrNotXBRL {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -17,7 +17,7 @@ Write-Host "== smoke: Run -> Events -> Handoff ==" -ForegroundColor Cyan
 
 $run = Invoke-RestMethod -Method Post -Uri "$base/phase1/runs" -Headers $headersJson -Body (@{
   mode = "manual"
-  config = @{ sources=@("ir"); max_items_per_source=1 }
+  config = @{ sources=@("ir"); max_items_per_source=1; auto_finalize=$false }
 } | ConvertTo-Json -Depth 10)
 
 $runId = $run.run_id
@@ -28,7 +28,35 @@ Invoke-RestMethod -Method Post -Uri "$base/phase1/runs/$runId/events" -Headers $
   payload = @{ note="smoke" }
 } | ConvertTo-Json -Depth 10) | Out-Null
 
-$handoff = Invoke-RestMethod -Method Post -Uri "$base/handoffs" -Headers $headersJson -Body (@{
+# finalize once the executor has fetched the sources, then let the API build the packet
+$deadline = (Get-Date).AddSeconds(60)
+while ($true) {
+  try {
+    Invoke-RestMethod -Method Post -Uri "$base/phase1/runs/$runId/finalize" -Headers $headers | Out-Null
+    break
+  } catch {
+    if ((Get-Date) -gt $deadline) { throw }
+    Start-Sleep -Milliseconds 500
+  }
+}
+
+$handoff = Invoke-RestMethod -Method Post -Uri "$base/phase1/runs/$runId/handoffs:generate?handoff_type=heavy" -Headers $headers
+
+$hid = $handoff.id
+$got = Invoke-RestMethod -Method Get -Uri "$base/handoffs/$hid" -Headers $headers
+
+if (-not $got.packet.version) { throw "handoff packet.version missing" }
+if (-not $got.packet.phases.phase1.run_id) { throw "handoff packet.phases.phase1.run_id missing" }
+if (-not $got.packet.phases.phase1.events) { throw "handoff packet.phases.phase1.events missing" }
+if (-not $got.packet.payload.summary_md) { throw "handoff packet.payload.summary_md missing" }
+
+$patched = Invoke-RestMethod -Method Patch -Uri "$base/handoffs/$hid" -Headers $headersJson -Body (@{
+  payload = @{ value_pool_notes = "edited by smoke" }
+} | ConvertTo-Json -Depth 10)
+if ($patched.packet.payload.value_pool_notes -ne "edited by smoke") { throw "handoff patch not applied" }
+
+# a client-built packet through POST /handoffs still gets the run's events attached
+$manual = Invoke-RestMethod -Method Post -Uri "$base/handoffs" -Headers $headersJson -Body (@{
   run_id = $runId
   handoff_type = "heavy"
   from_phase = 1
@@ -50,11 +78,9 @@ $handoff = Invoke-RestMethod -Method Post -Uri "$base/handoffs" -Headers $header
   }
 } | ConvertTo-Json -Depth 20)
 
-$hid = $handoff.id
-$got = Invoke-RestMethod -Method Get -Uri "$base/handoffs/$hid" -Headers $headers
-
-if (-not $got.packet.version) { throw "handoff packet.version missing" }
-if (-not $got.packet.phases.phase1.run_id) { throw "handoff packet.phases.phase1.run_id missing" }
-if (-not $got.packet.phases.phase1.events) { throw "handoff packet.phases.phase1.events missing" }
+$gotManual = Invoke-RestMethod -Method Get -Uri "$base/handoffs/$($manual.id)" -Headers $headers
+if (-not $gotManual.packet.version) { throw "manual handoff packet.version missing" }
+if (-not $gotManual.packet.phases.phase1.events) { throw "manual handoff packet.phases.phase1.events missing" }
+if ($gotManual.packet.payload.industry_scope -ne "smoke") { throw "manual handoff payload not kept" }
 
 Write-Host "[OK] smoke passed" -ForegroundColor Green
@@ -23,6 +23,16 @@ $run = Invoke-RestMethod -Method Post -Uri "$base/phase1/runs" -Headers $headers
 
 $runId = $run.run_id
 
+# runs execute in the background; poll until the executor finishes
+$deadline = (Get-Date).AddSeconds(60)
+do {
+  Start-Sleep -Milliseconds 500
+  $status = (Invoke-RestMethod -Method Get -Uri "$base/phase1/runs/$runId" -Headers $headers).status
+} while ($status -eq "running" -and (Get-Date) -lt $deadline)
+
+if ($status -eq "running") { throw "run still running after 60s" }
+Write-Host ("run status = " + $status)
+
 $events = Invoke-RestMethod -Method Get -Uri "$base/phase1/runs/$runId/events?limit=50" -Headers $headers
 
 $found = $false